@@ -0,0 +1,490 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmelicque/test-parser/emitter"
+	"github.com/bmelicque/test-parser/lint"
+	"github.com/bmelicque/test-parser/parser"
+	"github.com/bmelicque/test-parser/render"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type chunk struct {
+	parser.Program
+	path    string
+	shebang bool
+}
+
+const shebang = "#!/usr/bin/env node\n"
+
+// Parse, check and lint the entries and all files they depend on.
+// Programs are returned in compile order.
+func loadProgram(entries []string, levels lint.Config) ([]parser.Program, []parser.Diagnostic, error) {
+	programs := []parser.Program{}
+	diagnostics := []parser.Diagnostic{}
+	loaded := map[string]bool{}
+	for _, entry := range entries {
+		if _, err := os.Stat(entry); err != nil {
+			return nil, nil, err
+		}
+		files, _ := parser.GetCompileOrder(entry)
+		for _, f := range files {
+			if loaded[f.Path] {
+				continue
+			}
+			loaded[f.Path] = true
+			if parser.IsDeclarationFile(f.Path) {
+				_, found, err := parser.ParseDeclarationFile(f.Path)
+				if err == nil {
+					diagnostics = append(diagnostics, found...)
+				}
+				continue
+			}
+			program, errs, err := parser.ParseFile(f.Path)
+			if os.IsNotExist(err) {
+				// missing dependencies are reported by the importing file
+				continue
+			}
+			if err != nil {
+				diagnostics = append(diagnostics, parser.Diagnostic{
+					Severity: parser.ErrorSeverity,
+					Code:     "io",
+					Message:  fmt.Sprintf("Cannot read file: %v", err),
+					Location: parser.Location{Path: f.Path},
+				})
+				continue
+			}
+			programs = append(programs, program)
+			for _, err := range errs {
+				diagnostics = append(diagnostics, err.Diagnostic(f.Path))
+			}
+			diagnostics = append(diagnostics, lint.Run(program, levels)...)
+		}
+	}
+	return programs, diagnostics, nil
+}
+
+// Load the program and report its diagnostics, including kiwi.json's and
+// unused exports.
+// Returns errReported if there are errors.
+func check(o buildOptions) ([]parser.Program, *parser.Reachability, error) {
+	programs, diagnostics, err := loadProgram(o.entries, o.lint)
+	if err != nil {
+		return nil, nil, err
+	}
+	diagnostics = append(o.diagnostics, diagnostics...)
+	var reach *parser.Reachability
+	if !parser.HasErrors(diagnostics) {
+		reach = parser.AnalyzeReachability(programs, o.entries)
+		diagnostics = append(diagnostics, reach.UnusedExports()...)
+	}
+	if err := logDiagnostics(o.stderr, diagnostics, o.format); err != nil {
+		return nil, nil, err
+	}
+	if parser.HasErrors(diagnostics) {
+		return nil, nil, errReported
+	}
+	return programs, reach, nil
+}
+
+// Compile the program into outDir.
+// Returns the paths of the emitted entries.
+func build(o buildOptions) ([]string, error) {
+	programs, reach, err := check(o)
+	if err != nil {
+		return nil, err
+	}
+	options := emitter.Options{
+		Minify:    o.minify,
+		Extension: o.extension(),
+		Language:  emitter.ParseLanguage(o.language),
+	}
+	// declared exports must exist, even if the program doesn't use them
+	if o.treeShake && !o.declarations {
+		options.Reach = reach
+	}
+
+	if err := checkOutDir(o); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(o.outDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := emptyOutDir(o.outDir); err != nil {
+		return nil, err
+	}
+
+	var entriesOut []string
+	if o.bundle {
+		entriesOut, err = writeBundles(programs, options, o)
+	} else {
+		entriesOut, err = writeChunks(programs, options, o)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if o.shebang {
+		for _, entry := range entriesOut {
+			if err := os.Chmod(entry, 0755); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// scripts for Node.js are not loaded by a page
+	if o.target == "node" {
+		return entriesOut, nil
+	}
+	if _, err := os.Stat(o.html); err == nil {
+		if err := buildHtml(o.html, o.outDir, entriesOut); err != nil {
+			return nil, err
+		}
+	}
+	return entriesOut, nil
+}
+
+// Emit a .js (or .mjs) file per program, and a std file.
+// Returns the paths of the emitted entries.
+func writeChunks(programs []parser.Program, options emitter.Options, o buildOptions) ([]string, error) {
+	paths := make([]string, len(programs))
+	for i := range programs {
+		paths[i] = programs[i].Path()
+	}
+	baseDir := getCommonDir(paths)
+	ext := o.extension()
+	entriesOut := make([]string, len(o.entries))
+	isEntry := map[string]bool{}
+	for i, entry := range o.entries {
+		entriesOut[i] = getOutPath(baseDir, entry, o.outDir, ext)
+		isEntry[entriesOut[i]] = true
+	}
+
+	std := emitter.CreateStdName(baseDir, ext)
+	std = filepath.Join(o.outDir, std)
+	var flags emitter.StandardFlags
+	for _, program := range programs {
+		c := chunk{
+			Program: program,
+			path:    getOutPath(baseDir, program.Path(), o.outDir, ext),
+		}
+		c.shebang = o.shebang && isEntry[c.path]
+		f, err := writeChunk(c, std, options, o.sourceMap)
+		if err != nil {
+			return nil, err
+		}
+		flags |= f
+		if o.declarations {
+			if err := writeDeclarations(c, std, ext); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := emitter.EmitStd(std, flags, options.Language); err != nil {
+		return nil, err
+	}
+	if o.declarations {
+		declarations := []byte(emitter.GetStdDeclarations())
+		if err := os.WriteFile(getDeclarationsPath(std, ext), declarations, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return entriesOut, nil
+}
+
+// Emit a single .js (or .mjs) file per entry, with all files it depends on.
+// Returns the paths of the emitted bundles.
+func writeBundles(programs []parser.Program, options emitter.Options, o buildOptions) ([]string, error) {
+	byPath := map[string]parser.Program{}
+	for _, program := range programs {
+		byPath[program.Path()] = program
+	}
+	bundles := make([]string, len(o.entries))
+	for i, entry := range o.entries {
+		files, _ := parser.GetCompileOrder(entry)
+		bundled := []parser.Program{}
+		for _, f := range files {
+			if program, ok := byPath[f.Path]; ok {
+				bundled = append(bundled, program)
+			}
+		}
+		output, _ := emitter.EmitBundle(bundled, options)
+		name := filepath.Base(entry)
+		name = name[:len(name)-len(filepath.Ext(name))] + o.extension()
+		bundles[i] = filepath.Join(o.outDir, name)
+		if o.shebang {
+			output = shebang + output
+		}
+		if err := os.WriteFile(bundles[i], []byte(output), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return bundles, nil
+}
+
+// Emit the HTML template with a script for each entry
+func buildHtml(template string, outDir string, entries []string) error {
+	h, err := parseHtml(template)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		src, _ := filepath.Rel(outDir, entry)
+		if err := appendScript(h, filepath.ToSlash(src)); err != nil {
+			return fmt.Errorf("%v: %w", template, err)
+		}
+	}
+	return emitHtml(outDir, h)
+}
+
+func parseHtml(path string) (*html.Node, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := html.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %v: %w", path, err)
+	}
+	return doc, nil
+}
+
+func appendScript(n *html.Node, outName string) error {
+	h := findHtmlNode(n, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "head"
+	})
+	if h == nil {
+		return fmt.Errorf("could not find <head>")
+	}
+	scriptNode := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Lookup([]byte("script")),
+		Data:     "script",
+		Attr: []html.Attribute{
+			{Key: "type", Val: "module"},
+			{Key: "crossorigin"},
+			{Key: "src", Val: outName},
+		},
+	}
+	h.AppendChild(scriptNode)
+	return nil
+}
+
+// TODO: remove recursivity (use ParentNode if no NextSibling, beware no to go to parent of starting node)
+func findHtmlNode(n *html.Node, predicate func(*html.Node) bool) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if predicate(c) {
+			return c
+		}
+		if n := findHtmlNode(c, predicate); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+func emitHtml(outDir string, n *html.Node) error {
+	f, err := os.Create(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := html.Render(f, n); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Deepest directory containing all given files
+func getCommonDir(paths []string) string {
+	if len(paths) == 0 {
+		return "."
+	}
+	dir, _ := filepath.Abs(filepath.Dir(paths[0]))
+	for _, path := range paths[1:] {
+		path, _ = filepath.Abs(path)
+		for !isInDir(path, dir) && filepath.Dir(dir) != dir {
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
+
+func isInDir(path string, dir string) bool {
+	relative, err := filepath.Rel(dir, path)
+	return err == nil && !strings.HasPrefix(relative, "..")
+}
+
+// Output files keep the layout of source files, relative to baseDir
+func getOutPath(baseDir, filePath, outDir, extension string) string {
+	filePath, _ = filepath.Abs(filePath)
+	relative, _ := filepath.Rel(baseDir, filePath)
+	outFile := filepath.Join(outDir, relative)
+	ext := len(filepath.Ext(outFile))
+	return outFile[:len(outFile)-ext] + extension
+}
+
+func writeChunk(chunk chunk, stdPath string, options emitter.Options, sourceMap bool) (emitter.StandardFlags, error) {
+	if err := os.MkdirAll(filepath.Dir(chunk.path), 0755); err != nil {
+		return emitter.NoFlag, err
+	}
+	f, err := os.Create(chunk.path)
+	if err != nil {
+		return emitter.NoFlag, err
+	}
+	defer f.Close()
+
+	stdPath = getStdImportPath(chunk.path, stdPath)
+	output, mappings, flags := emitter.EmitProgramWithMappings(chunk.Program, options)
+	if chunk.shebang {
+		output = shebang + output
+		for i := range mappings {
+			mappings[i].GeneratedLine++
+		}
+	}
+	if _, err = f.WriteString(output); err != nil {
+		return flags, err
+	}
+	if flags != emitter.NoFlag {
+		_, err = f.WriteString("import * as __ from \"" + stdPath + filepath.Ext(chunk.path) + "\";\n")
+		if err != nil {
+			return flags, err
+		}
+	}
+	if sourceMap {
+		if err := writeSourceMap(chunk, mappings); err != nil {
+			return flags, err
+		}
+		mapName := filepath.Base(chunk.path) + ".map"
+		if _, err = f.WriteString("//# sourceMappingURL=" + mapName + "\n"); err != nil {
+			return flags, err
+		}
+	}
+	return flags, f.Sync()
+}
+
+// Path of the std file relative to the chunk, without extension
+func getStdImportPath(chunkPath string, stdPath string) string {
+	stdPath, _ = filepath.Rel(filepath.Dir(chunkPath), stdPath)
+	stdPath = strings.TrimSuffix(stdPath, filepath.Ext(stdPath))
+	stdPath = filepath.ToSlash(stdPath)
+	if stdPath[0] != '.' {
+		stdPath = "./" + stdPath
+	}
+	return stdPath
+}
+
+// Write the TypeScript declarations of a chunk next to it, as '<chunk>.d.ts'
+// (or '<chunk>.d.mts' for '.mjs' chunks)
+func writeDeclarations(chunk chunk, stdPath string, extension string) error {
+	declarations := emitter.EmitDeclarations(chunk.Program, getStdImportPath(chunk.path, stdPath), extension)
+	return os.WriteFile(getDeclarationsPath(chunk.path, extension), []byte(declarations), 0644)
+}
+
+func getDeclarationsPath(path string, extension string) string {
+	return strings.TrimSuffix(path, extension) + ".d" + strings.Replace(extension, "js", "ts", 1)
+}
+
+// Write the source map of a chunk next to it, as '<chunk>.js.map'
+func writeSourceMap(chunk chunk, mappings []emitter.Mapping) error {
+	content, err := os.ReadFile(chunk.Path())
+	if err != nil {
+		return err
+	}
+	source, _ := filepath.Abs(chunk.Path())
+	outDir, _ := filepath.Abs(filepath.Dir(chunk.path))
+	if relative, err := filepath.Rel(outDir, source); err == nil {
+		source = relative
+	}
+	m := emitter.NewSourceMap(
+		filepath.Base(chunk.path),
+		filepath.ToSlash(source),
+		string(content),
+		mappings,
+	)
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(chunk.path+".map", b, 0644)
+}
+
+// Write diagnostics to w, which is stderr so that they don't mix with the
+// output of programs started by 'kiwi run'
+func logDiagnostics(w io.Writer, diagnostics []parser.Diagnostic, format string) error {
+	if format == "json" {
+		b, err := json.MarshalIndent(diagnostics, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+		return nil
+	}
+	f, isFile := w.(*os.File)
+	r := render.NewRenderer(isFile && render.IsTerminal(f))
+	for _, d := range diagnostics {
+		r.Render(w, d)
+	}
+	return nil
+}
+
+// The out dir is emptied before writing the output, so it must not hold
+// the project's sources: the working directory, kiwi.json's directory and
+// the directories of entries and of the HTML template.
+func checkOutDir(o buildOptions) error {
+	out, err := filepath.Abs(o.outDir)
+	if err != nil {
+		return err
+	}
+	sources := []string{".", o.projectDir, filepath.Dir(o.html)}
+	for _, entry := range o.entries {
+		sources = append(sources, filepath.Dir(entry))
+	}
+	for _, dir := range sources {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if isInside(abs, out) {
+			return fmt.Errorf("cannot output to '%v', which contains source files in '%v'", o.outDir, dir)
+		}
+	}
+	return nil
+}
+
+// Check if path is dir or one of its descendants
+func isInside(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func emptyOutDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bmelicque/test-parser/format"
+	"github.com/bmelicque/test-parser/lsp"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func runBuild(args []string, stderr io.Writer) error {
+	fs := newFlagSet("build")
+	o := buildOptions{stderr: stderr}
+	addBuildFlags(fs, &o)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := o.load(fs, args); err != nil {
+		return err
+	}
+	_, err = build(o)
+	return err
+}
+
+func runCheck(args []string, stderr io.Writer) error {
+	fs := newFlagSet("check")
+	o := buildOptions{stderr: stderr}
+	addEntryFlag(fs, &o)
+	addTargetFlag(fs, &o)
+	addFormatFlag(fs, &o.format)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := o.load(fs, args); err != nil {
+		return err
+	}
+	_, _, err = check(o)
+	return err
+}
+
+// Build the program in a temporary directory, then run it with node
+func runRun(args []string, stderr io.Writer) error {
+	fs := newFlagSet("run")
+	o := buildOptions{stderr: stderr}
+	addBuildFlags(fs, &o)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := o.load(fs, args); err != nil {
+		return err
+	}
+	if len(o.entries) > 1 {
+		return usageError{"expected a single entry to run"}
+	}
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		return fmt.Errorf("cannot find 'node' in PATH, which is needed to run programs")
+	}
+	dir, err := os.MkdirTemp("", "kiwi-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	o.outDir = dir
+	entries, err := build(o)
+	if err != nil {
+		return err
+	}
+	// emitted files use ES modules
+	err = os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"type":"module"}`), 0644)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(node, entries[0])
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		os.RemoveAll(dir)
+		os.Exit(exit.ExitCode())
+	}
+	return err
+}
+
+func runFmt(args []string, stderr io.Writer) error {
+	fs := newFlagSet("fmt")
+	checkOnly := fs.Bool("check", false, "list unformatted files instead of rewriting them")
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return usageError{"missing files to format"}
+	}
+
+	unformatted := false
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted := format.Source(string(content))
+		if formatted == string(content) {
+			continue
+		}
+		if *checkOnly {
+			fmt.Println(path)
+			unformatted = true
+			continue
+		}
+		if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+			return err
+		}
+	}
+	if unformatted {
+		return errReported
+	}
+	return nil
+}
+
+const initEntry = `use log from "io"
+
+log("Hello, world!")
+`
+
+const initConfig = `{
+    "entries": ["main"],
+    "outDir": "dist",
+    "html": "index.html"
+}
+`
+
+const initHtml = `<!DOCTYPE html>
+<html>
+    <head>
+        <meta charset="utf-8">
+        <title>Kiwi</title>
+    </head>
+    <body></body>
+</html>
+`
+
+// Create a minimal project in the given directory (defaults to the current one)
+func runInit(args []string, stderr io.Writer) error {
+	fs := newFlagSet("init")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		return usageError{fmt.Sprintf("unexpected argument '%v'", args[1])}
+	}
+
+	files := map[string]string{
+		filepath.Join(dir, parser.ConfigName): initConfig,
+		filepath.Join(dir, "main"):            initEntry,
+		filepath.Join(dir, "index.html"):      initHtml,
+	}
+	for path := range files {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%v already exists", path)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Created project in %v, build it with 'kiwi build' from there\n", dir)
+	return nil
+}
+
+func runLsp(args []string, stderr io.Writer) error {
+	fs := newFlagSet("lsp")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return usageError{fmt.Sprintf("unexpected argument '%v'", args[0])}
+	}
+	return lsp.Serve(os.Stdin, os.Stdout)
+}
+
+func runVersion(args []string, stderr io.Writer) error {
+	fs := newFlagSet("version")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return usageError{fmt.Sprintf("unexpected argument '%v'", args[0])}
+	}
+	fmt.Printf("kiwi %v\n", version)
+	return nil
+}
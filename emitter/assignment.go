@@ -3,13 +3,20 @@ package emitter
 import (
 	"fmt"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
 func needsCopy(expr parser.Expression) bool {
-	switch expr.Type().(type) {
+	switch t := expr.Type().(type) {
 	case parser.Void, parser.Number, parser.Boolean, parser.String, parser.Function:
 		return false
+	case parser.TypeAlias:
+		// values of JavaScript libraries are shared, as they may not be
+		// cloneable
+		if parser.IsDeclarationFile(t.From) {
+			return false
+		}
 	}
 
 	switch expr := expr.(type) {
@@ -27,51 +34,89 @@ func needsCopy(expr parser.Expression) bool {
 	return false
 }
 
-func emitAssign(e *Emitter, a *parser.Assignment) {
-	e.emitExpression(a.Pattern)
+// Emit the value of an assignment, copied if it could be shared
+func emitAssignedValue(e *Emitter, a *parser.Assignment) js.Expression {
+	value := e.emitExpression(a.Value)
+	if implementsNode(a.Value.Type()) {
+		return js.Call(js.Dot(value, "cloneNode"), &js.Literal{Raw: "true"})
+	}
+	if needsCopy(a.Value) {
+		return js.Call(js.Name("structuredClone"), value)
+	}
+	return value
+}
 
+var assignmentOperators = map[parser.TokenKind]string{
+	parser.Assign:           "=",
+	parser.Declare:          "=",
+	parser.AddAssign:        "+=",
+	parser.ConcatAssign:     "+=",
+	parser.SubAssign:        "-=",
+	parser.MulAssign:        "*=",
+	parser.DivAssign:        "/=",
+	parser.ModAssign:        "%=",
+	parser.LogicalAndAssign: "&&=",
+	parser.LogicalOrAssign:  "||=",
+}
+
+func emitAssign(e *Emitter, a *parser.Assignment) js.Expression {
+	pattern := e.emitExpression(a.Pattern)
+	operator := assignmentOperators[a.Operator.Kind()]
+	value := emitAssignedValue(e, a)
 	switch a.Operator.Kind() {
-	case parser.Assign, parser.Declare:
-		e.write(" = ")
-	case parser.AddAssign, parser.ConcatAssign:
-		e.write(" += ")
-	case parser.SubAssign:
-		e.write(" -= ")
-	case parser.MulAssign:
-		e.write(" *= ")
-	case parser.DivAssign:
-		e.write(" /= ")
-	case parser.ModAssign:
-		e.write(" %= ")
-	case parser.LogicalAndAssign:
-		e.write(" &&= ")
-	case parser.LogicalOrAssign:
-		e.write(" ||= ")
+	case parser.LogicalAndAssign, parser.LogicalOrAssign:
+		// logical assignments are from ES2021, they are lowered to 'a || (a = b)'
+		if !e.language.has(ESNext) {
+			read, write := hoistOperands(e, pattern)
+			return &js.BinaryExpression{
+				Operator: operator[:2],
+				Left:     read,
+				Right:    js.Assign(write, value),
+			}
+		}
 	}
+	return &js.AssignmentExpression{Operator: operator, Left: pattern, Right: value}
+}
 
-	if implementsNode(a.Value.Type()) {
-		e.emitExpression(a.Value)
-		e.write(".cloneNode(true)")
-	} else if needsCopy(a.Value) {
-		e.write("structuredClone(")
-		e.emitExpression(a.Value)
-		e.write(")")
-	} else {
-		e.emitExpression(a.Value)
+// Store the object and the computed property of a member expression in
+// temporaries, so that they are evaluated once when the member is both read
+// and written.
+func hoistOperands(e *Emitter, pattern js.Expression) (read js.Expression, write js.Expression) {
+	switch pattern := pattern.(type) {
+	case *js.Identifier:
+		return pattern, js.Name(pattern.Name)
+	case *js.MemberExpression:
+		hoisted := *pattern
+		hoisted.Object = hoistOperand(e, pattern.Object)
+		if pattern.Computed {
+			hoisted.Property = hoistOperand(e, pattern.Property)
+		}
+		copied := hoisted
+		return &hoisted, &copied
+	default:
+		return pattern, pattern
+	}
+}
+func hoistOperand(e *Emitter, operand js.Expression) js.Expression {
+	switch operand.(type) {
+	case *js.Identifier, *js.Literal:
+		return operand
 	}
-	e.write(";\n")
+	name := js.Name(fmt.Sprintf("__ref%v", e.references))
+	e.references++
+	e.add(js.Declare("const", name, operand))
+	return name
 }
+
 func (e *Emitter) emitAssignment(a *parser.Assignment, isTopLevel bool) {
 	switch a.Operator.Kind() {
 	case parser.Assign:
 		if u, ok := a.Pattern.(*parser.UnaryExpression); ok {
 			// deref
-			e.emitExpression(u.Operand)
-			e.write("(0, ")
-			e.emitExpression(a.Value)
-			e.write(")")
+			set := js.Call(e.emitExpression(u.Operand), &js.Literal{Raw: "0"}, e.emitExpression(a.Value))
+			e.add(&js.ExpressionStatement{Expression: set})
 		} else {
-			emitAssign(e, a)
+			e.add(&js.ExpressionStatement{Expression: emitAssign(e, a)})
 		}
 	case parser.AddAssign,
 		parser.ConcatAssign,
@@ -81,7 +126,7 @@ func (e *Emitter) emitAssignment(a *parser.Assignment, isTopLevel bool) {
 		parser.ModAssign,
 		parser.LogicalAndAssign,
 		parser.LogicalOrAssign:
-		emitAssign(e, a)
+		e.add(&js.ExpressionStatement{Expression: emitAssign(e, a)})
 	case parser.Declare:
 		e.emitDeclaration(a, isTopLevel)
 	case parser.Define:
@@ -94,40 +139,37 @@ func (e *Emitter) emitAssignment(a *parser.Assignment, isTopLevel bool) {
 			return
 		}
 
-		if needsExport(a.Pattern) {
-			e.write("export ")
-		}
-		e.write("const ")
-		e.emitExpression(a.Pattern)
-		e.write(" = ")
-		e.emitExpression(a.Value)
+		declaration := js.Declare("const", e.emitExpression(a.Pattern), e.emitExpression(a.Value))
+		declaration.Export = e.needsExport(a.Pattern)
+		e.add(declaration)
 	}
 }
 
 func (e *Emitter) emitDeclaration(a *parser.Assignment, isTopLevel bool) {
-	if needsExport(a.Pattern) && isTopLevel {
-		e.write("export ")
-	}
-	if i, ok := a.Pattern.(*parser.Identifier); !ok || !isReferenced(i) {
-		e.write("let ")
+	if i, ok := a.Pattern.(*parser.Identifier); ok && isReferenced(i) {
+		// declared in the scope object
+		e.add(&js.ExpressionStatement{Expression: emitAssign(e, a)})
+		return
 	}
-	emitAssign(e, a)
+	declaration := js.Declare("let", e.emitExpression(a.Pattern), emitAssignedValue(e, a))
+	declaration.Export = e.needsExport(a.Pattern) && isTopLevel
+	e.add(declaration)
 }
 
-func (e *Emitter) emitObjectConstructorParam(n parser.Node) {
+func (e *Emitter) emitObjectConstructorParam(n parser.Node) js.Expression {
 	switch n := n.(type) {
 	case *parser.Identifier:
-		e.emitIdentifier(n)
+		return e.emitIdentifier(n)
 	case *parser.Param:
-		e.emitIdentifier(n.Identifier)
+		return e.emitIdentifier(n.Identifier)
 	case *parser.Entry:
-		e.emitIdentifier(n.Key.(*parser.Identifier))
-		e.write(" = ")
-		e.emitExpression(n.Value)
+		return js.Assign(e.emitIdentifier(n.Key.(*parser.Identifier)), e.emitExpression(n.Value))
+	default:
+		panic("unexpected object member")
 	}
 }
 
-func (e *Emitter) emitObjectConstructorStatement(n parser.Node) {
+func (e *Emitter) emitObjectConstructorStatement(n parser.Node) js.Statement {
 	var name string
 	switch n := n.(type) {
 	case *parser.Identifier:
@@ -139,49 +181,31 @@ func (e *Emitter) emitObjectConstructorStatement(n parser.Node) {
 	case *parser.Entry:
 		name = getSanitizedName(n.Key.(*parser.Identifier).Text())
 	}
-	e.indent()
-	e.write(fmt.Sprintf("this.%v = %v;\n", name, name))
+	return &js.ExpressionStatement{Expression: js.Assign(js.Dot(js.Name("this"), name), js.Name(name))}
 }
 
 func (e *Emitter) emitObjectTypeDefinition(definition *parser.Assignment) {
-	if needsExport(definition.Pattern) {
-		e.write("export ")
+	class := &js.ClassDeclaration{
+		Export: e.needsExport(definition.Pattern),
+		Name:   e.getTypeIdentifier(definition.Pattern),
 	}
 	b := definition.Value.(*parser.BracedExpression)
 	elements := b.Expr.(*parser.TupleExpression).Elements
 	if len(elements) == 0 {
-		e.write("class ")
-		e.write(getTypeIdentifier(definition.Pattern))
-		e.write(" {}\n")
+		e.add(class)
 		return
 	}
 
-	e.write("class ")
-	e.write(getTypeIdentifier(definition.Pattern))
-	e.write(" {\n")
-
-	e.depth++
-	e.indent()
-	e.write("constructor(")
+	constructor := &js.Method{Name: "constructor", Body: &js.BlockStatement{}}
 	elements = sortObjectElements(elements)
-	max := len(elements) - 1
-	for _, s := range elements[:max] {
-		e.emitObjectConstructorParam(s)
-		e.write(", ")
+	for _, s := range elements {
+		constructor.Params = append(constructor.Params, e.emitObjectConstructorParam(s))
 	}
-	e.emitObjectConstructorParam(elements[max])
-	e.write(") {\n")
-
-	e.depth++
 	for _, s := range elements {
-		e.emitObjectConstructorStatement(s)
+		constructor.Body.Body = append(constructor.Body.Body, e.emitObjectConstructorStatement(s))
 	}
-	e.depth--
-	e.indent()
-	e.write("}\n")
-	e.depth--
-	e.indent()
-	e.write("}\n")
+	class.Methods = []*js.Method{constructor}
+	e.add(class)
 }
 func sortObjectElements(elements []parser.Expression) []parser.Expression {
 	sorted := make([]parser.Expression, len(elements))
@@ -213,15 +237,14 @@ func (e *Emitter) emitTypeDeclaration(definition *parser.Assignment) {
 		return
 	}
 	switch definition.Value.Type().(parser.Type).Value.(type) {
-	case parser.Trait:
+	case parser.Trait, parser.Newtype:
 		return
 	case parser.Sum:
-		if needsExport(definition.Pattern) {
-			e.write("export ")
-		}
-		e.write("class ")
-		e.write(getTypeIdentifier(definition.Pattern))
-		e.write(" extends __.Sum {}\n")
+		e.add(&js.ClassDeclaration{
+			Export:  e.needsExport(definition.Pattern),
+			Name:    e.getTypeIdentifier(definition.Pattern),
+			Extends: js.Dot(js.Name("__"), "Sum"),
+		})
 		e.addFlag(SumFlag)
 		return
 	}
@@ -230,29 +253,55 @@ func (e *Emitter) emitTypeDeclaration(definition *parser.Assignment) {
 func (e *Emitter) emitMethodDeclaration(a *parser.Assignment) {
 	pattern := a.Pattern.(*parser.PropertyAccessExpression)
 	receiver := pattern.Expr.(*parser.ParenthesizedExpression).Expr.(*parser.Param)
+	if isNewtype(receiver.Complement.Type()) {
+		e.emitNewtypeMethodDeclaration(a)
+		return
+	}
 
-	e.emitExpression(receiver.Complement)
-	e.write(".prototype.")
-	e.emitExpression(pattern.Property)
-	e.write(" = function ")
+	prototype := js.Dot(e.emitExpression(receiver.Complement), "prototype")
+	method := &js.MemberExpression{Object: prototype, Property: e.emitExpression(pattern.Property)}
 
 	e.thisName = receiver.Identifier.Text()
 	defer func() { e.thisName = "" }()
 
 	init := a.Value.(*parser.FunctionExpression)
-	e.write("(")
-	params := init.Params.Expr.(*parser.TupleExpression).Elements
-	max := len(params)
-	if max > 0 {
-		for i := range params[:max] {
-			param := params[i].(*parser.Param)
-			e.emitIdentifier(param.Identifier)
-			e.write(", ")
-		}
-		e.emitIdentifier(params[max].(*parser.Param).Identifier)
+	params := init.Params.Expr.(*parser.TupleExpression)
+	function := &js.FunctionExpression{
+		Params: emitMethodParams(e, params.Elements),
+		Body:   e.emitFunctionBody(init.Body, params),
 	}
-	e.write(") ")
-	e.emitFunctionBody(init.Body, init.Params.Expr.(*parser.TupleExpression))
+	e.add(&js.ExpressionStatement{Expression: js.Assign(method, function)})
+}
+
+// Newtypes have no runtime representation, so their methods are stored
+// in a plain object named after the type, and take the receiver as first param.
+func (e *Emitter) emitNewtypeMethodDeclaration(a *parser.Assignment) {
+	pattern := a.Pattern.(*parser.PropertyAccessExpression)
+	receiver := pattern.Expr.(*parser.ParenthesizedExpression).Expr.(*parser.Param)
+	name := e.getTypeIdentifier(receiver.Complement)
+	if !e.newtypes[name] {
+		holder := js.Declare("const", js.Name(name), &js.ObjectExpression{})
+		holder.Export = e.needsExport(receiver.Complement)
+		e.add(holder)
+		e.newtypes[name] = true
+	}
+
+	method := &js.MemberExpression{Object: js.Name(name), Property: e.emitExpression(pattern.Property)}
+	init := a.Value.(*parser.FunctionExpression)
+	params := init.Params.Expr.(*parser.TupleExpression)
+	function := &js.FunctionExpression{
+		Params: append([]js.Expression{e.emitIdentifier(receiver.Identifier)}, emitMethodParams(e, params.Elements)...),
+		Body:   e.emitFunctionBody(init.Body, params),
+	}
+	e.add(&js.ExpressionStatement{Expression: js.Assign(method, function)})
+}
+
+func emitMethodParams(e *Emitter, params []parser.Expression) []js.Expression {
+	emitted := make([]js.Expression, len(params))
+	for i, param := range params {
+		emitted[i] = e.emitIdentifier(param.(*parser.Param).Identifier)
+	}
+	return emitted
 }
 
 func isTypePattern(expr parser.Expression) bool {
@@ -266,28 +315,36 @@ func isTypePattern(expr parser.Expression) bool {
 	}
 	return identifier.IsType()
 }
-func getTypeIdentifier(expr parser.Node) string {
+func (e *Emitter) getTypeIdentifier(expr parser.Node) string {
 	c, ok := expr.(*parser.ComputedAccessExpression)
 	if ok {
 		expr = c.Expr
 	}
 	identifier := expr.(*parser.Identifier)
+	if e.bundle != nil && e.topLevel.FindLocal(identifier.Text()) != nil {
+		return e.getTopLevelName(identifier.Text())
+	}
 	return identifier.Text()
 }
 
-func needsExport(pattern parser.Expression) bool {
+// Bundles are single modules, so nothing is exported
+func (e *Emitter) needsExport(pattern parser.Expression) bool {
+	return e.bundle == nil && isPublicPattern(pattern)
+}
+
+func isPublicPattern(pattern parser.Expression) bool {
 	switch pattern := pattern.(type) {
 	case *parser.Identifier:
 		return !pattern.IsPrivate()
 	case *parser.TupleExpression:
 		for _, el := range pattern.Elements {
-			if needsExport(el) {
+			if isPublicPattern(el) {
 				return true
 			}
 		}
 		return false
 	case *parser.ComputedAccessExpression:
-		return needsExport(pattern.Expr)
+		return isPublicPattern(pattern.Expr)
 	default:
 		panic("Case not handled!")
 	}
@@ -18,7 +18,7 @@ func TestInderectAssignment(t *testing.T) {
 	source += "ref := &i\n"
 	source += "*ref = 42"
 
-	expected := "ref(0, 42)"
+	expected := "ref(0, 42);\n"
 
 	testEmitter(t, source, expected, 2)
 }
@@ -61,3 +61,40 @@ func TestMethodDefinition(t *testing.T) {
 	expected += "}\n"
 	testEmitter(t, source, expected, 1)
 }
+
+func TestNewtypeDefinition(t *testing.T) {
+	testEmitter(t, "UserId :: new number\nUserId{42}", "", 0)
+}
+
+func TestNewtypeMethodDefinition(t *testing.T) {
+	source := "UserId :: new number\n"
+	source += "(id UserId).add :: (n number) => { UserId{id.value + n} }"
+	expected := "export const UserId = {};\n"
+	expected += "UserId.add = function (id, n) {\n"
+	expected += "    return id + n;\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 1)
+}
+
+func TestLoweredLogicalAssignment(t *testing.T) {
+	source := "Box :: { flag boolean }\n"
+	source += "Outer :: { box Box }\n"
+	source += "_set :: (o Outer) => {\n"
+	source += "    o.box.flag ||= true\n"
+	source += "    o.box.flag &&= false\n"
+	source += "}\n"
+	program := parseTestProgram(t, source)
+	emitter := makeEmitter()
+	emitter.language = ES2020
+	emitter.emit(program.Nodes()[2])
+
+	expected := "const _set = (o) => {\n"
+	expected += "    const __ref0 = o.box;\n"
+	expected += "    __ref0.flag || (__ref0.flag = true);\n"
+	expected += "    const __ref1 = o.box;\n"
+	expected += "    __ref1.flag && (__ref1.flag = false);\n"
+	expected += "}\n"
+	if received := emitter.string(); received != expected {
+		t.Fatalf("expected output:\n%v\n\ngot:\n%v", expected, received)
+	}
+}
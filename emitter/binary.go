@@ -1,53 +1,130 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
-func (e *Emitter) emitBinaryExpression(expr *parser.BinaryExpression) {
-	if expr.Operator.Kind() == parser.Equal {
-		if e.emitComparison(expr) {
-			return
+func (e *Emitter) emitBinaryExpression(expr *parser.BinaryExpression) js.Expression {
+	if e.minify {
+		if folded, ok := foldConstant(expr); ok {
+			return folded
 		}
 	}
-
-	precedence := Precedence(expr)
-	if expr.Left != nil {
-		left := Precedence(expr.Left)
-		if left < precedence {
-			e.write("(")
-		}
-		e.emitExpression(expr.Left)
-		if left < precedence {
-			e.write(")")
+	if kind := expr.Operator.Kind(); kind == parser.Equal || kind == parser.NotEqual {
+		if comparison, ok := e.emitComparison(expr); ok {
+			return comparison
 		}
 	}
-
-	e.write(" ")
-	e.write(expr.Operator.Text())
-	e.write(" ")
-
-	if expr.Right != nil {
-		right := Precedence(expr.Right)
-		if right < precedence {
-			e.write("(")
-		}
-		e.emitExpression(expr.Right)
-		if right < precedence {
-			e.write(")")
+	if expr.Operator.Kind() == parser.IsKeyword {
+		t := expr.Right.Type().(parser.Type).Value
+		return emitTypeTest(e, func() js.Expression { return e.emitExpression(expr.Left) }, t)
+	}
+	// '**' is from ES2016
+	if expr.Operator.Kind() == parser.Pow && !e.language.has(ES2018) {
+		pow := js.Dot(js.Name("Math"), "pow")
+		return js.Call(pow, e.emitExpression(expr.Left), e.emitExpression(expr.Right))
+	}
+	if expr.Left == nil {
+		return &js.UnaryExpression{
+			Operator: expr.Operator.Text(),
+			Argument: e.emitExpression(expr.Right),
 		}
 	}
+	return &js.BinaryExpression{
+		Operator: expr.Operator.Text(),
+		Left:     e.emitExpression(expr.Left),
+		Right:    e.emitExpression(expr.Right),
+	}
 }
 
-// returns true if wrote something
-func (e *Emitter) emitComparison(expr *parser.BinaryExpression) bool {
+// Compare values which are not primitives by content
+func (e *Emitter) emitComparison(expr *parser.BinaryExpression) (js.Expression, bool) {
 	switch expr.Left.Type().(type) {
 	case parser.List, parser.Ref, parser.Trait, parser.TypeAlias:
 		e.addFlag(DeepEqualFlag)
-		e.write("__.equals(")
-		e.emitExpression(expr.Left)
-		e.write(", ")
-		e.emitExpression(expr.Right)
-		e.write(")")
-		return true
-	}
-	return false
+		equals := js.Dot(js.Name("__"), "equals")
+		comparison := js.Call(equals, e.emitExpression(expr.Left), e.emitExpression(expr.Right))
+		if expr.Operator.Kind() == parser.NotEqual {
+			return &js.UnaryExpression{Operator: "!", Argument: comparison}, true
+		}
+		return comparison, true
+	}
+	return nil, false
+}
+
+// Emit a runtime check that the subject holds a value of the given type.
+// Union members are not wrapped, so checks rely on 'typeof' and 'instanceof'.
+func emitTypeTest(e *Emitter, emitSubject func() js.Expression, t parser.ExpressionType) js.Expression {
+	switch t := t.(type) {
+	case parser.Boolean:
+		return emitTypeof(emitSubject, "boolean")
+	case parser.Number:
+		return emitTypeof(emitSubject, "number")
+	case parser.String:
+		return emitTypeof(emitSubject, "string")
+	case parser.Function:
+		return emitTypeof(emitSubject, "function")
+	case parser.Void:
+		return strictEqual(emitSubject(), js.Name("undefined"))
+	case parser.List, parser.Tuple:
+		return js.Call(js.Dot(js.Name("Array"), "isArray"), emitSubject())
+	case parser.Map:
+		return emitInstanceof(emitSubject, js.Name("Map"))
+	case parser.Ref:
+		if implementsNode(t.To) {
+			e.addFlag(NodePointerFlag)
+			return emitInstanceof(emitSubject, js.Dot(js.Name("__"), "NodePointer"))
+		}
+		e.addFlag(PointerFlag)
+		return emitInstanceof(emitSubject, js.Dot(js.Name("__"), "Pointer"))
+	case parser.LiteralType:
+		return strictEqual(emitSubject(), &js.Literal{Raw: t.Value})
+	case parser.Union:
+		var test js.Expression
+		for i, member := range t.Members {
+			if i == 0 {
+				test = emitTypeTest(e, emitSubject, member)
+				continue
+			}
+			test = &js.BinaryExpression{
+				Operator: "||",
+				Left:     test,
+				Right:    emitTypeTest(e, emitSubject, member),
+			}
+		}
+		return test
+	case parser.TypeAlias:
+		return emitAliasTest(e, emitSubject, t)
+	default:
+		return &js.Literal{Raw: "true"}
+	}
+}
+func emitAliasTest(e *Emitter, emitSubject func() js.Expression, t parser.TypeAlias) js.Expression {
+	switch t.Name {
+	case "?":
+		e.addFlag(OptionFlag)
+		return emitInstanceof(emitSubject, js.Dot(js.Name("__"), "Option"))
+	case "#":
+		return emitInstanceof(emitSubject, js.Name("Map"))
+	case "...":
+		return emitInstanceof(emitSubject, js.Name("Promise"))
+	default:
+		switch t.Ref.(type) {
+		case parser.Object, parser.Sum:
+			return emitInstanceof(emitSubject, js.Name(t.Name))
+		default:
+			return emitTypeTest(e, emitSubject, t.Ref)
+		}
+	}
+}
+func emitTypeof(emitSubject func() js.Expression, name string) js.Expression {
+	typeOf := &js.UnaryExpression{Operator: "typeof", Argument: emitSubject()}
+	return strictEqual(typeOf, js.String(name))
+}
+func emitInstanceof(emitSubject func() js.Expression, class js.Expression) js.Expression {
+	return &js.BinaryExpression{Operator: "instanceof", Left: emitSubject(), Right: class}
+}
+func strictEqual(left js.Expression, right js.Expression) js.Expression {
+	return &js.BinaryExpression{Operator: "===", Left: left, Right: right}
 }
@@ -17,3 +17,19 @@ func TestEmitRefComparison(t *testing.T) {
 	expected := "__.equals(a, b);\n"
 	testEmitter(t, source, expected, 3)
 }
+
+func TestEmitTypeTest(t *testing.T) {
+	source := "_f :: (x number | string) => { x is number }"
+	expected := "const _f = (x) => {\n"
+	expected += "    return typeof x === \"number\";\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitLiteralTypeTest(t *testing.T) {
+	source := "_f :: (x number | string) => { x is (\"a\" | 42) }"
+	expected := "const _f = (x) => {\n"
+	expected += "    return x === \"a\" || x === 42;\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
@@ -3,59 +3,51 @@ package emitter
 import (
 	"fmt"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
-func emitScope(e *Emitter, scope *parser.Scope) {
-	e.write(fmt.Sprintf("__s%v", scope.GetId()))
+func emitScope(e *Emitter, scope *parser.Scope) *js.Identifier {
+	if e.minify {
+		// '$' cannot appear in source names
+		return js.Name("$" + shortName(scope.GetId()))
+	}
+	return js.Name(fmt.Sprintf("__s%v", scope.GetId()))
 }
 
 func (e *Emitter) emitBlockStatement(b *parser.Block) {
-	e.write("{")
-	if len(b.Statements) == 0 {
-		e.write("}")
-		return
-	}
-	e.write("\n")
-	e.depth++
-	if b.Scope().HasReferencedVars() {
-		e.indent()
-		e.write("const ")
-		emitScope(e, b.Scope())
-		e.write(" = {};\n")
-	}
-	for _, statement := range b.Statements {
-		e.indent()
-		e.emit(statement)
-	}
-	e.depth--
-	e.indent()
-	e.write("}\n")
+	e.add(e.emitBlock(b))
+}
+
+func (e *Emitter) emitBlock(b *parser.Block) *js.BlockStatement {
+	return e.block(func() {
+		if len(b.Statements) == 0 {
+			return
+		}
+		if b.Scope().HasReferencedVars() {
+			e.add(js.Declare("const", emitScope(e, b.Scope()), &js.ObjectExpression{}))
+		}
+		for _, statement := range b.Statements {
+			e.emit(statement)
+		}
+	})
 }
 
-func (e *Emitter) emitBlockExpression(b *parser.Block) {
+func (e *Emitter) emitBlockExpression(b *parser.Block) js.Expression {
 	if id, ok := e.uninlinables[b]; ok {
-		e.write(fmt.Sprintf("__tmp%v", id))
 		delete(e.uninlinables, b)
-		return
+		return getTemporary(id)
 	}
 
 	if len(b.Statements) == 0 {
-		e.write("undefined")
-		return
+		return js.Name("undefined")
 	}
 	if len(b.Statements) == 1 {
-		e.emitExpression(b.Statements[0].(parser.Expression))
-		return
+		return e.emitExpression(b.Statements[0].(parser.Expression))
 	}
-	e.write("(\n")
-	e.depth += 1
+	sequence := &js.SequenceExpression{}
 	for _, statement := range b.Statements {
-		e.indent()
-		e.emitExpression(statement.(parser.Expression))
-		e.write(",\n")
+		sequence.Expressions = append(sequence.Expressions, e.emitExpression(statement.(parser.Expression)))
 	}
-	e.depth -= 1
-	e.indent()
-	e.write(")")
+	return sequence
 }
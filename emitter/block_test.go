@@ -3,14 +3,15 @@ package emitter
 import (
 	"testing"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
 func TestEmptyBlockExpression(t *testing.T) {
 	emitter := makeEmitter()
-	emitter.emitExpression(&parser.Block{})
+	expr := emitter.emitExpression(&parser.Block{})
 
-	text := emitter.string()
+	text := js.Print(expr, js.Options{})
 	expected := "undefined"
 	if text != expected {
 		t.Fatalf("Expected string:\n%v\ngot:\n%v", expected, text)
@@ -19,11 +20,11 @@ func TestEmptyBlockExpression(t *testing.T) {
 
 func TestSingleLineBlockExpression(t *testing.T) {
 	emitter := makeEmitter()
-	emitter.emitExpression(&parser.Block{Statements: []parser.Node{
+	expr := emitter.emitExpression(&parser.Block{Statements: []parser.Node{
 		&parser.Literal{Token: testToken{kind: parser.NumberLiteral, value: "42"}},
 	}})
 
-	text := emitter.string()
+	text := js.Print(expr, js.Options{})
 	expected := "42"
 	if text != expected {
 		t.Fatalf("Expected string:\n%v\ngot:\n%v", expected, text)
@@ -32,13 +33,13 @@ func TestSingleLineBlockExpression(t *testing.T) {
 
 func TestBlockExpression(t *testing.T) {
 	emitter := makeEmitter()
-	emitter.emitExpression(&parser.Block{Statements: []parser.Node{
+	expr := emitter.emitExpression(&parser.Block{Statements: []parser.Node{
 		&parser.Literal{Token: testToken{kind: parser.NumberLiteral, value: "42"}},
 		&parser.Literal{Token: testToken{kind: parser.NumberLiteral, value: "42"}},
 	}})
 
-	text := emitter.string()
-	expected := "(\n    42,\n    42,\n)"
+	text := js.Print(expr, js.Options{})
+	expected := "42, 42"
 	if text != expected {
 		t.Fatalf("Expected string:\n%v\ngot:\n%v", expected, text)
 	}
@@ -0,0 +1,150 @@
+package emitter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Emit programs (expected in compile order) as a single ES module.
+//
+// Modules are hoisted in the same scope: top-level names are renamed when
+// they collide, and 'use' directives of bundled files become local bindings.
+// Needed std helpers are inlined.
+func EmitBundle(programs []parser.Program, options Options) (string, StandardFlags) {
+	b := bundler{
+		names:   map[string]map[string]string{},
+		exports: map[string][]string{},
+		taken:   map[string]bool{},
+	}
+	var code strings.Builder
+	var flags StandardFlags
+	for _, program := range programs {
+		e := makeEmitter()
+		e.bundle = &b
+		e.names = b.nameModule(program)
+		e.topLevel = program.Scope()
+		e.reach = options.Reach
+		e.minify = options.Minify
+		e.language = options.Language
+		e.emitProgram(program)
+		code.WriteString(e.string())
+		flags |= e.flags
+	}
+	return GetInlinedStd(flags, options.Language) + code.String(), flags
+}
+
+type bundler struct {
+	names   map[string]map[string]string // bundled names by source name, by module path
+	exports map[string][]string          // public names declared by each module
+	taken   map[string]bool              // bundled names in use
+}
+
+// Pick bundled names for the top-level names of a program.
+// Names used from other bundled modules keep the name they were given there.
+func (b *bundler) nameModule(program parser.Program) map[string]string {
+	names := map[string]string{}
+	used := map[string]bool{}
+	imported := map[string]string{}
+	for _, node := range program.Nodes() {
+		u, ok := node.(*parser.UseDirective)
+		if !ok || u.Names == nil {
+			continue
+		}
+		module, bundled := b.getUsedModule(program.Path(), u)
+		for _, name := range getUsedNames(u.Names) {
+			used[name] = true
+			if bundled && !u.Star {
+				imported[name] = module[name]
+			}
+		}
+	}
+
+	exports := []string{}
+	for _, name := range program.Scope().Names() {
+		if bundled, ok := imported[name]; ok {
+			names[name] = bundled
+			continue
+		}
+		names[name] = b.pick(name)
+		if !used[name] && name[0] != '_' {
+			exports = append(exports, name)
+		}
+	}
+	b.names[program.Path()] = names
+	b.exports[program.Path()] = exports
+	return names
+}
+
+// Bundled names of the module used by the directive, if it is bundled
+func (b *bundler) getUsedModule(from string, u *parser.UseDirective) (map[string]string, bool) {
+	if u.Source == nil {
+		return nil, false
+	}
+	source := u.Source.Text()
+	source = source[1 : len(source)-1]
+	path, ok := parser.ResolvePath(from, source)
+	if !ok {
+		return nil, false
+	}
+	module, ok := b.names[path]
+	return module, ok
+}
+
+func (b *bundler) pick(name string) string {
+	bundled := getSanitizedName(name)
+	// '$' cannot appear in source names, so renamed ones never collide with them
+	for i := 1; b.taken[bundled]; i++ {
+		bundled = fmt.Sprintf("%v$%v", name, i)
+	}
+	b.taken[bundled] = true
+	return bundled
+}
+
+// Get the bundled name of an identifier, if it refers to a top-level name
+func (e *Emitter) getBundledName(i *parser.Identifier) (string, bool) {
+	if e.bundle == nil {
+		return "", false
+	}
+	name, ok := e.names[i.Text()]
+	if !ok {
+		return "", false
+	}
+	if scope := i.GetScope(); scope != nil {
+		return name, scope == e.topLevel
+	}
+	// declarations are not resolved by the checker
+	v := e.topLevel.FindLocal(i.Text())
+	return name, v != nil && v.DeclaredAt() == i.Loc()
+}
+
+// Name of the top-level variable in the emitted code
+func (e *Emitter) getTopLevelName(name string) string {
+	if bundled, ok := e.names[name]; ok {
+		return bundled
+	}
+	return getSanitizedName(name)
+}
+
+// Bundled modules are already in scope: only 'use * as x' needs a binding,
+// which is an object of the module's public names.
+func (e *Emitter) emitBundledUse(u *parser.UseDirective, path string) {
+	if !u.Star {
+		return
+	}
+	module := e.bundle.names[path]
+	object := &js.ObjectExpression{}
+	for _, name := range e.bundle.exports[path] {
+		if e.reach != nil && !e.reach.IsUsed(path, name) {
+			continue
+		}
+		property := &js.Property{Key: name}
+		if bundled := module[name]; bundled != name {
+			property.Value = js.Name(bundled)
+		}
+		object.Properties = append(object.Properties, property)
+	}
+	e.add(js.Declare("const", e.emitExpression(u.Names), object))
+}
@@ -0,0 +1,46 @@
+package emitter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func parseBundled(t *testing.T, dir string, name string, source string) parser.Program {
+	path := filepath.Join(dir, name)
+	os.WriteFile(path, []byte(source), 0644)
+	program, errors := parser.ParseProgram(strings.NewReader(source), path)
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors in %v: %#v", name, errors)
+	}
+	parser.ExportProgram(path, program)
+	return program
+}
+
+func TestEmitBundle(t *testing.T) {
+	dir := t.TempDir()
+	lib := parseBundled(t, dir, "lib", "count :: 2\ndouble :: (a number) => { a * count }")
+	main := parseBundled(t, dir, "main", "use double from \"./lib\"\nuse * as lib from \"./lib\"\ncount :: double(lib.count)\n_f :: () => number { todo() }")
+
+	output, flags := EmitBundle([]parser.Program{lib, main}, Options{})
+	if strings.Contains(output, "import ") || strings.Contains(output, "export ") {
+		t.Fatalf("expected no import or export, got:\n%v", output)
+	}
+	expected := []string{
+		"const count = 2;\n",
+		"const lib = { count, double: double_ };\n",
+		"const count$1 = double_(lib.count);\n",
+		"let todo=()=>",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Fatalf("expected output to contain %q, got:\n%v", e, output)
+		}
+	}
+	if flags != TodoFlag || strings.Contains(output, "Sum") {
+		t.Fatalf("expected only needed std helpers, got:\n%v", output)
+	}
+}
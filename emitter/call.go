@@ -1,30 +1,31 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
-func (e *Emitter) emitCallExpression(expr *parser.CallExpression, await bool) {
+func (e *Emitter) emitCallExpression(expr *parser.CallExpression, await bool) js.Expression {
 	args := expr.Args.Expr.(*parser.TupleExpression).Elements
-	if expr.Callee.Type().(parser.Function).Async && await {
-		e.write("await ")
-	}
 
-	if p, ok := expr.Callee.(*parser.PropertyAccessExpression); ok {
-		e.emitPropertyAccessExpression(p, true)
+	var call js.Expression
+	if p, ok := expr.Callee.(*parser.PropertyAccessExpression); ok && isNewtype(p.Expr.Type()) {
+		call = emitNewtypeMethodCall(e, p, args)
+	} else if ok {
+		call = js.Call(e.emitPropertyAccessExpression(p, true), e.emitExpressions(args)...)
 	} else {
-		e.emitExpression(expr.Callee)
+		call = js.Call(e.emitExpression(expr.Callee), e.emitExpressions(args)...)
 	}
 
-	max := len(args) - 1
-	if max == -1 {
-		e.write("()")
-		return
+	if expr.Callee.Type().(parser.Function).Async && await {
+		return e.await(call)
 	}
+	return call
+}
 
-	e.write("(")
-	for i := range args[:max] {
-		e.emitExpression(args[i])
-		e.write(", ")
-	}
-	e.emitExpression(args[max])
-	e.write(")")
+// Newtype methods take their receiver as first argument
+func emitNewtypeMethodCall(e *Emitter, callee *parser.PropertyAccessExpression, args []parser.Expression) js.Expression {
+	method := emitNewtypeMethod(e, callee)
+	receiver := emitNewtypeReceiver(e, callee)
+	return js.Call(method, append([]js.Expression{receiver}, e.emitExpressions(args)...)...)
 }
@@ -1,19 +1,21 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
 func (e *Emitter) emitCatchStatement(c *parser.CatchExpression) {
-	e.write("try {\n")
-	e.depth++
-	e.indent()
-	e.emit(c.Left)
-	e.depth--
-	e.write("} catch (")
+	e.add(&js.TryStatement{
+		Block:   e.block(func() { e.emit(c.Left) }),
+		Param:   emitCatchParam(e, c),
+		Handler: e.emitBlock(c.Body),
+	})
+}
+
+func emitCatchParam(e *Emitter, c *parser.CatchExpression) js.Expression {
 	if c.Identifier != nil {
-		e.emitExpression(c.Identifier)
-	} else {
-		e.write("_")
+		return e.emitExpression(c.Identifier)
 	}
-	e.write(") ")
-	e.emitBlockStatement(c.Body)
+	return js.Name("_")
 }
@@ -3,6 +3,7 @@ package emitter
 import (
 	"testing"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
@@ -71,8 +72,8 @@ func TestEmitCatchStatement(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			emitter := makeEmitter()
 			emitter.extractUninlinables(tt.node)
-			emitter.emitExpression(tt.node)
-			text := emitter.string()
+			expr := emitter.emitExpression(tt.node)
+			text := emitter.string() + js.Print(expr, js.Options{})
 
 			if text != tt.expectedExpression {
 				t.Fatalf("Expected expression:\n%v\ngot:\n%v", tt.expectedExpression, text)
@@ -1,22 +1,21 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
-func (e *Emitter) emitComputedAccessExpression(expr *parser.ComputedAccessExpression) {
+func (e *Emitter) emitComputedAccessExpression(expr *parser.ComputedAccessExpression) js.Expression {
 	switch left := expr.Expr.Type().(type) {
 	case parser.TypeAlias:
 		if left.Name == "#" {
-			emitGetElement(e, expr)
-		} else {
-			e.emitExpression(expr.Expr)
+			return emitGetElement(e, expr)
 		}
+		return e.emitExpression(expr.Expr)
 	default:
-		e.emitExpression(expr.Expr)
+		return e.emitExpression(expr.Expr)
 	}
 }
-func emitGetElement(e *Emitter, c *parser.ComputedAccessExpression) {
-	e.emitExpression(c.Expr)
-	e.write(".get(")
-	e.emitExpression(c.Property.Expr)
-	e.write(")")
+func emitGetElement(e *Emitter, c *parser.ComputedAccessExpression) js.Expression {
+	return js.Call(js.Dot(e.emitExpression(c.Expr), "get"), e.emitExpression(c.Property.Expr))
 }
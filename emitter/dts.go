@@ -0,0 +1,498 @@
+package emitter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Types of the DOM library which have the same name in TypeScript's
+var domTypes = []string{
+	"CharacterData", "Document", "Element", "Event", "EventTarget",
+	"HTMLBodyElement", "HTMLFrameElement", "Node", "Text",
+}
+
+// Declarations of the std classes used by emitted declarations
+func GetStdDeclarations() string {
+	return `export declare class Sum<Tag extends string = string, Value = unknown> {
+    constructor(tag: Tag, value: Value);
+    tag: Tag;
+    value: Value;
+}
+export declare class Option<T> extends Sum<"Some" | "None", T | undefined> {}
+export declare class Pointer<T> {
+    get(): T;
+    set(value: T): void;
+}
+export declare class NodePointer<T extends Node> {
+    get(): T;
+    set(value: T): void;
+}
+`
+}
+
+type declarationWriter struct {
+	path      string
+	module    parser.Module
+	scope     *parser.Scope
+	builder   strings.Builder
+	functions map[string]*parser.FunctionExpression // declared functions, by name or 'Type.method'
+	std       []string                              // std classes used
+	imports   map[string][]string                   // types used from other files, by import path
+	declared  map[string]bool                       // type names declared in this file
+	constants map[string]bool                       // names declared with '::'
+	private   []string                              // private types to declare
+}
+
+// Emit TypeScript declarations for the public names of a program.
+// Std classes are imported from stdPath (without extension), and imported
+// paths get the extension of output files.
+func EmitDeclarations(program parser.Program, stdPath string, extension string) string {
+	d := declarationWriter{
+		path:      program.Path(),
+		module:    program.Module(),
+		scope:     program.Scope(),
+		functions: map[string]*parser.FunctionExpression{},
+		imports:   map[string][]string{},
+		declared:  map[string]bool{},
+		constants: map[string]bool{},
+	}
+	imported := map[string]bool{}
+	for _, node := range program.Nodes() {
+		switch node := node.(type) {
+		case *parser.UseDirective:
+			if node.Names != nil {
+				for _, name := range getUsedNames(node.Names) {
+					imported[name] = true
+				}
+			}
+		case *parser.ExternDeclaration:
+			if node.Name != nil {
+				imported[node.Name.Text()] = true
+			}
+		case *parser.Assignment:
+			if i, ok := node.Pattern.(*parser.Identifier); ok && node.Operator.Kind() == parser.Define {
+				d.constants[i.Text()] = true
+			}
+			d.addFunction(node)
+		}
+	}
+
+	for _, member := range d.module.Members {
+		if !imported[member.Name] {
+			d.declare(member.Name, member.Type, true)
+		}
+	}
+	for len(d.private) > 0 {
+		name := d.private[0]
+		d.private = d.private[1:]
+		d.declare(name, d.scope.FindLocal(name).Typing, false)
+	}
+
+	var header strings.Builder
+	if len(d.std) > 0 {
+		slices.Sort(d.std)
+		header.WriteString(fmt.Sprintf("import type { %v } from %v;\n", strings.Join(d.std, ", "), strconv.Quote(stdPath+extension)))
+	}
+	paths := make([]string, 0, len(d.imports))
+	for path := range d.imports {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		names := d.imports[path]
+		slices.Sort(names)
+		header.WriteString(fmt.Sprintf("import type { %v } from %v;\n", strings.Join(names, ", "), strconv.Quote(path+extension)))
+	}
+	return header.String() + d.builder.String()
+}
+
+// Remember declared functions, to name their params
+func (d *declarationWriter) addFunction(a *parser.Assignment) {
+	f, ok := a.Value.(*parser.FunctionExpression)
+	if !ok || a.Operator.Kind() != parser.Define {
+		return
+	}
+	switch pattern := a.Pattern.(type) {
+	case *parser.Identifier:
+		d.functions[pattern.Text()] = f
+	case *parser.PropertyAccessExpression:
+		paren, ok := pattern.Expr.(*parser.ParenthesizedExpression)
+		if !ok {
+			return
+		}
+		receiver, ok := paren.Expr.(*parser.Param)
+		method, isIdentifier := pattern.Property.(*parser.Identifier)
+		if !ok || !isIdentifier {
+			return
+		}
+		if t, ok := receiver.Complement.Type().(parser.Type); ok {
+			if alias, ok := t.Value.(parser.TypeAlias); ok {
+				d.functions[alias.Name+"."+method.Text()] = f
+			}
+		}
+	}
+}
+
+func (d *declarationWriter) write(str string) { d.builder.WriteString(str) }
+
+func (d *declarationWriter) declare(name string, typing parser.ExpressionType, exported bool) {
+	d.declared[name] = true
+	prefix := ""
+	if exported {
+		prefix = "export "
+	}
+	t, ok := typing.(parser.Type)
+	if !ok {
+		keyword := "let"
+		if d.constants[name] {
+			keyword = "const"
+		}
+		if f, ok := typing.(parser.Function); ok {
+			d.write(fmt.Sprintf("%vdeclare %v %v: %v;\n", prefix, keyword, name, d.functionText(f, d.functions[name])))
+			return
+		}
+		d.write(fmt.Sprintf("%vdeclare %v %v: %v;\n", prefix, keyword, name, d.typeText(typing)))
+		return
+	}
+	alias, ok := t.Value.(parser.TypeAlias)
+	if !ok {
+		d.write(fmt.Sprintf("%vtype %v = %v;\n", prefix, name, d.typeText(t.Value)))
+		return
+	}
+	params := d.typeParamsText(alias.Params)
+	self := name + d.typeArgsText(alias.Params)
+	switch ref := alias.Ref.(type) {
+	case parser.Object:
+		d.write(fmt.Sprintf("%vinterface %v%v {\n", prefix, name, params))
+		for _, member := range getObjectMembers(ref) {
+			d.write(fmt.Sprintf("    %v: %v;\n", member.Name, d.typeText(member.Type)))
+		}
+		d.writeMethods(name, alias)
+		d.write("}\n")
+		d.write(fmt.Sprintf("%vdeclare const %v: new %v(%v) => %v;\n", prefix, name, params, d.constructorParamsText(ref), self))
+	case parser.Sum:
+		d.write(fmt.Sprintf("%vtype %v%v = %v", prefix, name, params, d.sumText(ref)))
+		if len(alias.Methods) > 0 {
+			d.write(" & {\n")
+			d.writeMethods(name, alias)
+			d.write("}")
+		}
+		d.write(";\n")
+		d.write(fmt.Sprintf("%vdeclare const %v: {\n", prefix, name))
+		for _, tag := range getSortedKeys(ref.Members) {
+			value := d.sumValueText(ref.Members[tag])
+			d.write(fmt.Sprintf("    new %v(tag: %v, value: %v): %v;\n", params, strconv.Quote(tag), value, self))
+		}
+		d.write("};\n")
+	case parser.Trait:
+		d.write(fmt.Sprintf("%vinterface %v%v {\n", prefix, name, params))
+		for _, member := range getSortedKeys(ref.Members) {
+			d.writeMember(member, ref.Members[member], nil)
+		}
+		d.write("}\n")
+	case parser.Newtype:
+		d.write(fmt.Sprintf("%vtype %v%v = %v;\n", prefix, name, params, d.typeText(ref.Underlying)))
+		if len(alias.Methods) == 0 {
+			return
+		}
+		// methods are stored in an object and take their receiver first
+		d.write(fmt.Sprintf("%vdeclare const %v: {\n", prefix, name))
+		for _, method := range getSortedKeys(alias.Methods) {
+			f, ok := alias.Methods[method].(parser.Function)
+			if !ok {
+				continue
+			}
+			elements := []parser.ExpressionType{parser.TypeAlias{Name: name, Params: alias.Params, From: d.path}}
+			if f.Params != nil {
+				elements = append(elements, f.Params.Elements...)
+			}
+			f.Params = &parser.Tuple{Elements: elements}
+			var names []string
+			if fn, ok := d.functions[name+"."+method]; ok {
+				names = append([]string{"self"}, getParamNames(fn)...)
+			}
+			d.write(fmt.Sprintf("    %v%v;\n", method, d.signatureText(f, names, ": ")))
+		}
+		d.write("};\n")
+	default:
+		d.write(fmt.Sprintf("%vtype %v%v = %v;\n", prefix, name, params, d.typeText(alias.Ref)))
+	}
+}
+
+func (d *declarationWriter) writeMethods(name string, alias parser.TypeAlias) {
+	for _, method := range getSortedKeys(alias.Methods) {
+		d.writeMember(method, alias.Methods[method], d.functions[name+"."+method])
+	}
+}
+
+func (d *declarationWriter) writeMember(name string, t parser.ExpressionType, fn *parser.FunctionExpression) {
+	f, ok := t.(parser.Function)
+	if !ok {
+		d.write(fmt.Sprintf("    %v: %v;\n", name, d.typeText(t)))
+		return
+	}
+	var names []string
+	if fn != nil {
+		names = getParamNames(fn)
+	}
+	d.write(fmt.Sprintf("    %v%v;\n", name, d.signatureText(f, names, ": ")))
+}
+
+// Constructors take embedded types, then members, then defaults
+func (d *declarationWriter) constructorParamsText(o parser.Object) string {
+	params := []string{}
+	required := len(o.Embedded) + len(o.Members)
+	for i, member := range getObjectMembers(o) {
+		if i >= required {
+			params = append(params, member.Name+"?: "+d.typeText(member.Type))
+		} else {
+			params = append(params, member.Name+": "+d.typeText(member.Type))
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+func (d *declarationWriter) sumText(sum parser.Sum) string {
+	members := []string{}
+	for _, tag := range getSortedKeys(sum.Members) {
+		value := d.sumValueText(sum.Members[tag])
+		members = append(members, fmt.Sprintf("{ tag: %v; value: %v }", strconv.Quote(tag), value))
+	}
+	if len(members) == 0 {
+		return "never"
+	}
+	return strings.Join(members, " | ")
+}
+
+func (d *declarationWriter) sumValueText(t parser.Tuple) string {
+	switch len(t.Elements) {
+	case 0:
+		return "undefined"
+	case 1:
+		return d.typeText(t.Elements[0])
+	default:
+		return d.typeText(t)
+	}
+}
+
+func (d *declarationWriter) functionText(f parser.Function, fn *parser.FunctionExpression) string {
+	var names []string
+	if fn != nil {
+		names = getParamNames(fn)
+	}
+	return d.signatureText(f, names, " => ")
+}
+
+// Text of a signature like '<T>(a: T): T' (with ': ' as arrow) or
+// '<T>(a: T) => T' (with ' => ').
+// Params are named 'argN' unless names are given.
+func (d *declarationWriter) signatureText(f parser.Function, names []string, arrow string) string {
+	params := []string{}
+	if f.Params != nil {
+		for i, param := range f.Params.Elements {
+			name := fmt.Sprintf("arg%v", i)
+			if i < len(names) {
+				name = names[i]
+			}
+			params = append(params, name+": "+d.typeText(param))
+		}
+	}
+	returned := "void"
+	if f.Returned != nil {
+		returned = d.typeText(f.Returned)
+	}
+	if f.Async {
+		returned = "Promise<" + returned + ">"
+	}
+	return d.typeParamsText(f.TypeParams) + "(" + strings.Join(params, ", ") + ")" + arrow + returned
+}
+
+func (d *declarationWriter) typeParamsText(params []parser.Generic) string {
+	if len(params) == 0 {
+		return ""
+	}
+	texts := make([]string, len(params))
+	for i, param := range params {
+		texts[i] = param.Name
+		if param.Constraints != nil {
+			texts[i] += " extends " + d.typeText(param.Constraints)
+		}
+	}
+	return "<" + strings.Join(texts, ", ") + ">"
+}
+
+// Type arguments of a generic type, which default to its params
+func (d *declarationWriter) typeArgsText(params []parser.Generic) string {
+	if len(params) == 0 {
+		return ""
+	}
+	texts := make([]string, len(params))
+	for i, param := range params {
+		if param.Value != nil {
+			texts[i] = d.typeText(param.Value)
+		} else {
+			texts[i] = param.Name
+		}
+	}
+	return "<" + strings.Join(texts, ", ") + ">"
+}
+
+func (d *declarationWriter) typeText(t parser.ExpressionType) string {
+	switch t := t.(type) {
+	case parser.Number, parser.Boolean, parser.String:
+		return t.Text()
+	case parser.Void:
+		return "void"
+	case parser.Never:
+		return "never"
+	case parser.LiteralType:
+		return t.Value
+	case parser.List:
+		return wrapTypeText(d.typeText(t.Element)) + "[]"
+	case parser.Map:
+		return "Map<" + d.typeText(t.Key) + ", " + d.typeText(t.Value) + ">"
+	case parser.Tuple:
+		elements := make([]string, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = d.typeText(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *parser.Tuple:
+		return d.typeText(*t)
+	case parser.Ref:
+		if implementsNode(t.To) {
+			return d.useStd("NodePointer") + "<" + d.typeText(t.To) + ">"
+		}
+		return d.useStd("Pointer") + "<" + d.typeText(t.To) + ">"
+	case parser.Function:
+		return d.signatureText(t, nil, " => ")
+	case parser.Generic:
+		if t.Value != nil {
+			return d.typeText(t.Value)
+		}
+		return t.Name
+	case parser.Union:
+		members := make([]string, len(t.Members))
+		for i, member := range t.Members {
+			members[i] = wrapTypeText(d.typeText(member))
+		}
+		return strings.Join(members, " | ")
+	case parser.Object:
+		members := []string{}
+		for _, member := range getObjectMembers(t) {
+			members = append(members, member.Name+": "+d.typeText(member.Type))
+		}
+		if len(members) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(members, "; ") + " }"
+	case parser.Sum:
+		return d.sumText(t)
+	case parser.Trait:
+		members := []string{}
+		for _, name := range getSortedKeys(t.Members) {
+			members = append(members, name+": "+d.typeText(t.Members[name]))
+		}
+		return "{ " + strings.Join(members, "; ") + " }"
+	case parser.Newtype:
+		return d.typeText(t.Underlying)
+	case parser.TypeAlias:
+		return d.aliasText(t)
+	default:
+		return "unknown"
+	}
+}
+
+func (d *declarationWriter) aliasText(t parser.TypeAlias) string {
+	if generic, ok := t.Ref.(parser.Generic); ok {
+		return d.typeText(generic)
+	}
+	switch t.Name {
+	case "?":
+		return d.useStd("Option") + "<" + d.typeText(t.Params[0].Value) + ">"
+	case "!":
+		ok, err := d.typeText(t.Params[0].Value), d.typeText(t.Params[1].Value)
+		return fmt.Sprintf("{ tag: \"Ok\"; value: %v } | { tag: \"Err\"; value: %v }", ok, err)
+	case "#":
+		return "Map<" + d.typeText(t.Params[0].Value) + ", " + d.typeText(t.Params[1].Value) + ">"
+	}
+	args := d.typeArgsText(t.Params)
+	switch {
+	case t.From == "":
+		if slices.Contains(domTypes, t.Name) {
+			return t.Name + args
+		}
+		return "unknown"
+	case t.From == d.path:
+		if t.Name[0] == '_' && !d.declared[t.Name] && !slices.Contains(d.private, t.Name) {
+			d.private = append(d.private, t.Name)
+		}
+	case t.Name[0] == '_' || parser.IsDeclarationFile(t.From):
+		// private types of other files cannot be imported, and declaration
+		// files are not part of the output
+		return "unknown"
+	default:
+		path := getImportPath(d.path, t.From, "")
+		if !slices.Contains(d.imports[path], t.Name) {
+			d.imports[path] = append(d.imports[path], t.Name)
+		}
+	}
+	return t.Name + args
+}
+
+func (d *declarationWriter) useStd(name string) string {
+	if !slices.Contains(d.std, name) {
+		d.std = append(d.std, name)
+	}
+	return name
+}
+
+// Wrap function and union types, so that they can be used as list elements
+// or union members
+func wrapTypeText(text string) string {
+	if strings.Contains(text, "=>") || strings.Contains(text, " | ") {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// Embedded types, members and defaults, in constructor order
+func getObjectMembers(o parser.Object) []parser.ObjectMember {
+	members := []parser.ObjectMember{}
+	for _, m := range [][]parser.ObjectMember{o.Embedded, o.Members, o.Defaults} {
+		members = append(members, m...)
+	}
+	return members
+}
+
+func getParamNames(f *parser.FunctionExpression) []string {
+	tuple, ok := f.Params.Expr.(*parser.TupleExpression)
+	if !ok {
+		return nil
+	}
+	names := []string{}
+	for _, param := range tuple.Elements {
+		switch param := param.(type) {
+		case *parser.Param:
+			names = append(names, param.Identifier.Text())
+		case *parser.Identifier:
+			names = append(names, param.Text())
+		default:
+			return nil
+		}
+	}
+	return names
+}
+
+func getSortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
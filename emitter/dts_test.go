@@ -0,0 +1,106 @@
+package emitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Declared types are recognized by their file, which must have a path
+func parseDeclared(t *testing.T, source string) parser.Program {
+	program, errors := parser.ParseProgram(strings.NewReader(source), "module")
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors: %#v", errors)
+	}
+	return program
+}
+
+func testDeclarations(t *testing.T, program parser.Program, expected string) {
+	received := EmitDeclarations(program, "./std", ".js")
+	if received != expected {
+		t.Fatalf("expected output:\n%v\n\ngot:\n%v", expected, received)
+	}
+}
+
+func TestDeclareValues(t *testing.T) {
+	source := "count :: 2\nnames := []string{\"a\"}\nadd :: (a number, b number) => { a + b }\n"
+	program := parseDeclared(t, source)
+	expected := "export declare const add: (a: number, b: number) => number;\n"
+	expected += "export declare const count: number;\n"
+	expected += "export declare let names: string[];\n"
+	testDeclarations(t, program, expected)
+}
+
+func TestDeclareObject(t *testing.T) {
+	source := "Counter :: {\n    name string\n    count: 0\n}\n"
+	source += "(c Counter).label :: (prefix string) => string { c.name }\n"
+	program := parseDeclared(t, source)
+	expected := "export interface Counter {\n"
+	expected += "    name: string;\n"
+	expected += "    count: number;\n"
+	expected += "    label(prefix: string): string;\n"
+	expected += "}\n"
+	expected += "export declare const Counter: new (name: string, count?: number) => Counter;\n"
+	testDeclarations(t, program, expected)
+}
+
+func TestDeclareGenericObject(t *testing.T) {
+	program := parseDeclared(t, "Box[Type] :: {\n    value Type\n}\n")
+	expected := "export interface Box<Type> {\n"
+	expected += "    value: Type;\n"
+	expected += "}\n"
+	expected += "export declare const Box: new <Type>(value: Type) => Box<Type>;\n"
+	testDeclarations(t, program, expected)
+}
+
+func TestDeclareSum(t *testing.T) {
+	program := parseDeclared(t, "Shape :: | Circle{number} | Square{number}")
+	expected := "export type Shape = { tag: \"Circle\"; value: number } | { tag: \"Square\"; value: number };\n"
+	expected += "export declare const Shape: {\n"
+	expected += "    new (tag: \"Circle\", value: number): Shape;\n"
+	expected += "    new (tag: \"Square\", value: number): Shape;\n"
+	expected += "};\n"
+	testDeclarations(t, program, expected)
+}
+
+func TestDeclareNewtype(t *testing.T) {
+	source := "UserId :: new number\n"
+	source += "(id UserId).show :: (prefix string) => string { prefix }\n"
+	program := parseDeclared(t, source)
+	expected := "export type UserId = number;\n"
+	expected += "export declare const UserId: {\n"
+	expected += "    show(self: UserId, prefix: string): string;\n"
+	expected += "};\n"
+	testDeclarations(t, program, expected)
+}
+
+func TestDeclareStdTypes(t *testing.T) {
+	source := "find :: (a number) => ?number { ?number{a} }\n"
+	source += "Mode :: \"light\" | \"dark\"\n"
+	program := parseDeclared(t, source)
+	expected := "import type { Option } from \"./std.js\";\n"
+	expected += "export type Mode = \"light\" | \"dark\";\n"
+	expected += "export declare const find: (a: number) => Option<number>;\n"
+	testDeclarations(t, program, expected)
+}
+
+func TestDeclarePrivateType(t *testing.T) {
+	source := "_Hidden :: { secret string }\n"
+	source += "reveal :: (h _Hidden) => string { h.secret }\n"
+	program := parseDeclared(t, source)
+	received := EmitDeclarations(program, "./std", ".js")
+	expected := "interface _Hidden {\n    secret: string;\n}\n"
+	if !strings.Contains(received, expected) || strings.Contains(received, "export interface _Hidden") {
+		t.Fatalf("expected private type to be declared without export, got:\n%v", received)
+	}
+}
+
+func TestDeclareImportedTypes(t *testing.T) {
+	dir := t.TempDir()
+	parseBundled(t, dir, "lib", "Point :: {\n    x number\n    y number\n}\n")
+	main := parseBundled(t, dir, "main", "use Point from \"./lib\"\ngetX :: (p Point) => { p.x }\n")
+	expected := "import type { Point } from \"./lib.js\";\n"
+	expected += "export declare const getX: (p: Point) => number;\n"
+	testDeclarations(t, main, expected)
+}
@@ -1,21 +1,23 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
 func (e *Emitter) emitExit(r *parser.Exit) {
+	var value js.Expression
+	if r.Value != nil {
+		value = e.emitExpression(r.Value)
+	}
 	switch r.Operator.Kind() {
 	case parser.BreakKeyword:
-		e.write("break")
+		e.add(&js.BreakStatement{})
 	case parser.ContinueKeyword:
-		e.write("continue")
+		e.add(&js.ContinueStatement{})
 	case parser.ReturnKeyword:
-		e.write("return")
+		e.add(&js.ReturnStatement{Argument: value})
 	case parser.ThrowKeyword:
-		e.write("throw")
-	}
-	if r.Value != nil {
-		e.write(" ")
-		e.emitExpression(r.Value)
+		e.add(&js.ThrowStatement{Argument: value})
 	}
-	e.write(";\n")
 }
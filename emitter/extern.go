@@ -0,0 +1,207 @@
+package emitter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Extern values are JavaScript values, converted from and to their kiwi
+// representation where it differs: options are nullable values, sum types
+// are plain '{ tag, value }' objects and DOM nodes are not behind pointers.
+//
+// Globals which need no conversion are read directly.
+func (e *Emitter) emitExternDeclaration(d *parser.ExternDeclaration) {
+	if d.Name == nil {
+		return
+	}
+	name := d.Name.Text()
+	local := e.getTopLevelName(name)
+	m := marshaller{e: e}
+	if d.Source == nil {
+		converted, ok := m.fromJS(js.Dot(js.Name("globalThis"), name), d.Type())
+		if ok || local != name {
+			e.add(js.Declare("const", js.Name(local), converted))
+		}
+		return
+	}
+
+	// names with '$' cannot be declared in kiwi
+	imported := local + "$js"
+	converted, ok := m.fromJS(js.Name(imported), d.Type())
+	if !ok {
+		imported = local
+	}
+	source, _ := strconv.Unquote(d.Source.Text())
+	specifier := &js.ImportSpecifier{Imported: js.Name(name)}
+	if imported != name {
+		specifier.Local = js.Name(imported)
+	}
+	e.add(&js.ImportDeclaration{Specifiers: []*js.ImportSpecifier{specifier}, Source: source})
+	if ok {
+		e.add(js.Declare("const", js.Name(local), converted))
+	}
+}
+
+// Builds the conversions of extern values
+type marshaller struct {
+	e      *Emitter
+	params int // number of generated params, to name new ones
+}
+
+func (m *marshaller) newParam() *js.Identifier {
+	m.params++
+	return js.Name(fmt.Sprintf("$%v", m.params-1))
+}
+
+// Convert the JavaScript value to the kiwi type.
+// Returns false if the value is already in kiwi representation.
+func (m *marshaller) fromJS(value js.Expression, t parser.ExpressionType) (js.Expression, bool) {
+	switch t := t.(type) {
+	case parser.Function:
+		return m.convertFunction(value, t, m.toJS, m.fromJS)
+	case parser.List:
+		return m.convertList(value, t, m.fromJS)
+	case parser.Ref:
+		if !isNodePointer(t) {
+			return value, false
+		}
+		m.e.addFlag(NodePointerFlag)
+		return js.New(js.Dot(js.Name("__"), "NodePointer"), value), true
+	case parser.TypeAlias:
+		switch {
+		case t.Name == "?":
+			m.e.addFlag(FromNullableFlag)
+			args := withMapper([]js.Expression{value}, m.getMapper(t.Params[0].Value, m.fromJS))
+			return js.Call(js.Dot(js.Name("__"), "fromNullable"), args...), true
+		case t.Name == "!":
+			return m.fromJS(value, t.Params[0].Value)
+		case isSumType(t):
+			m.e.addFlag(FromTaggedFlag)
+			return js.Call(js.Dot(js.Name("__"), "fromTagged"), m.getSumClass(t), value), true
+		}
+	}
+	return value, false
+}
+
+// Convert the kiwi value to its JavaScript representation.
+// Returns false if the representations are the same.
+func (m *marshaller) toJS(value js.Expression, t parser.ExpressionType) (js.Expression, bool) {
+	switch t := t.(type) {
+	case parser.Function:
+		return m.convertFunction(value, t, m.fromJS, m.toJS)
+	case parser.List:
+		return m.convertList(value, t, m.toJS)
+	case parser.Ref:
+		if !isNodePointer(t) {
+			return value, false
+		}
+		return js.Call(js.Dot(value, "get")), true
+	case parser.TypeAlias:
+		switch t.Name {
+		case "?":
+			m.e.addFlag(ToNullableFlag)
+			args := withMapper([]js.Expression{value}, m.getMapper(t.Params[0].Value, m.toJS))
+			return js.Call(js.Dot(js.Name("__"), "toNullable"), args...), true
+		case "!":
+			return m.toJS(value, t.Params[0].Value)
+		}
+	}
+	return value, false
+}
+
+type conversion = func(value js.Expression, t parser.ExpressionType) (js.Expression, bool)
+
+// Functions crossing the boundary get their arguments from the other side,
+// and return to it.
+func (m *marshaller) convertFunction(value js.Expression, f parser.Function, args conversion, returned conversion) (js.Expression, bool) {
+	changed := false
+	params := []js.Expression{}
+	converted := []js.Expression{}
+	if f.Params != nil {
+		for _, param := range f.Params.Elements {
+			name := m.newParam()
+			arg, ok := args(name, param)
+			changed = changed || ok
+			params = append(params, name)
+			converted = append(converted, arg)
+		}
+	}
+	call := js.Call(value, converted...)
+	var awaited js.Expression = call
+	if f.Async {
+		awaited = m.e.await(call)
+	}
+	result, ok := returned(awaited, f.Returned)
+	if !ok && !changed {
+		return value, false
+	}
+	if !ok && f.Async {
+		// the promise can be returned as is
+		result = call
+	}
+	arrow := &js.ArrowFunction{Params: params, Body: result}
+	switch {
+	case !f.Async || !ok:
+	case m.e.language.has(ES2018):
+		arrow.Async = true
+	default:
+		m.e.addFlag(RunAsyncFlag)
+		generator := &js.FunctionExpression{
+			Generator: true,
+			Body:      &js.BlockStatement{Body: []js.Statement{&js.ReturnStatement{Argument: result}}},
+		}
+		arrow.Body = js.Call(js.Dot(js.Name("__"), "runAsync"), js.Call(generator))
+	}
+	return arrow, true
+}
+
+func (m *marshaller) convertList(value js.Expression, l parser.List, convert conversion) (js.Expression, bool) {
+	mapper := m.getMapper(l.Element, convert)
+	if mapper == nil {
+		return value, false
+	}
+	return js.Call(js.Dot(value, "map"), mapper), true
+}
+
+// Get the function converting values of type t, or nil if values are not
+// converted
+func (m *marshaller) getMapper(t parser.ExpressionType, convert conversion) js.Expression {
+	param := m.newParam()
+	converted, ok := convert(param, t)
+	if !ok {
+		return nil
+	}
+	return &js.ArrowFunction{Params: []js.Expression{param}, Body: converted}
+}
+
+// Add the mapper to the arguments of a conversion, if there is one
+func withMapper(args []js.Expression, mapper js.Expression) []js.Expression {
+	if mapper == nil {
+		return args
+	}
+	return append(args, mapper)
+}
+
+// Sum types declared in the program are rebuilt with their class, to get
+// their methods. Others are rebuilt as plain sums.
+func (m *marshaller) getSumClass(t parser.TypeAlias) js.Expression {
+	if m.e.topLevel != nil && m.e.topLevel.FindLocal(t.Name) != nil {
+		return js.Name(m.e.getTopLevelName(t.Name))
+	}
+	m.e.addFlag(SumFlag)
+	return js.Dot(js.Name("__"), "Sum")
+}
+
+func isSumType(t parser.TypeAlias) bool {
+	_, ok := t.Ref.(parser.Sum)
+	return ok
+}
+
+// References to DOM nodes are held in node pointers
+func isNodePointer(r parser.Ref) bool {
+	alias, ok := r.To.(parser.TypeAlias)
+	return ok && (alias.Name == "Node" && alias.From == "" || implementsNode(alias))
+}
@@ -0,0 +1,76 @@
+package emitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func TestEmitExternImport(t *testing.T) {
+	source := "extern fetchText :: async (string) -> (Error!string) from \"./ffi.js\""
+	expected := "import { fetchText } from \"./ffi.js\";\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitExternGlobal(t *testing.T) {
+	testEmitter(t, "extern innerWidth :: number", "", 0)
+}
+
+func TestEmitExternOption(t *testing.T) {
+	source := "extern find :: (?string) -> ?number from \"./ffi.js\""
+	expected := "import { find as find$js } from \"./ffi.js\";\n"
+	expected += "const find = ($0) => __.fromNullable(find$js(__.toNullable($0)));\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitExternCallback(t *testing.T) {
+	source := "extern each :: ((?number) -> ()) -> ()"
+	expected := "const each = ($0) => globalThis.each(($1) => $0(__.fromNullable($1)));\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitExternAsync(t *testing.T) {
+	source := "extern load :: async (string) -> ?string from \"./ffi.js\""
+	expected := "import { load as load$js } from \"./ffi.js\";\n"
+	expected += "const load = async ($0) => __.fromNullable(await load$js($0));\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitExternSum(t *testing.T) {
+	dir := t.TempDir()
+	parseBundled(t, dir, "shapes", "Shape :: | Circle{number} | Square{number}")
+	main := parseBundled(t, dir, "main", "use Shape from \"./shapes\"\nextern pick :: () -> Shape from \"./ffi.js\"\n_s :: pick()")
+
+	output, _, flags := EmitProgramWithMappings(main, Options{})
+	expected := "const pick = () => __.fromTagged(Shape, pick$js());\n"
+	if !strings.Contains(output, expected) {
+		t.Fatalf("expected sums to be rebuilt with their class, got:\n%v", output)
+	}
+	if !hasFlag(flags, FromTaggedFlag) {
+		t.Fatalf("expected std helper to be needed")
+	}
+}
+
+func TestDeclareExtern(t *testing.T) {
+	program := parseDeclared(t, "extern find :: (string) -> ?number from \"./ffi.js\"\ncount :: 2")
+	expected := "export declare const count: number;\n"
+	testDeclarations(t, program, expected)
+}
+
+func TestShakeExtern(t *testing.T) {
+	dir := t.TempDir()
+	main := parseBundled(t, dir, "main", "extern find :: (string) -> ?number from \"./ffi.js\"\nextern alert :: (string) -> ()\nalert(\"hi\")")
+	reach := parser.AnalyzeReachability([]parser.Program{main}, []string{main.Path()})
+
+	output, _, _ := EmitProgramWithMappings(main, Options{Reach: reach})
+	if strings.Contains(output, "find") {
+		t.Fatalf("expected unused externs to be dropped, got:\n%v", output)
+	}
+}
+
+func TestEmitExternNode(t *testing.T) {
+	source := "use Node from \"dom\"\nextern focus :: (&Node) -> &Node"
+	expected := "const focus = ($0) => new __.NodePointer(globalThis.focus($0.get()));\n"
+	testEmitter(t, source, expected, 1)
+}
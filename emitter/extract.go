@@ -2,7 +2,9 @@ package emitter
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
@@ -46,60 +48,67 @@ func needsEscape(node parser.Node) bool {
 	}
 }
 
+// Emit uninlinable blocks and catch expressions of the node before it,
+// storing their value in temporary variables
 func (e *Emitter) extractUninlinables(node parser.Node) {
 	startAt := len(e.uninlinables)
 	e.findUninlinables(node)
+	extracted := []parser.Node{}
 	for n, id := range e.uninlinables {
-		if id < startAt {
-			continue
+		if id >= startAt {
+			extracted = append(extracted, n)
 		}
-		// outline block
-		e.write(fmt.Sprintf("let __tmp%v;\n", id))
-		e.indent()
+	}
+	slices.SortFunc(extracted, func(a, b parser.Node) int {
+		return e.uninlinables[a] - e.uninlinables[b]
+	})
+	for _, n := range extracted {
+		id := e.uninlinables[n]
 		switch n := n.(type) {
 		case *parser.Block:
-			emitExtractedBlock(e, n, id)
+			e.add(js.Declare("let", getTemporary(id), nil))
+			e.add(emitExtractedBlock(e, n, id))
 		case *parser.CatchExpression:
-			emitExtractedCatch(e, n)
+			e.add(js.Declare("let", getTemporary(id), nil))
+			e.add(emitExtractedCatch(e, n))
 		}
-		e.indent()
 	}
 }
 
-func emitExtractedBlock(e *Emitter, b *parser.Block, id int) {
-	e.write("{\n")
-	e.depth++
-	max := len(b.Statements) - 1
-	for _, statement := range b.Statements[:max] {
-		e.indent()
-		e.emit(statement)
-	}
-	e.indent()
-	last := b.Statements[max]
-	if !parser.IsExiting(last) {
-		e.write(fmt.Sprintf("__tmp%v = ", id))
-	}
-	e.emit(last)
-	e.depth--
-	e.indent()
-	e.write("}\n")
+// Name of the variable holding the value of an uninlinable expression
+func getTemporary(id int) *js.Identifier {
+	return js.Name(fmt.Sprintf("__tmp%v", id))
+}
+
+func emitExtractedBlock(e *Emitter, b *parser.Block, id int) *js.BlockStatement {
+	return e.block(func() {
+		max := len(b.Statements) - 1
+		for _, statement := range b.Statements[:max] {
+			e.emit(statement)
+		}
+		last, ok := b.Statements[max].(parser.Expression)
+		if !ok || parser.IsExiting(last) {
+			e.emit(b.Statements[max])
+			return
+		}
+		if !needsEscape(last) {
+			e.extractUninlinables(last)
+		}
+		value := js.Assign(getTemporary(id), e.emitExpression(last))
+		statement := &js.ExpressionStatement{Expression: value}
+		e.locate(statement, last)
+		e.add(statement)
+	})
 }
 
-func emitExtractedCatch(e *Emitter, c *parser.CatchExpression) {
-	e.write("try {\n")
-	e.depth++
-	e.indent()
+func emitExtractedCatch(e *Emitter, c *parser.CatchExpression) *js.TryStatement {
 	id := e.uninlinables[c]
-	e.write(fmt.Sprintf("__tmp%v = ", id))
-	e.emitExpression(c.Left)
-	e.write(";\n")
-	e.depth--
-	e.write("} catch (")
-	if c.Identifier != nil {
-		e.emitExpression(c.Identifier)
-	} else {
-		e.write("_")
+	return &js.TryStatement{
+		Block: e.block(func() {
+			value := js.Assign(getTemporary(id), e.emitExpression(c.Left))
+			e.add(&js.ExpressionStatement{Expression: value})
+		}),
+		Param:   emitCatchParam(e, c),
+		Handler: emitExtractedBlock(e, c.Body, id),
 	}
-	e.write(") ")
-	emitExtractedBlock(e, c.Body, id)
 }
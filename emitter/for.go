@@ -1,20 +1,19 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
 func (e *Emitter) emitFor(f *parser.ForExpression) {
 	if f.Expr == nil {
-		e.write("while (true) ")
-		e.emitBlockStatement(f.Body)
+		e.add(&js.WhileStatement{Test: &js.Literal{Raw: "true"}, Body: e.emitBlock(f.Body)})
 		return
 	}
 
 	binary, ok := f.Expr.(*parser.BinaryExpression)
 	if !ok || binary.Operator.Kind() != parser.InKeyword {
-		e.write("while (")
-		e.emitExpression(f.Expr)
-		e.write(") ")
-		e.emitBlockStatement(f.Body)
+		e.add(&js.WhileStatement{Test: e.emitExpression(f.Expr), Body: e.emitBlock(f.Body)})
 		return
 	}
 	_, isTuple := binary.Left.(*parser.TupleExpression)
@@ -38,27 +37,30 @@ func (e *Emitter) emitFor(f *parser.ForExpression) {
 
 }
 
+// Test of a loop over a range (e.g. 'x < 10')
+func emitRangeTest(e *Emitter, r *parser.RangeExpression, counter js.Expression) js.Expression {
+	operator := "<"
+	if r.Operator.Kind() == parser.InclusiveRange {
+		operator = "<="
+	}
+	return &js.BinaryExpression{Operator: operator, Left: counter, Right: e.emitExpression(r.Right)}
+}
+
+func increment(expr js.Expression) js.Expression {
+	return &js.UpdateExpression{Operator: "++", Argument: expr}
+}
+
 func emitForRange(e *Emitter, f *parser.ForExpression) {
 	binary := f.Expr.(*parser.BinaryExpression)
 	r := binary.Right.(*parser.RangeExpression)
 	identifier := binary.Left.(*parser.Identifier)
 
-	e.write("for (let ")
-	e.emitIdentifier(identifier)
-	e.write(" = ")
-	e.emitExpression(r.Left)
-	e.write("; ")
-	e.emitExpression(identifier)
-	if r.Operator.Kind() == parser.InclusiveRange {
-		e.write(" <= ")
-	} else {
-		e.write(" < ")
-	}
-	e.emitExpression(r.Right)
-	e.write("; ")
-	e.emitExpression(identifier)
-	e.write("++) ")
-	e.emitBlockStatement(f.Body)
+	e.add(&js.ForStatement{
+		Init:   js.Declare("let", e.emitIdentifier(identifier), e.emitExpression(r.Left)),
+		Test:   emitRangeTest(e, r, e.emitExpression(identifier)),
+		Update: increment(e.emitExpression(identifier)),
+		Body:   e.emitBlock(f.Body),
+	})
 }
 
 func emitForRangeTuple(e *Emitter, f *parser.ForExpression) {
@@ -66,59 +68,92 @@ func emitForRangeTuple(e *Emitter, f *parser.ForExpression) {
 	r := binary.Right.(*parser.RangeExpression)
 	tuple := binary.Left.(*parser.TupleExpression)
 
-	e.write("for (let ")
-	e.emitExpression(tuple.Elements[0])
-	e.write(" = ")
-	e.emitExpression(r.Left)
-	e.write(", ")
-	e.emitExpression(tuple.Elements[1])
-	e.write(" = 0; ")
-	e.emitExpression(tuple.Elements[0])
-	if r.Operator.Kind() == parser.InclusiveRange {
-		e.write(" <= ")
-	} else {
-		e.write(" < ")
-	}
-	e.emitExpression(r.Right)
-	e.write("; ")
-	e.emitExpression(tuple.Elements[0])
-	e.write("++, ")
-	e.emitExpression(tuple.Elements[1])
-	e.write("++) ")
-	e.emitBlockStatement(f.Body)
+	init := js.Declare("let", e.emitExpression(tuple.Elements[0]), e.emitExpression(r.Left))
+	init.Declarations = append(init.Declarations, &js.Declarator{
+		Name: e.emitExpression(tuple.Elements[1]),
+		Init: &js.Literal{Raw: "0"},
+	})
+	e.add(&js.ForStatement{
+		Init: init,
+		Test: emitRangeTest(e, r, e.emitExpression(tuple.Elements[0])),
+		Update: &js.SequenceExpression{Expressions: []js.Expression{
+			increment(e.emitExpression(tuple.Elements[0])),
+			increment(e.emitExpression(tuple.Elements[1])),
+		}},
+		Body: e.emitBlock(f.Body),
+	})
 }
 
 func emitForList(e *Emitter, f *parser.ForExpression) {
 	binary := f.Expr.(*parser.BinaryExpression)
 	identifier := binary.Left.(*parser.Identifier)
+	if !e.language.has(ES2018) {
+		emitForListIndex(e, f)
+		return
+	}
+
+	e.add(&js.ForOfStatement{
+		Left:  js.Declare("let", e.emitIdentifier(identifier), nil),
+		Right: e.emitExpression(binary.Right),
+		Body:  e.emitBlock(f.Body),
+	})
+}
+
+// Element of the iterated list (e.g. '__list[0]')
+func getListElement(index js.Expression) js.Expression {
+	return &js.MemberExpression{Object: js.Name("__list"), Property: index, Computed: true}
+}
+
+func getNextListElement(counter js.Expression) js.Expression {
+	next := &js.UpdateExpression{Operator: "++", Prefix: true, Argument: counter}
+	return getListElement(next)
+}
+
+// In ES2015, lists are iterated by index rather than with the iterator
+// protocol
+func emitForListIndex(e *Emitter, f *parser.ForExpression) {
+	binary := f.Expr.(*parser.BinaryExpression)
+	identifier := binary.Left.(*parser.Identifier)
 
-	e.write("for (let ")
-	e.emitIdentifier(identifier)
-	e.write(" of ")
-	e.emitExpression(binary.Right)
-	e.write(") ")
-	e.emitBlockStatement(f.Body)
+	init := js.Declare("let", js.Name("__list"), e.emitExpression(binary.Right))
+	init.Declarations = append(init.Declarations,
+		&js.Declarator{Name: js.Name("__i"), Init: &js.Literal{Raw: "0"}},
+		&js.Declarator{Name: e.emitIdentifier(identifier), Init: getListElement(&js.Literal{Raw: "0"})},
+	)
+	e.add(&js.ForStatement{
+		Init: init,
+		Test: &js.BinaryExpression{
+			Operator: "<",
+			Left:     js.Name("__i"),
+			Right:    js.Dot(js.Name("__list"), "length"),
+		},
+		Update: js.Assign(e.emitIdentifier(identifier), getNextListElement(js.Name("__i"))),
+		Body:   e.emitBlock(f.Body),
+	})
 }
 
 func emitForListTuple(e *Emitter, f *parser.ForExpression) {
 	binary := f.Expr.(*parser.BinaryExpression)
 	tuple := binary.Left.(*parser.TupleExpression)
 
-	e.write("const __list = ")
-	e.emitExpression(binary.Right)
-	e.write(";\n")
-
-	e.indent()
-	e.write("for (let ")
-	e.emitExpression(tuple.Elements[0])
-	e.write(" = __list[0], ")
-	e.emitExpression(tuple.Elements[1])
-	e.write(" = 0; ")
-	e.emitExpression(tuple.Elements[1])
-	e.write(" < __list.length; ")
-	e.emitExpression(tuple.Elements[0])
-	e.write(" = __list[++")
-	e.emitExpression(tuple.Elements[1])
-	e.write("]) ")
-	e.emitBlockStatement(f.Body)
+	e.add(js.Declare("const", js.Name("__list"), e.emitExpression(binary.Right)))
+
+	init := js.Declare("let", e.emitExpression(tuple.Elements[0]), getListElement(&js.Literal{Raw: "0"}))
+	init.Declarations = append(init.Declarations, &js.Declarator{
+		Name: e.emitExpression(tuple.Elements[1]),
+		Init: &js.Literal{Raw: "0"},
+	})
+	e.add(&js.ForStatement{
+		Init: init,
+		Test: &js.BinaryExpression{
+			Operator: "<",
+			Left:     e.emitExpression(tuple.Elements[1]),
+			Right:    js.Dot(js.Name("__list"), "length"),
+		},
+		Update: js.Assign(
+			e.emitExpression(tuple.Elements[0]),
+			getNextListElement(e.emitExpression(tuple.Elements[1])),
+		),
+		Body: e.emitBlock(f.Body),
+	})
 }
@@ -4,13 +4,13 @@ import "testing"
 
 func TestEmitFor(t *testing.T) {
 	source := "for {}"
-	expected := "while (true) {}"
+	expected := "while (true) {}\n"
 	testEmitter(t, source, expected, 0)
 }
 
 func TestEmitForCondition(t *testing.T) {
 	source := "for true {}"
-	expected := "while (true) {}"
+	expected := "while (true) {}\n"
 	testEmitter(t, source, expected, 0)
 }
 
@@ -1,81 +1,118 @@
 package emitter
 
 import (
-	"fmt"
-
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
-func (e *Emitter) emitFunctionBody(b *parser.Block, params *parser.TupleExpression) {
-	e.write("{")
-	if len(b.Statements) == 0 {
-		e.write("}")
-		return
-	}
-	e.write("\n")
-	e.depth++
-
-	for _, param := range params.Elements {
-		if _, ok := param.Type().(parser.Ref); ok {
-			continue
-		}
-		name := param.(*parser.Param).Identifier.Text()
-		v, ok := b.Scope().Find(name)
-		if !ok {
-			panic("variable should be found in current scope...")
+// Emit the body of a function, which returns the value of its last statement
+func (e *Emitter) emitFunctionBody(b *parser.Block, params *parser.TupleExpression) *js.BlockStatement {
+	return e.block(func() {
+		if len(b.Statements) == 0 {
+			return
 		}
-		if isMutated(v) {
-			e.indent()
-			e.write(fmt.Sprintf("%v = structuredClone(%v);\n", name, name))
+		for _, param := range params.Elements {
+			if _, ok := param.Type().(parser.Ref); ok {
+				continue
+			}
+			identifier := param.(*parser.Param).Identifier
+			v, ok := b.Scope().Find(identifier.Text())
+			if !ok {
+				panic("variable should be found in current scope...")
+			}
+			if isMutated(v) {
+				name := js.Name(e.getVariableName(identifier))
+				clone := js.Call(js.Name("structuredClone"), name)
+				e.add(&js.ExpressionStatement{Expression: js.Assign(name, clone)})
+			}
 		}
+		e.emitReturnedStatements(b.Statements)
+	})
+}
+
+func (e *Emitter) emitReturnedStatements(statements []parser.Node) {
+	if len(statements) == 0 {
+		return
 	}
-	max := len(b.Statements) - 1
-	for _, statement := range b.Statements[:max] {
-		e.indent()
+	max := len(statements) - 1
+	for _, statement := range statements[:max] {
 		e.emit(statement)
 	}
-	e.indent()
-	e.write("return ")
-	e.emit(b.Statements[max])
-	e.depth--
-	e.indent()
-	e.write("}\n")
+	e.emitReturn(statements[max])
 }
 
-func (e *Emitter) emitFunctionExpression(f *parser.FunctionExpression) {
-	if f.Type().(parser.Function).Async {
-		e.write("async ")
+// Return the value of the last statement of a function. Statements which
+// have no value, like exits or loops, are emitted as is.
+func (e *Emitter) emitReturn(node parser.Node) {
+	if i, ok := node.(*parser.IfExpression); ok && i.Alternate != nil {
+		// each branch returns its own value
+		e.add(e.emitReturnedIf(i))
+		return
+	}
+	if m, ok := node.(*parser.MatchExpression); ok {
+		e.emitMatchStatement(m, true)
+		return
 	}
-	args := f.Params.Expr.(*parser.TupleExpression).Elements
-	e.emitFunctionParams(args)
-	e.write(" => ")
+	expr, ok := node.(parser.Expression)
+	if _, isExit := node.(*parser.Exit); !ok || isExit || needsEscape(node) {
+		e.emit(node)
+		return
+	}
+	e.extractUninlinables(expr)
+	returned := &js.ReturnStatement{Argument: e.emitExpression(expr)}
+	e.locate(returned, node)
+	e.add(returned)
+}
 
-	params := f.Params.Expr.(*parser.TupleExpression)
-	e.emitFunctionBody(f.Body, params)
+func (e *Emitter) emitReturnedIf(i *parser.IfExpression) *js.IfStatement {
+	statement := &js.IfStatement{
+		Test:       e.emitExpression(i.Condition.(parser.Expression)),
+		Consequent: e.block(func() { e.emitReturnedStatements(i.Body.Statements) }),
+	}
+	switch alternate := i.Alternate.(type) {
+	case *parser.Block:
+		statement.Alternate = e.block(func() { e.emitReturnedStatements(alternate.Statements) })
+	case *parser.IfExpression:
+		statement.Alternate = e.emitReturnedIf(alternate)
+	}
+	e.locate(statement, i)
+	return statement
 }
 
-func (e *Emitter) emitFunctionParams(params []parser.Expression) {
-	if len(params) == 0 {
-		e.write("()")
-		return
+func (e *Emitter) emitFunctionExpression(f *parser.FunctionExpression) js.Expression {
+	async := f.Type().(parser.Function).Async
+	params := f.Params.Expr.(*parser.TupleExpression)
+	arrow := &js.ArrowFunction{Params: e.emitFunctionParams(params.Elements)}
+	if !async || e.language.has(ES2018) {
+		arrow.Async = async
+		arrow.Body = e.emitFunctionBody(f.Body, params)
+		return arrow
 	}
+	// generators can pause like async functions, runAsync resumes them
+	e.addFlag(RunAsyncFlag)
+	generator := &js.FunctionExpression{
+		Generator: true,
+		Body:      e.emitFunctionBody(f.Body, params),
+	}
+	run := js.Call(js.Dot(generator, "call"), js.Name("this"))
+	arrow.Body = js.Call(js.Dot(js.Name("__"), "runAsync"), run)
+	return arrow
+}
 
-	e.write("(")
-	max := len(params) - 1
-	for i := range params[:max] {
-		e.emitFunctionParam(params[i])
-		e.write(", ")
+func (e *Emitter) emitFunctionParams(params []parser.Expression) []js.Expression {
+	emitted := make([]js.Expression, len(params))
+	for i, param := range params {
+		emitted[i] = e.emitFunctionParam(param)
 	}
-	e.emitFunctionParam(params[max])
-	e.write(")")
+	return emitted
 }
 
-func (e *Emitter) emitFunctionParam(arg parser.Expression) {
+func (e *Emitter) emitFunctionParam(arg parser.Expression) js.Expression {
 	switch arg := arg.(type) {
 	case *parser.Param:
-		e.emitIdentifier(arg.Identifier)
+		return e.emitIdentifier(arg.Identifier)
 	case *parser.Identifier:
-		e.emitIdentifier(arg)
+		return e.emitIdentifier(arg)
 	default:
 		panic("expected param or identifier")
 	}
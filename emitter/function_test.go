@@ -11,3 +11,23 @@ func TestEmitFunctionExpression(t *testing.T) {
 
 	testEmitter(t, source, expected, 0)
 }
+
+func TestEmitTodo(t *testing.T) {
+	source := "_f :: () => number { todo() }"
+	expected := "const _f = () => {\n"
+	expected += "    return __.todo();\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitReturnedIf(t *testing.T) {
+	source := "_pick :: (cond boolean, a number, b number) => { if cond { a } else { b } }"
+	expected := "const _pick = (cond, a, b) => {\n"
+	expected += "    if (cond) {\n"
+	expected += "        return a;\n"
+	expected += "    } else {\n"
+	expected += "        return b;\n"
+	expected += "    }\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
@@ -3,23 +3,71 @@ package emitter
 import (
 	"slices"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
-func (e *Emitter) emitIdentifier(i *parser.Identifier) {
+func (e *Emitter) emitIdentifier(i *parser.Identifier) js.Expression {
 	text := i.Token.Text()
 	if text == e.thisName {
-		e.write("this")
-		return
+		return js.Name("this")
 	}
+	if std, ok := emitStdIdentifier(e, i); ok {
+		return std
+	}
+	var emitted js.Expression
 	if _, ok := i.Type().(parser.Type); !ok && isReferenced(i) {
-		emitScope(e, i.GetScope())
-		e.write("." + text)
+		emitted = js.Dot(emitScope(e, i.GetScope()), text)
+	} else if name, ok := e.getBundledName(i); ok {
+		emitted = js.Name(name)
 	} else {
-		e.write(getSanitizedName(text))
+		emitted = js.Name(e.getVariableName(i))
+	}
+	if isUnwrappedOption(i) {
+		return js.Dot(emitted, "value")
+	}
+	return emitted
+}
+
+// Emit values declared in the std scope, if the identifier refers to one.
+func emitStdIdentifier(e *Emitter, i *parser.Identifier) (js.Expression, bool) {
+	if i.GetScope() != nil {
+		return nil, false
+	}
+	switch i.Text() {
+	case "None":
+		if !isOption(i.Type()) {
+			return nil, false
+		}
+		e.addFlag(OptionFlag)
+		return js.New(js.Dot(js.Name("__"), "Option"), js.String("None")), true
+	case "todo":
+		e.addFlag(TodoFlag)
+		return js.Dot(js.Name("__"), "todo"), true
+	case "unreachable":
+		e.addFlag(UnreachableFlag)
+		return js.Dot(js.Name("__"), "unreachable"), true
+	default:
+		return nil, false
 	}
 }
 
+// Options narrowed by control flow (e.g. after 'if option == None { return }')
+// are read through their value.
+func isUnwrappedOption(i *parser.Identifier) bool {
+	scope := i.GetScope()
+	if scope == nil {
+		return false
+	}
+	v, ok := scope.Find(i.Text())
+	return ok && isOption(v.Typing) && !isOption(i.Type())
+}
+
+func isOption(t parser.ExpressionType) bool {
+	alias, ok := t.(parser.TypeAlias)
+	return ok && alias.Name == "?"
+}
+
 var reservedWords = []string{
 	"abstract", "arguments", "boolean", "byte", "case", "char", "class",
 	"const", "debugger", "default", "delete", "do", "double", "enum", "eval",
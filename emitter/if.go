@@ -1,39 +1,41 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
 func (e *Emitter) emitIfStatement(i *parser.IfExpression) {
-	e.write("if (")
-	// FIXME:
-	e.emitExpression(i.Condition.(parser.Expression))
-	e.write(") ")
-	e.emitBlockStatement(i.Body)
-	if i.Alternate == nil {
-		return
+	e.add(e.emitIf(i))
+}
+
+func (e *Emitter) emitIf(i *parser.IfExpression) *js.IfStatement {
+	statement := &js.IfStatement{
+		// FIXME:
+		Test:       e.emitExpression(i.Condition.(parser.Expression)),
+		Consequent: e.emitBlock(i.Body),
 	}
-	e.write(" else ")
 	switch alternate := i.Alternate.(type) {
 	case *parser.Block:
-		e.emitBlockStatement(alternate)
+		statement.Alternate = e.emitBlock(alternate)
 	case *parser.IfExpression:
-		e.emitIfStatement(alternate)
+		statement.Alternate = e.emitIf(alternate)
 	}
+	return statement
 }
 
-func (e *Emitter) emitIfExpression(i *parser.IfExpression) {
-	// FIXME:
-	e.emitExpression(i.Condition.(parser.Expression))
-	e.write(" ? ")
-	e.emitBlockExpression(i.Body)
-	e.write(" : ")
-	if i.Alternate == nil {
-		e.write("undefined")
-		return
+func (e *Emitter) emitIfExpression(i *parser.IfExpression) js.Expression {
+	conditional := &js.ConditionalExpression{
+		// FIXME:
+		Test:       e.emitExpression(i.Condition.(parser.Expression)),
+		Consequent: e.emitBlockExpression(i.Body),
+		Alternate:  js.Name("undefined"),
 	}
 	switch alternate := i.Alternate.(type) {
 	case *parser.Block:
-		e.emitBlockExpression(alternate)
+		conditional.Alternate = e.emitBlockExpression(alternate)
 	case *parser.IfExpression:
-		e.emitIfExpression(alternate)
+		conditional.Alternate = e.emitIfExpression(alternate)
 	}
+	return conditional
 }
@@ -3,6 +3,7 @@ package emitter
 import (
 	"testing"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
@@ -23,7 +24,7 @@ func TestIfStatement(t *testing.T) {
 	})
 
 	text := emitter.string()
-	expected := "if (false) {} else if (false) {} else {}"
+	expected := "if (false) {} else if (false) {} else {}\n"
 	if text != expected {
 		t.Fatalf("Expected string:\n%v\ngot:\n%v", expected, text)
 	}
@@ -31,7 +32,7 @@ func TestIfStatement(t *testing.T) {
 
 func TestIfExpression(t *testing.T) {
 	emitter := makeEmitter()
-	emitter.emitExpression(&parser.IfExpression{
+	expr := emitter.emitExpression(&parser.IfExpression{
 		Condition: &parser.Literal{
 			Token: testToken{kind: parser.BooleanLiteral, value: "false"},
 		},
@@ -45,9 +46,37 @@ func TestIfExpression(t *testing.T) {
 		},
 	})
 
-	text := emitter.string()
+	text := js.Print(expr, js.Options{})
 	expected := "false ? undefined : false ? undefined : undefined"
 	if text != expected {
 		t.Fatalf("Expected string:\n%v\ngot:\n%v", expected, text)
 	}
 }
+
+func TestEmitNarrowedOption(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option == None { return 0 }\n"
+	source += "    option + 1\n"
+	source += "}"
+	expected := "const _f = (option) => {\n"
+	expected += "    if (__.equals(option, new __.Option(\"None\"))) {\n"
+	expected += "        return 0;\n"
+	expected += "    }\n"
+	expected += "    return option.value + 1;\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitNarrowedOptionNotEqual(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option != None { option + 1 } else { 0 }\n"
+	source += "}"
+	expected := "const _f = (option) => {\n"
+	expected += "    if (!__.equals(option, new __.Option(\"None\"))) {\n"
+	expected += "        return option.value + 1;\n"
+	expected += "    } else {\n"
+	expected += "        return 0;\n"
+	expected += "    }\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
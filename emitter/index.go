@@ -3,48 +3,80 @@ package emitter
 import (
 	"fmt"
 	"reflect"
-	"strings"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
 type Emitter struct {
-	depth        int
-	builder      strings.Builder
+	path         string // path of the emitted program
+	bundle       *bundler
+	names        map[string]string // bundled names of top-level variables
+	topLevel     *parser.Scope
+	statements   []js.Statement  // emitted top-level statements
+	body         *[]js.Statement // statements of the block being emitted
 	thisName     string
 	constructors map[string]map[string]parser.Expression
 	uninlinables map[parser.Node]int
+	references   int                  // temporaries holding operands of lowered assignments
+	newtypes     map[string]bool      // newtypes whose method holder was emitted
+	reach        *parser.Reachability // nil if everything is emitted
+	minify       bool
+	extension    string                              // of output files, for local imports
+	language     Language                            // newer syntax is lowered
+	locals       map[*parser.Scope]map[string]string // mangled names, nil if not minified
+	declarations map[declaration]*parser.Scope
 	stdEmitter
 }
 
 func makeEmitter() *Emitter {
-	return &Emitter{
-		depth:        0,
-		builder:      strings.Builder{},
+	e := &Emitter{
+		statements:   []js.Statement{},
 		constructors: map[string]map[string]parser.Expression{},
 		uninlinables: map[parser.Node]int{},
+		newtypes:     map[string]bool{},
+		extension:    ".js",
 	}
+	e.body = &e.statements
+	return e
 }
 
-func (e *Emitter) write(str string) {
-	e.builder.WriteString(str)
+// Add statements to the block being emitted. Consecutive const
+// declarations are merged in minified code.
+func (e *Emitter) add(statements ...js.Statement) {
+	for _, statement := range statements {
+		if e.minify && mergeDeclarations(*e.body, statement) {
+			continue
+		}
+		*e.body = append(*e.body, statement)
+	}
 }
 
-func (e *Emitter) indent() {
-	for i := 0; i < e.depth; i++ {
-		e.builder.WriteString("    ")
-	}
+// Collect the statements added by emit in a block
+func (e *Emitter) block(emit func()) *js.BlockStatement {
+	outer := e.body
+	block := &js.BlockStatement{Body: []js.Statement{}}
+	e.body = &block.Body
+	emit()
+	e.body = outer
+	return block
 }
 
-func (e Emitter) string() string {
-	return e.builder.String()
+func (e *Emitter) program() *js.Program {
+	return &js.Program{Body: e.statements}
+}
+
+func (e *Emitter) string() string {
+	return js.Print(e.program(), js.Options{Compact: e.minify})
 }
 
 func (e *Emitter) emitAtTopLevel(node parser.Node) {
 	switch node := node.(type) {
 	case *parser.Assignment:
 		e.extractUninlinables(node)
+		start := len(*e.body)
 		e.emitAssignment(node, true)
+		e.locateAdded(start, node)
 	default:
 		e.emit(node)
 	}
@@ -55,6 +87,7 @@ func (e *Emitter) emit(node parser.Node) {
 	if !needsEscape(node) {
 		e.extractUninlinables(node)
 	}
+	start := len(*e.body)
 	switch node := node.(type) {
 	// Statements
 	case *parser.Assignment:
@@ -68,66 +101,121 @@ func (e *Emitter) emit(node parser.Node) {
 	case *parser.IfExpression:
 		e.emitIfStatement(node)
 	case *parser.MatchExpression:
-		e.emitMatchStatement(node)
+		e.emitMatchStatement(node, false)
 	case *parser.Exit:
 		e.emitExit(node)
 	case *parser.UseDirective:
 		e.emitUseStatement(node)
+	case *parser.ExternDeclaration:
+		e.emitExternDeclaration(node)
 	case parser.Expression:
-		e.emitExpression(node)
-		e.write(";\n")
+		e.add(&js.ExpressionStatement{Expression: e.emitExpression(node)})
 	default:
 		panic(fmt.Sprintf("Cannot emit type '%v' (not implemented yet)", reflect.TypeOf(node)))
 	}
+	e.locateAdded(start, node)
 }
 
-func (e *Emitter) emitExpression(expr parser.Expression) {
+func (e *Emitter) emitExpression(expr parser.Expression) js.Expression {
+	var emitted js.Expression
 	switch expr := expr.(type) {
 	case *parser.Block:
-		e.emitBlockExpression(expr)
+		emitted = e.emitBlockExpression(expr)
 	case *parser.BinaryExpression:
-		e.emitBinaryExpression(expr)
+		emitted = e.emitBinaryExpression(expr)
 	case *parser.CallExpression:
-		e.emitCallExpression(expr, true)
+		emitted = e.emitCallExpression(expr, true)
 	case *parser.CatchExpression:
 		id, ok := e.uninlinables[expr]
 		if !ok {
 			panic("Catch expression should have been escaped!")
 		}
-		e.write(fmt.Sprintf("__tmp%v", id))
+		emitted = getTemporary(id)
 		delete(e.uninlinables, expr)
 	case *parser.ComputedAccessExpression:
-		e.emitComputedAccessExpression(expr)
+		emitted = e.emitComputedAccessExpression(expr)
 	case *parser.FunctionExpression:
-		e.emitFunctionExpression(expr)
+		emitted = e.emitFunctionExpression(expr)
 	case *parser.Identifier:
-		e.emitIdentifier(expr)
+		emitted = e.emitIdentifier(expr)
 	case *parser.IfExpression:
-		e.emitIfExpression(expr)
+		emitted = e.emitIfExpression(expr)
 	case *parser.InstanceExpression:
-		e.emitInstanceExpression(expr)
+		emitted = e.emitInstanceExpression(expr)
 	case *parser.Literal:
-		e.write(expr.Token.Text())
+		emitted = &js.Literal{Raw: expr.Token.Text()}
 	case *parser.ParenthesizedExpression:
-		e.write("(")
-		e.emit(expr.Expr)
-		e.write(")")
+		// parentheses are added by the printer where needed
+		emitted = e.emitExpression(expr.Expr)
 	case *parser.PropertyAccessExpression:
-		e.emitPropertyAccessExpression(expr, false)
+		emitted = e.emitPropertyAccessExpression(expr, false)
 	case *parser.TupleExpression:
-		e.emitTupleExpression(expr)
+		emitted = e.emitTupleExpression(expr)
 	case *parser.UnaryExpression:
-		e.emitUnaryExpression(expr)
+		emitted = e.emitUnaryExpression(expr)
+	default:
+		panic(fmt.Sprintf("Cannot emit expression '%v' (not implemented yet)", reflect.TypeOf(expr)))
+	}
+	e.locate(emitted, expr)
+	return emitted
+}
+
+func (e *Emitter) emitExpressions(exprs []parser.Expression) []js.Expression {
+	emitted := make([]js.Expression, len(exprs))
+	for i, expr := range exprs {
+		emitted[i] = e.emitExpression(expr)
+	}
+	return emitted
+}
+
+// How programs are emitted
+type Options struct {
+	Reach  *parser.Reachability // if set, unreachable top-level statements are dropped
+	Minify bool                 // mangle local names, fold constants and strip whitespace
+	// Extension of output files, '.js' if empty (e.g. '.mjs' for Node.js)
+	Extension string
+	Language  Language // newer syntax is lowered, ESNext by default
+}
+
+func (o Options) extension() string {
+	if o.Extension == "" {
+		return ".js"
 	}
+	return o.Extension
 }
 
 func EmitProgram(program parser.Program) (string, StandardFlags) {
 	e := makeEmitter()
-	e.write("const ")
-	emitScope(e, program.Scope())
-	e.write(" = {};\n")
+	e.emitProgram(program)
+	return e.string(), e.flags
+}
+
+// Same as EmitProgram, but also returns mappings to the program's source.
+// Minified code has no mappings.
+func EmitProgramWithMappings(program parser.Program, options Options) (string, []Mapping, StandardFlags) {
+	e := makeEmitter()
+	e.reach = options.Reach
+	e.minify = options.Minify
+	e.extension = options.extension()
+	e.language = options.Language
+	e.emitProgram(program)
+	if e.minify {
+		return e.string(), nil, e.flags
+	}
+	code, mappings := e.print()
+	return code, mappings, e.flags
+}
+
+func (e *Emitter) emitProgram(program parser.Program) {
+	e.path = program.Path()
+	e.topLevel = program.Scope()
+	if e.minify {
+		e.mangle(program)
+	}
+	e.add(js.Declare("const", emitScope(e, program.Scope()), &js.ObjectExpression{}))
 	for _, node := range program.Nodes() {
-		e.emitAtTopLevel(node)
+		if e.reach == nil || e.reach.IsReachable(node) {
+			e.emitAtTopLevel(node)
+		}
 	}
-	return e.string(), e.flags
 }
@@ -17,7 +17,7 @@ func (t testToken) Kind() parser.TokenKind { return t.kind }
 func (t testToken) Text() string           { return t.value }
 func (t testToken) Loc() parser.Loc        { return t.loc }
 
-func testEmitter(t *testing.T, source string, expected string, line int) {
+func parseTestProgram(t *testing.T, source string) parser.Program {
 	program, err := parser.ParseProgram(strings.NewReader(source), "")
 	if len(err) > 0 {
 		t.Log("Got unexpected parser errors:\n")
@@ -29,6 +29,11 @@ func testEmitter(t *testing.T, source string, expected string, line int) {
 		}
 		t.FailNow()
 	}
+	return program
+}
+
+func testEmitter(t *testing.T, source string, expected string, line int) {
+	program := parseTestProgram(t, source)
 	emitter := makeEmitter()
 	emitter.emit(program.Nodes()[line])
 	received := emitter.string()
@@ -36,3 +41,16 @@ func testEmitter(t *testing.T, source string, expected string, line int) {
 		t.Fatalf("expected output:\n%v\n\ngot:\n%v", expected, received)
 	}
 }
+
+// Same as testEmitter, for minified output
+func testMinifiedEmitter(t *testing.T, source string, expected string, line int) {
+	program := parseTestProgram(t, source)
+	emitter := makeEmitter()
+	emitter.minify = true
+	emitter.mangle(program)
+	emitter.emit(program.Nodes()[line])
+	received := emitter.string()
+	if received != expected {
+		t.Fatalf("expected output:\n%v\n\ngot:\n%v", expected, received)
+	}
+}
@@ -1,43 +1,29 @@
 package emitter
 
 import (
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
-func (e *Emitter) emitListInstance(constructor *parser.ListTypeExpression, args *parser.TupleExpression) {
-	e.write("[")
-	c := constructor.Expr
-	max := len(args.Elements) - 1
-	for _, arg := range args.Elements[:max] {
-		e.emitInstance(
-			c,
-			&parser.TupleExpression{Elements: []parser.Expression{arg}},
-		)
-		e.write(", ")
+func (e *Emitter) emitListInstance(constructor *parser.ListTypeExpression, args *parser.TupleExpression) js.Expression {
+	list := &js.ArrayExpression{}
+	for _, arg := range args.Elements {
+		element := &parser.TupleExpression{Elements: []parser.Expression{arg}}
+		list.Elements = append(list.Elements, e.emitInstance(constructor.Expr, element))
 	}
-	e.emitInstance(
-		c,
-		&parser.TupleExpression{Elements: []parser.Expression{args.Elements[max]}},
-	)
-	e.write("]")
+	return list
 }
-func (e *Emitter) emitMapInstance(args *parser.TupleExpression) {
+func (e *Emitter) emitMapInstance(args *parser.TupleExpression) js.Expression {
 	if len(args.Elements) == 0 {
-		e.write("new Map()")
-		return
+		return js.New(js.Name("Map"))
 	}
-	e.write("new Map([")
-
-	max := len(args.Elements) - 1
-	for _, arg := range args.Elements[:max] {
-		emitMapEntry(e, arg)
-		e.write(", ")
+	entries := &js.ArrayExpression{}
+	for _, arg := range args.Elements {
+		entries.Elements = append(entries.Elements, emitMapEntry(e, arg))
 	}
-	emitMapEntry(e, args.Elements[max])
-
-	e.write("])")
+	return js.New(js.Name("Map"), entries)
 }
-func emitMapEntry(e *Emitter, arg parser.Expression) {
+func emitMapEntry(e *Emitter, arg parser.Expression) js.Expression {
 	entry := arg.(*parser.Entry)
 	var key parser.Expression
 	if b, ok := entry.Key.(*parser.BracketedExpression); ok {
@@ -45,12 +31,10 @@ func emitMapEntry(e *Emitter, arg parser.Expression) {
 	} else {
 		key = entry.Key
 	}
-
-	e.write("[")
-	e.emitExpression(key)
-	e.write(", ")
-	e.emitExpression(entry.Value)
-	e.write("]")
+	return &js.ArrayExpression{Elements: []js.Expression{
+		e.emitExpression(key),
+		e.emitExpression(entry.Value),
+	}}
 }
 
 func findMemberByName(members *parser.TupleExpression, name string) parser.Expression {
@@ -70,105 +54,129 @@ func findMemberByName(members *parser.TupleExpression, name string) parser.Expre
 	}
 	return nil
 }
-func (e *Emitter) emitObjectInstance(constructor *parser.Identifier, args *parser.TupleExpression) {
+func (e *Emitter) emitObjectInstance(constructor *parser.Identifier, args *parser.TupleExpression) js.Expression {
+	alias := constructor.Type().(parser.Type).Value.(parser.TypeAlias)
+	if parser.IsTypeOnly(alias.From, alias.Name) {
+		return e.emitObjectLiteral(args)
+	}
+	instance := js.New(e.emitExpression(constructor))
 	if len(args.Elements) == 0 {
-		e.write("new ")
-		e.emitExpression(constructor)
-		e.write("()")
-		return
+		return instance
 	}
 
-	e.write("new ")
-	e.emitExpression(constructor)
-	e.write("(")
-	typing := constructor.Type().(parser.Type).Value.(parser.TypeAlias).Ref.(parser.Object)
+	typing := alias.Ref.(parser.Object)
+	if params, ok := parser.GetDeclaredConstructor(alias); ok {
+		typing = params
+	}
 	l := len(args.Elements)
 	i := 0
 	elements := append(append(typing.Embedded, typing.Members...), typing.Defaults...)
 	for _, m := range elements {
 		member := findMemberByName(args, m.Name)
 		if member == nil {
-			e.write("undefined")
+			instance.Arguments = append(instance.Arguments, js.Name("undefined"))
 		} else {
-			e.emitExpression(member)
+			instance.Arguments = append(instance.Arguments, e.emitExpression(member))
 			i++
 		}
-		if i != l {
-			e.write(", ")
-		} else {
+		if i == l {
 			break
 		}
 	}
-	e.write(")")
+	return instance
 }
-func (e *Emitter) emitSumInstance(constructor *parser.PropertyAccessExpression, args *parser.TupleExpression) {
-	e.write("new ")
-	e.emitExpression(constructor.Expr)
-	e.write("(\"")
-	e.emitExpression(constructor.Property)
-	e.write("\", ")
+
+// Types of declaration files which are not classes don't exist at runtime:
+// their instances are plain objects.
+func (e *Emitter) emitObjectLiteral(args *parser.TupleExpression) js.Expression {
+	object := &js.ObjectExpression{}
+	for _, arg := range args.Elements {
+		switch arg := arg.(type) {
+		case *parser.Param:
+			object.Properties = append(object.Properties, &js.Property{
+				Key:   arg.Identifier.Text(),
+				Value: e.emitExpression(arg.Complement),
+			})
+		case *parser.Entry:
+			object.Properties = append(object.Properties, &js.Property{
+				Key:   arg.Key.(*parser.Identifier).Text(),
+				Value: e.emitExpression(arg.Value),
+			})
+		}
+	}
+	return object
+}
+
+func (e *Emitter) emitSumInstance(constructor *parser.PropertyAccessExpression, args *parser.TupleExpression) js.Expression {
 	sum := constructor.Expr.(*parser.Identifier).Text()
 	cons := constructor.Property.(*parser.Identifier).Text()
-	c := e.constructors[sum][cons]
-	e.emitInstance(c, args)
-	e.write(")")
+	class := e.emitExpression(constructor.Expr)
+	c, ok := e.constructors[sum][cons]
+	if !ok {
+		return js.New(class, js.String(cons), e.emitTupleExpression(args))
+	}
+	return js.New(class, js.String(cons), e.emitInstance(c, args))
 }
 
-func (e *Emitter) emitRefInstance(constructor parser.Expression, args *parser.TupleExpression) {
+func (e *Emitter) emitRefInstance(constructor parser.Expression, args *parser.TupleExpression) js.Expression {
 	c := constructor.Type().(parser.Type).Value.(parser.Ref).To
+	value := e.emitInstance(constructor.(*parser.UnaryExpression).Operand, args)
 	if implementsNode(c) {
-		e.write("new __.NodePointer(")
 		e.addFlag(NodePointerFlag)
-	} else {
-		e.write("new __.Pointer(null, ")
-		e.addFlag(PointerFlag)
+		return js.New(js.Dot(js.Name("__"), "NodePointer"), value)
 	}
-	e.emitInstance(constructor.(*parser.UnaryExpression).Operand, args)
-	e.write(")")
+	e.addFlag(PointerFlag)
+	return js.New(js.Dot(js.Name("__"), "Pointer"), js.Name("null"), value)
 }
 
-func (e *Emitter) emitOptionInstance(args *parser.TupleExpression) {
+func (e *Emitter) emitOptionInstance(args *parser.TupleExpression) js.Expression {
 	e.addFlag(OptionFlag)
-	e.write("new __.Option(")
+	option := js.Dot(js.Name("__"), "Option")
 	if len(args.Elements) > 0 {
-		e.write(`"Some", `)
-		e.emitExpression(args.Elements[0])
-	} else {
-		e.write(`"None"`)
+		return js.New(option, js.String("Some"), e.emitExpression(args.Elements[0]))
 	}
-	e.write(")")
+	return js.New(option, js.String("None"))
+}
+
+// Newtypes have the same runtime representation as their underlying type
+func (e *Emitter) emitNewtypeInstance(args *parser.TupleExpression) js.Expression {
+	return e.emitExpression(args.Elements[0])
 }
 
-func (e *Emitter) emitInstance(constructor parser.Expression, args *parser.TupleExpression) {
+func (e *Emitter) emitInstance(constructor parser.Expression, args *parser.TupleExpression) js.Expression {
 	if isReferenceExpression(constructor) {
-		e.emitRefInstance(constructor, args)
-		return
+		return e.emitRefInstance(constructor, args)
 	}
 	switch c := constructor.(type) {
 	case *parser.ListTypeExpression:
-		e.emitListInstance(c, args)
+		return e.emitListInstance(c, args)
 	case *parser.PropertyAccessExpression:
-		e.emitSumInstance(c, args)
+		return e.emitSumInstance(c, args)
 	case *parser.ComputedAccessExpression:
-		e.emitInstance(c.Expr, args)
+		return e.emitInstance(c.Expr, args)
+	case *parser.Literal:
+		// primitive types (e.g. '[]number{1, 2}') are their value
+		return e.emitTupleExpression(args)
 	case *parser.Identifier:
 		if hasMapType(c) {
-			e.emitMapInstance(args)
+			return e.emitMapInstance(args)
+		} else if isNewtype(c.Type()) {
+			return e.emitNewtypeInstance(args)
 		} else {
-			e.emitObjectInstance(c, args)
+			return e.emitObjectInstance(c, args)
 		}
 	case *parser.BinaryExpression:
 		if hasMapType(c) {
-			e.emitMapInstance(args)
+			return e.emitMapInstance(args)
 		}
 	case *parser.UnaryExpression:
 		if c.Operator.Kind() == parser.QuestionMark {
-			e.emitOptionInstance(args)
-		} else {
-			panic("unexpected operator in constructor")
+			return e.emitOptionInstance(args)
 		}
+		panic("unexpected operator in constructor")
 	}
+	panic("unexpected constructor")
 }
-func (e *Emitter) emitInstanceExpression(expr *parser.InstanceExpression) {
-	e.emitInstance(expr.Typing, expr.Args.Expr.(*parser.TupleExpression))
+func (e *Emitter) emitInstanceExpression(expr *parser.InstanceExpression) js.Expression {
+	return e.emitInstance(expr.Typing, expr.Args.Expr.(*parser.TupleExpression))
 }
@@ -97,3 +97,9 @@ func TestMapInstance(t *testing.T) {
 	expected := "new Map([[\"value\", 42]]);\n"
 	testEmitter(t, source, expected, 0)
 }
+
+func TestNewtypeInstance(t *testing.T) {
+	source := "UserId :: new number\n"
+	source += "UserId{42}"
+	testEmitter(t, source, "42;\n", 1)
+}
@@ -0,0 +1,41 @@
+package emitter
+
+import "github.com/bmelicque/test-parser/js"
+
+// ECMAScript version of the output. Syntax from newer versions is lowered
+// to older equivalents.
+type Language int
+
+const (
+	ESNext Language = iota
+	ES2020
+	ES2018
+	ES2015
+)
+
+// Language by name, as in parser.Languages. Unknown names are ESNext.
+func ParseLanguage(name string) Language {
+	switch name {
+	case "es2015":
+		return ES2015
+	case "es2018":
+		return ES2018
+	case "es2020":
+		return ES2020
+	default:
+		return ESNext
+	}
+}
+
+// Whether the language includes the syntax of the given version
+func (l Language) has(version Language) bool {
+	return l <= version
+}
+
+// Await the value. Without async functions, awaiting is yielding to runAsync.
+func (e *Emitter) await(value js.Expression) js.Expression {
+	if e.language.has(ES2018) {
+		return &js.AwaitExpression{Argument: value}
+	}
+	return &js.YieldExpression{Argument: value}
+}
@@ -0,0 +1,61 @@
+package emitter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+const languageSource = `use log from "io"
+extern fetchText :: async (string) -> ?string from "./ffi.js"
+extern numbers :: []number
+load :: (url string) => {
+    text := fetchText(url)
+    log(text)
+    2
+}
+square :: (n number) => { n ** 2 }
+either :: (a boolean, b boolean) => {
+    c := a
+    c ||= b && a
+    c
+}
+total :: () => {
+    sum := 0
+    for n in numbers {
+        sum += n
+    }
+    sum
+}
+`
+
+// Emit languageSource in the given language, with the std parts it needs
+// and pointers, and compare it with testdata/<name>.golden
+func testLanguageGolden(t *testing.T, name string, language Language) {
+	program := parseTestProgram(t, languageSource)
+	output, _, flags := EmitProgramWithMappings(program, Options{Language: language})
+	// scope names depend on the order of tests
+	output = regexp.MustCompile(`__s\d+`).ReplaceAllString(output, "__s")
+	output += "\n" + GetStd(flags|PointerFlag|NodePointerFlag, language)
+
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		os.WriteFile(golden, []byte(output), 0644)
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Cannot read golden file: %v", err)
+	}
+	if output != string(expected) {
+		t.Fatalf("Expected:\n%v\nGot:\n%v", string(expected), output)
+	}
+}
+
+func TestLanguageES2015(t *testing.T) { testLanguageGolden(t, "es2015", ES2015) }
+func TestLanguageES2018(t *testing.T) { testLanguageGolden(t, "es2018", ES2018) }
+func TestLanguageES2020(t *testing.T) { testLanguageGolden(t, "es2020", ES2020) }
+func TestLanguageESNext(t *testing.T) { testLanguageGolden(t, "esnext", ESNext) }
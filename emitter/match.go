@@ -1,95 +1,137 @@
 package emitter
 
 import (
-	"fmt"
-
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
-func (e *Emitter) emitMatchStatement(m *parser.MatchExpression) {
-	e.write("const _m = ")
-	e.emitExpression(m.Value)
-	e.write(";\n")
+// When returned, each case returns the value of its consequent
+func (e *Emitter) emitMatchStatement(m *parser.MatchExpression, returned bool) {
+	e.add(js.Declare("const", js.Name("_m"), e.emitExpression(m.Value)))
 	t := m.Value.Type()
 	if alias, ok := t.(parser.TypeAlias); ok {
 		t = alias.Ref
 	}
 	if _, ok := t.(parser.Sum); ok {
-		emitSumMatch(e, m)
+		emitSumMatch(e, m, returned)
+		return
+	}
+	if _, ok := t.(parser.Union); ok {
+		emitUnionMatch(e, m, returned)
 		return
 	}
-	e.indent()
-	e.write("switch (_m.constructor) {\n")
+	s := &js.SwitchStatement{Discriminant: js.Dot(js.Name("_m"), "constructor")}
 	for _, c := range m.Cases {
-		e.indent()
-		if c.IsCatchall() {
-			e.write("default:")
-		} else if call, ok := c.Pattern.(*parser.InstanceExpression); ok {
-			e.write("case ")
-			e.emitExpression(call.Typing)
-			e.write(": {\n")
-		} else if id, ok := c.Pattern.(*parser.Identifier); ok {
-			e.write("case ")
-			e.emitIdentifier(id)
-			e.write(": {\n")
+		var test js.Expression
+		switch pattern := c.Pattern.(type) {
+		case *parser.InstanceExpression:
+			test = e.emitExpression(pattern.Typing)
+		case *parser.Identifier:
+			if !c.IsCatchall() {
+				test = e.emitIdentifier(pattern)
+			}
 		}
-		e.depth++
-		if c.Pattern != nil {
-			pattern := getMatchedName(c.Pattern)
-			e.indent()
-			e.write(fmt.Sprintf("let %v = _m;\n", pattern))
-		}
-		e.emitExpression(c.Consequent)
-		e.write(";\n")
-		e.depth--
-		e.indent()
-		e.write("}\n")
+		s.Cases = append(s.Cases, emitCase(e, c, test, js.Name("_m"), returned))
 	}
-	e.indent()
-	e.write("}\n")
+	e.add(s)
 }
 
-func emitSumMatch(e *Emitter, m *parser.MatchExpression) {
-	e.indent()
-	e.write("switch (_m.tag) {\n")
+func emitSumMatch(e *Emitter, m *parser.MatchExpression, returned bool) {
+	s := &js.SwitchStatement{Discriminant: js.Dot(js.Name("_m"), "tag")}
 	for _, c := range m.Cases {
-		e.indent()
-		if c.IsCatchall() {
-			e.write("default:")
-		} else if param, ok := c.Pattern.(*parser.Param); ok {
-			e.write("case \"")
-			e.emitExpression(param.Complement)
-			e.write("\": {\n")
-		} else if id, ok := c.Pattern.(*parser.Identifier); ok {
-			e.write("case \"")
-			e.emitIdentifier(id)
-			e.write("\": {\n")
-		} else {
+		var test js.Expression
+		switch pattern := c.Pattern.(type) {
+		case *parser.Param:
+			test = js.String(pattern.Complement.(*parser.Identifier).Text())
+		case *parser.Identifier:
+			if !c.IsCatchall() {
+				test = js.String(pattern.Text())
+			}
+		default:
 			panic("unexpected case pattern")
 		}
-		e.depth++
-		if c.Pattern != nil {
-			pattern := getMatchedName(c.Pattern)
-			e.indent()
-			e.write(fmt.Sprintf("let %v = _m.value;\n", pattern))
+		s.Cases = append(s.Cases, emitCase(e, c, test, js.Dot(js.Name("_m"), "value"), returned))
+	}
+	e.add(s)
+}
+
+// Cases are blocks, so that their bindings don't collide, which end with a
+// break unless they exit or return. Params of patterns are bound to the
+// matched value.
+func emitCase(e *Emitter, c parser.MatchCase, test js.Expression, matched js.Expression, returned bool) *js.SwitchCase {
+	block := e.block(func() {
+		if param, ok := c.Pattern.(*parser.Param); ok {
+			e.add(js.Declare("let", js.Name(e.getMatchedName(param)), matched))
+		}
+		emitCaseConsequent(e, c.Consequent, returned)
+		if _, ok := c.Consequent.(*parser.Exit); !ok && !returned {
+			e.add(&js.BreakStatement{})
 		}
-		e.indent()
-		e.emitExpression(c.Consequent)
-		e.write(";\n")
-		e.depth--
-		e.indent()
-		e.write("}\n")
+	})
+	return &js.SwitchCase{Test: test, Consequent: []js.Statement{block}}
+}
+
+// Union members are not tagged, so cases are checked in order with type tests
+func emitUnionMatch(e *Emitter, m *parser.MatchExpression, returned bool) {
+	var first, last *js.IfStatement
+	for _, c := range m.Cases {
+		var param *parser.Param
+		var test js.Expression
+		switch pattern := c.Pattern.(type) {
+		case *parser.Param:
+			param = pattern
+			t := param.Complement.Type().(parser.Type).Value
+			test = emitTypeTest(e, func() js.Expression { return js.Name("_m") }, t)
+		case *parser.Literal:
+			test = strictEqual(js.Name("_m"), e.emitExpression(pattern))
+		}
+		block := e.block(func() {
+			if param != nil {
+				e.add(js.Declare("let", js.Name(e.getMatchedName(param)), js.Name("_m")))
+			}
+			emitCaseConsequent(e, c.Consequent, returned)
+		})
+		if test == nil {
+			// catch-all case
+			if last == nil {
+				e.add(block)
+			} else {
+				last.Alternate = block
+			}
+			break
+		}
+		statement := &js.IfStatement{Test: test, Consequent: block}
+		if last == nil {
+			first = statement
+		} else {
+			last.Alternate = statement
+		}
+		last = statement
+	}
+	if first != nil {
+		e.add(first)
+	}
+}
+
+// Exits are emitted as statements (e.g. '_: return 0')
+func emitCaseConsequent(e *Emitter, consequent parser.Expression, returned bool) {
+	if returned {
+		e.emitReturn(consequent)
+		return
+	}
+	if exit, ok := consequent.(*parser.Exit); ok {
+		e.emitExit(exit)
+		return
 	}
-	e.indent()
-	e.write("}\n")
+	e.add(&js.ExpressionStatement{Expression: e.emitExpression(consequent)})
 }
 
-func getMatchedName(pattern parser.Expression) string {
+func (e *Emitter) getMatchedName(pattern parser.Expression) string {
 	switch pattern := pattern.(type) {
 	case *parser.Identifier:
-		return pattern.Text()
+		return e.getVariableName(pattern)
 	case *parser.Param:
-		return pattern.Identifier.Text()
+		return e.getVariableName(pattern.Identifier)
 	default:
 		panic("unexpected case param")
 	}
@@ -0,0 +1,88 @@
+package emitter
+
+import "testing"
+
+func TestEmitUnionMatch(t *testing.T) {
+	source := "_f :: (x number | string) => {\n"
+	source += "    match x {\n"
+	source += "        n number: n\n"
+	source += "        _: 0\n"
+	source += "    }\n"
+	source += "    x\n"
+	source += "}"
+
+	expected := "const _f = (x) => {\n"
+	expected += "    const _m = x;\n"
+	expected += "    if (typeof _m === \"number\") {\n"
+	expected += "        let n = _m;\n"
+	expected += "        n;\n"
+	expected += "    } else {\n"
+	expected += "        0;\n"
+	expected += "    }\n"
+	expected += "    return x;\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitLiteralUnionMatch(t *testing.T) {
+	source := "_f :: (mode \"light\" | \"dark\") => {\n"
+	source += "    match mode {\n"
+	source += "        \"light\": 1\n"
+	source += "        \"dark\": 2\n"
+	source += "    }\n"
+	source += "    mode\n"
+	source += "}"
+
+	expected := "const _f = (mode) => {\n"
+	expected += "    const _m = mode;\n"
+	expected += "    if (_m === \"light\") {\n"
+	expected += "        1;\n"
+	expected += "    } else if (_m === \"dark\") {\n"
+	expected += "        2;\n"
+	expected += "    }\n"
+	expected += "    return mode;\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitExitingCases(t *testing.T) {
+	source := "_f :: (x number | string) => number {\n"
+	source += "    match x {\n"
+	source += "        n number: n\n"
+	source += "        _: return 0\n"
+	source += "    }\n"
+	source += "    1\n"
+	source += "}"
+
+	expected := "const _f = (x) => {\n"
+	expected += "    const _m = x;\n"
+	expected += "    if (typeof _m === \"number\") {\n"
+	expected += "        let n = _m;\n"
+	expected += "        n;\n"
+	expected += "    } else {\n"
+	expected += "        return 0;\n"
+	expected += "    }\n"
+	expected += "    return 1;\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitReturnedUnionMatch(t *testing.T) {
+	source := "_describe :: (v number | string) => {\n"
+	source += "    match v {\n"
+	source += "        n number: \"number\"\n"
+	source += "        s string: s\n"
+	source += "    }\n"
+	source += "}"
+	expected := "const _describe = (v) => {\n"
+	expected += "    const _m = v;\n"
+	expected += "    if (typeof _m === \"number\") {\n"
+	expected += "        let n = _m;\n"
+	expected += "        return \"number\";\n"
+	expected += "    } else if (typeof _m === \"string\") {\n"
+	expected += "        let s = _m;\n"
+	expected += "        return s;\n"
+	expected += "    }\n"
+	expected += "}\n"
+	testEmitter(t, source, expected, 0)
+}
@@ -0,0 +1,230 @@
+package emitter
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Global names written as is by the emitter or std helpers, which mangled
+// names must not hide.
+var jsGlobals = []string{
+	"Array", "Error", "Infinity", "JSON", "Map", "Math", "NaN", "Number",
+	"Object", "Promise", "Set", "String", "Symbol", "console", "document",
+	"globalThis", "of", "structuredClone", "undefined", "window",
+}
+
+// Where a local variable is declared, to find its scope from declarations
+// (which are not resolved by the checker)
+type declaration struct {
+	name  string
+	start parser.Position
+}
+
+// Pick short names for the variables of the program's inner scopes.
+//
+// Variables get the next names after the ones of enclosing scopes, so they
+// never hide them, while sibling scopes reuse the same names. Top-level
+// names are left as is, since they can be exported.
+func (e *Emitter) mangle(program parser.Program) {
+	e.locals = map[*parser.Scope]map[string]string{}
+	e.declarations = map[declaration]*parser.Scope{}
+	reserved := map[string]bool{}
+	for _, name := range append(reservedWords, jsGlobals...) {
+		reserved[name] = true
+	}
+	for _, name := range program.Scope().Names() {
+		reserved[e.getTopLevelName(name)] = true
+	}
+	if e.bundle != nil {
+		for name := range e.bundle.taken {
+			reserved[name] = true
+		}
+	}
+
+	generated := []string{}
+	getName := func(i int) string {
+		for n := len(generated); len(generated) <= i; n++ {
+			if name := shortName(n); !reserved[name] {
+				generated = append(generated, name)
+			}
+		}
+		return generated[i]
+	}
+
+	bases := map[*parser.Scope]int{}
+	var getBase func(scope *parser.Scope) int
+	getBase = func(scope *parser.Scope) int {
+		outer := scope.Outer()
+		if outer == nil || outer == program.Scope() {
+			return 0
+		}
+		if base, ok := bases[scope]; ok {
+			return base
+		}
+		bases[scope] = getBase(outer) + len(outer.Names())
+		return bases[scope]
+	}
+
+	for _, scope := range program.Scopes() {
+		if scope == program.Scope() {
+			continue
+		}
+		names := map[string]string{}
+		base := getBase(scope)
+		for i, name := range scope.Names() {
+			names[name] = getName(base + i)
+			start := scope.FindLocal(name).DeclaredAt().Start
+			e.declarations[declaration{name, start}] = scope
+		}
+		e.locals[scope] = names
+	}
+}
+
+// Get the mangled name of an identifier, if it refers to a local variable
+func (e *Emitter) getLocalName(i *parser.Identifier) (string, bool) {
+	if e.locals == nil {
+		return "", false
+	}
+	scope := i.GetScope()
+	if scope == nil {
+		scope = e.declarations[declaration{i.Text(), i.Loc().Start}]
+	}
+	name, ok := e.locals[scope][i.Text()]
+	return name, ok
+}
+
+// Name of a local variable in the emitted code
+func (e *Emitter) getVariableName(i *parser.Identifier) string {
+	if name, ok := e.getLocalName(i); ok {
+		return name
+	}
+	return getSanitizedName(i.Text())
+}
+
+// Short identifiers: 'a' to 'Z', then 'aa', 'ba'...
+func shortName(n int) string {
+	const first = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	const rest = first + "0123456789"
+	name := []byte{first[n%len(first)]}
+	n /= len(first)
+	for n > 0 {
+		n--
+		name = append(name, rest[n%len(rest)])
+		n /= len(rest)
+	}
+	return string(name)
+}
+
+// Get the value of an expression made of literals only, if it can be
+// computed at compile time.
+func foldConstant(expr parser.Expression) (js.Expression, bool) {
+	value, ok := evaluateConstant(expr)
+	if !ok {
+		return nil, false
+	}
+	switch value := value.(type) {
+	case bool:
+		return &js.Literal{Raw: strconv.FormatBool(value)}, true
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) || value == 0 && math.Signbit(value) {
+			return nil, false
+		}
+		text := strconv.FormatFloat(math.Abs(value), 'f', -1, 64)
+		if short := strconv.FormatFloat(math.Abs(value), 'g', -1, 64); len(short) < len(text) {
+			text = short
+		}
+		if value < 0 {
+			return &js.UnaryExpression{Operator: "-", Argument: &js.Literal{Raw: text}}, true
+		}
+		return &js.Literal{Raw: text}, true
+	}
+	return nil, false
+}
+
+func evaluateConstant(expr parser.Expression) (any, bool) {
+	switch expr := expr.(type) {
+	case *parser.Literal:
+		switch expr.Token.Kind() {
+		case parser.NumberLiteral:
+			value, err := strconv.ParseFloat(strings.ReplaceAll(expr.Token.Text(), "_", ""), 64)
+			return value, err == nil
+		case parser.BooleanLiteral:
+			return expr.Token.Text() == "true", true
+		}
+	case *parser.ParenthesizedExpression:
+		return evaluateConstant(expr.Expr)
+	case *parser.UnaryExpression:
+		if expr.Operator.Kind() != parser.Bang {
+			return nil, false
+		}
+		value, ok := evaluateConstant(expr.Operand)
+		b, isBool := value.(bool)
+		return !b, ok && isBool
+	case *parser.BinaryExpression:
+		return evaluateBinaryConstant(expr)
+	}
+	return nil, false
+}
+
+func evaluateBinaryConstant(expr *parser.BinaryExpression) (any, bool) {
+	right, ok := evaluateConstant(expr.Right)
+	if !ok {
+		return nil, false
+	}
+	if expr.Left == nil {
+		value, ok := right.(float64)
+		return -value, ok && expr.Operator.Kind() == parser.Sub
+	}
+	left, ok := evaluateConstant(expr.Left)
+	if !ok {
+		return nil, false
+	}
+	if l, ok := left.(bool); ok {
+		r, ok := right.(bool)
+		switch expr.Operator.Kind() {
+		case parser.LogicalAnd:
+			return l && r, ok
+		case parser.LogicalOr:
+			return l || r, ok
+		}
+		return nil, false
+	}
+	l, _ := left.(float64)
+	r, ok := right.(float64)
+	if !ok {
+		return nil, false
+	}
+	switch expr.Operator.Kind() {
+	case parser.Add:
+		return l + r, true
+	case parser.Sub:
+		return l - r, true
+	case parser.Mul:
+		return l * r, true
+	case parser.Div:
+		return l / r, true
+	case parser.Mod:
+		return math.Mod(l, r), true
+	}
+	return nil, false
+}
+
+// Merge a const declaration into the previous statement, if it is a
+// const declaration too (e.g. 'const a=1,b=2;').
+// Returns false if the declaration must be added.
+func mergeDeclarations(statements []js.Statement, statement js.Statement) bool {
+	declaration, ok := statement.(*js.VariableDeclaration)
+	if !ok || declaration.Kind != "const" || len(statements) == 0 {
+		return false
+	}
+	previous, ok := statements[len(statements)-1].(*js.VariableDeclaration)
+	if !ok || previous.Kind != "const" || previous.Export != declaration.Export {
+		return false
+	}
+	previous.Declarations = append(previous.Declarations, declaration.Declarations...)
+	return true
+}
@@ -0,0 +1,92 @@
+package emitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func TestMinifyFunction(t *testing.T) {
+	source := "_add :: (first number, second number) => {\n"
+	source += "    total := first + second\n"
+	source += "    total * 2\n"
+	source += "}"
+	expected := "const _add=(a,b)=>{let c=a+b;return c*2;}\n"
+	testMinifiedEmitter(t, source, expected, 0)
+}
+
+func TestMinifySiblingScopes(t *testing.T) {
+	source := "_f :: (x number | string) => {\n"
+	source += "    match x {\n"
+	source += "        n number: n\n"
+	source += "        s string: s\n"
+	source += "    }\n"
+	source += "    x\n"
+	source += "}"
+	expected := "const _f=(a)=>{const _m=a;if(typeof _m===\"number\"){let b=_m;b;}else if(typeof _m===\"string\"){let b=_m;b;}return a;}\n"
+	testMinifiedEmitter(t, source, expected, 0)
+}
+
+func TestMinifyConstantFolding(t *testing.T) {
+	testMinifiedEmitter(t, "_day :: 60 * 60 * (24 - 1)", "const _day=82800;\n", 0)
+	testMinifiedEmitter(t, "_x :: 1 - 3", "const _x=-2;\n", 0)
+	testMinifiedEmitter(t, "_b :: true && !false", "const _b=true;\n", 0)
+	testMinifiedEmitter(t, "_y :: 1 / 0", "const _y=1/0;\n", 0)
+}
+
+func TestMinifyCode(t *testing.T) {
+	e := makeEmitter()
+	e.minify = true
+	decrement := &js.BinaryExpression{
+		Operator: "-",
+		Left:     js.Name("a"),
+		Right:    &js.UnaryExpression{Operator: "-", Argument: &js.Literal{Raw: "1"}},
+	}
+	b := js.Declare("const", js.Name("b"), &js.ArrowFunction{
+		Body: &js.BlockStatement{Body: []js.Statement{&js.ReturnStatement{Argument: decrement}}},
+	})
+	b.Export = true
+	c := js.Declare("const", js.Name("c"), js.String("a  b"))
+	c.Export = true
+	e.add(js.Declare("const", js.Name("a"), &js.Literal{Raw: "1"}), b, c)
+	e.add(&js.ForOfStatement{
+		Left:  js.Declare("const", js.Name("x"), nil),
+		Right: js.Name("y"),
+		Body: e.block(func() {
+			typeOf := &js.UnaryExpression{Operator: "typeof", Argument: js.Name("x")}
+			e.add(js.Declare("const", js.Name("z"), typeOf))
+			e.add(js.Declare("const", js.Name("w"), js.Name("z")))
+		}),
+	})
+	expected := "const a=1;export const b=()=>{return a- -1;},c=\"a  b\";for(const x of y){const z=typeof x,w=z;}\n"
+	if received := e.string(); received != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, received)
+	}
+}
+
+func TestShortName(t *testing.T) {
+	names := map[string]bool{}
+	for i := 0; i < 4000; i++ {
+		name := shortName(i)
+		if names[name] {
+			t.Fatalf("name %q generated twice", name)
+		}
+		names[name] = true
+	}
+	if shortName(0) != "a" || shortName(51) != "Z" || shortName(52) != "aa" {
+		t.Fatalf("unexpected names: %v, %v, %v", shortName(0), shortName(51), shortName(52))
+	}
+}
+
+func TestMinifyBundle(t *testing.T) {
+	dir := t.TempDir()
+	lib := parseBundled(t, dir, "lib", "a :: (b number) => { b * 2 }")
+	main := parseBundled(t, dir, "main", "use a from \"./lib\"\n_f :: (x number) => { a(x) }")
+	output, _ := EmitBundle([]parser.Program{lib, main}, Options{Minify: true})
+	// locals must not hide the top-level 'a' used by '_f'
+	if !strings.Contains(output, "_f=(b)=>{return a(b);}") {
+		t.Fatalf("unexpected output:\n%v", output)
+	}
+}
@@ -1,75 +1,100 @@
 package emitter
 
-import "github.com/bmelicque/test-parser/parser"
+import (
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
 
-func (e *Emitter) emitPropertyAccessExpression(p *parser.PropertyAccessExpression, isCalled bool) {
+func (e *Emitter) emitPropertyAccessExpression(p *parser.PropertyAccessExpression, isCalled bool) js.Expression {
 	_, isMethod := p.Type().(parser.Function)
 	switch {
+	case isNewtype(p.Expr.Type()):
+		return emitNewtypeProperty(e, p)
 	case isDomType(p.Expr, "Document"):
 		switch p.Property.(*parser.Identifier).Text() {
 		case "body":
-			e.write("__.getDocumentBody(")
-			e.emitExpression(p.Expr)
-			e.write(")")
 			e.addFlag(DocumentGetBodyFlag)
+			return js.Call(js.Dot(js.Name("__"), "getDocumentBody"), e.emitExpression(p.Expr))
 		case "setBody":
-			e.write("__.setDocumentBody(")
-			e.emitExpression(p.Expr)
-			e.write(")")
 			e.addFlag(DocumentSetBodyFlag)
+			return js.Call(js.Dot(js.Name("__"), "setDocumentBody"), e.emitExpression(p.Expr))
 		default:
-			emitPropertyAccess(e, p)
+			return emitPropertyAccess(e, p)
 		}
 	case isMethod && implementsNode(p.Expr.Type()):
-		emitNodeMethod(e, p)
+		return emitNodeMethod(e, p)
 	case isMethod && !isCalled:
-		emitNoncalledMethod(e, p)
+		return emitNoncalledMethod(e, p)
 	default:
-		emitPropertyAccess(e, p)
+		return emitPropertyAccess(e, p)
 	}
 }
 
 // Native methods on nodes are tricky to handle (concerning refs for example)
-func emitNodeMethod(e *Emitter, p *parser.PropertyAccessExpression) {
+func emitNodeMethod(e *Emitter, p *parser.PropertyAccessExpression) js.Expression {
 	e.addFlag(WrapNodeMethodFlag)
-	e.write("__.wrapNodeMethod(")
-	e.emitExpression(p.Expr)
+	object := e.emitExpression(p.Expr)
 	if isDomType(p.Expr, "DocumentBody") {
-		e.write(".value")
+		object = js.Dot(object, "value")
 	}
-	e.write(", \"")
-	e.emitExpression(p.Property)
+	returnsNode := "0"
 	f := p.Type().(parser.Function)
-	if _, returnsNode := f.Returned.(parser.Ref); returnsNode {
-		e.write("\", 1)")
-	} else {
-		e.write("\", 0)")
+	if _, ok := f.Returned.(parser.Ref); ok {
+		returnsNode = "1"
 	}
+	wrap := js.Dot(js.Name("__"), "wrapNodeMethod")
+	return js.Call(wrap, object, emitPropertyName(p), &js.Literal{Raw: returnsNode})
 }
 
 // A method which is not called has to be bound to handle correct behavior of "this".
 // Method on Node trait has to have extra handling.
-func emitNoncalledMethod(e *Emitter, p *parser.PropertyAccessExpression) {
+func emitNoncalledMethod(e *Emitter, p *parser.PropertyAccessExpression) js.Expression {
 	e.addFlag(BindFlag)
-	e.write("__.bind(")
-	e.emitExpression(p.Expr)
-	e.write(", \"")
-	e.emitExpression(p.Property)
-	e.write("\")")
+	return js.Call(js.Dot(js.Name("__"), "bind"), e.emitExpression(p.Expr), emitPropertyName(p))
 }
 
-func emitPropertyAccess(e *Emitter, p *parser.PropertyAccessExpression) {
-	e.emitExpression(p.Expr)
+// Name of the accessed property, as a string literal
+func emitPropertyName(p *parser.PropertyAccessExpression) js.Expression {
+	return js.String(p.Property.(*parser.Identifier).Text())
+}
+
+// Newtypes are erased: unwrapping is a no-op and methods are static functions.
+// Called methods are handled by emitNewtypeMethodCall.
+func emitNewtypeProperty(e *Emitter, p *parser.PropertyAccessExpression) js.Expression {
+	if p.Property.(*parser.Identifier).Text() == "value" {
+		return emitNewtypeReceiver(e, p)
+	}
+	bind := js.Dot(emitNewtypeMethod(e, p), "bind")
+	return js.Call(bind, js.Name("null"), emitNewtypeReceiver(e, p))
+}
+func emitNewtypeMethod(e *Emitter, p *parser.PropertyAccessExpression) js.Expression {
+	t := p.Expr.Type()
+	if ref, ok := t.(parser.Ref); ok {
+		t = ref.To
+	}
+	return &js.MemberExpression{
+		Object:   js.Name(t.(parser.TypeAlias).Name),
+		Property: e.emitExpression(p.Property),
+	}
+}
+func emitNewtypeReceiver(e *Emitter, p *parser.PropertyAccessExpression) js.Expression {
+	receiver := e.emitExpression(p.Expr)
+	if _, isRef := p.Expr.Type().(parser.Ref); isRef {
+		return js.Call(receiver, &js.Literal{Raw: "1"})
+	}
+	return receiver
+}
+
+func emitPropertyAccess(e *Emitter, p *parser.PropertyAccessExpression) js.Expression {
+	object := e.emitExpression(p.Expr)
 	if _, isRef := p.Expr.Type().(parser.Ref); isRef {
-		e.write("(1)")
+		object = js.Call(object, &js.Literal{Raw: "1"})
 	}
-	if _, ok := p.Expr.Type().(parser.Tuple); ok {
-		e.write("[")
-		e.emitExpression(p.Property)
-		e.write("]")
-	} else {
-		e.write(".")
-		e.emitExpression(p.Property)
+	_, isTuple := p.Expr.Type().(parser.Tuple)
+	return &js.MemberExpression{
+		Object:   object,
+		Property: e.emitExpression(p.Property),
+		Computed: isTuple,
 	}
 }
 
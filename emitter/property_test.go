@@ -22,3 +22,27 @@ func TestEmitIndirectPropertyAccess(t *testing.T) {
 
 	testEmitter(t, source, expected, 3)
 }
+
+func TestNewtypeUnwrap(t *testing.T) {
+	source := "UserId :: new number\n"
+	source += "id := UserId{42}\n"
+	source += "id.value + 1"
+	testEmitter(t, source, "id + 1;\n", 2)
+}
+
+func TestNewtypeMethodCall(t *testing.T) {
+	source := "UserId :: new number\n"
+	source += "(id UserId).add :: (n number) => { UserId{id.value + n} }\n"
+	source += "id := UserId{42}\n"
+	source += "id.add(1)"
+	testEmitter(t, source, "UserId.add(id, 1);\n", 3)
+}
+
+func TestNewtypeMethodAccess(t *testing.T) {
+	source := "UserId :: new number\n"
+	source += "(id UserId).add :: (n number) => { UserId{id.value + n} }\n"
+	source += "id := UserId{42}\n"
+	source += "add := id.add\n"
+	source += "add(1)"
+	testEmitter(t, source, "let add = UserId.add.bind(null, id);\n", 3)
+}
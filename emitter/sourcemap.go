@@ -0,0 +1,145 @@
+package emitter
+
+import (
+	"reflect"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/bmelicque/test-parser/js"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Links a position in the emitted code to a position in the source.
+// Lines and columns are 0-based, columns count UTF-16 code units in the
+// emitted code and bytes in the source (like parser.Position).
+type Mapping struct {
+	GeneratedLine int
+	GeneratedCol  int
+	SourceLine    int
+	SourceCol     int
+}
+
+// Source map, revision 3
+type SourceMap struct {
+	Version        int      `json:"version"`
+	File           string   `json:"file"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent"`
+	Names          []string `json:"names"`
+	Mappings       string   `json:"mappings"`
+}
+
+// Make the source map of an emitted file, generated from a single source
+func NewSourceMap(file string, source string, content string, mappings []Mapping) SourceMap {
+	return SourceMap{
+		Version:        3,
+		File:           file,
+		Sources:        []string{source},
+		SourcesContent: []string{content},
+		Names:          []string{},
+		Mappings:       encodeMappings(toUTF16Columns(mappings, content)),
+	}
+}
+
+// Source maps count columns in UTF-16 code units
+func toUTF16Columns(mappings []Mapping, content string) []Mapping {
+	lines := strings.Split(content, "\n")
+	converted := make([]Mapping, len(mappings))
+	for i, m := range mappings {
+		if m.SourceLine < len(lines) {
+			line := lines[m.SourceLine]
+			m.SourceCol = len(utf16.Encode([]rune(line[:min(m.SourceCol, len(line))])))
+		}
+		converted[i] = m
+	}
+	return converted
+}
+
+// Encode mappings, which are expected to be sorted by generated position
+func encodeMappings(mappings []Mapping) string {
+	var b strings.Builder
+	var line, col, sourceLine, sourceCol int
+	for i, m := range mappings {
+		if m.GeneratedLine != line {
+			for ; line < m.GeneratedLine; line++ {
+				b.WriteByte(';')
+			}
+			col = 0
+		} else if i > 0 {
+			b.WriteByte(',')
+		}
+		writeVLQ(&b, m.GeneratedCol-col)
+		writeVLQ(&b, 0) // always the first source
+		writeVLQ(&b, m.SourceLine-sourceLine)
+		writeVLQ(&b, m.SourceCol-sourceCol)
+		col, sourceLine, sourceCol = m.GeneratedCol, m.SourceLine, m.SourceCol
+	}
+	return b.String()
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// Write a base64 variable-length quantity: the sign is the lowest bit,
+// then groups of 5 bits (lowest first) with a continuation bit.
+func writeVLQ(b *strings.Builder, value int) {
+	v := value << 1
+	if value < 0 {
+		v = (-value << 1) | 1
+	}
+	for {
+		digit := v & 31
+		v >>= 5
+		if v > 0 {
+			digit |= 32
+		}
+		b.WriteByte(base64Chars[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
+
+// Link the emitted node to the start of the source node
+func (e *Emitter) locate(emitted js.Node, node parser.Node) {
+	if emitted == nil || isNil(node) {
+		return
+	}
+	loc := node.Loc()
+	// nodes built by hand may lack tokens, and then have no location
+	if loc.Start.Line == 0 {
+		return
+	}
+	js.Locate(emitted, js.Position{Line: loc.Start.Line - 1, Col: loc.Start.Col - 1})
+}
+
+// Link the first statement added to the current block since start, if any,
+// to the source node
+func (e *Emitter) locateAdded(start int, node parser.Node) {
+	if len(*e.body) > start {
+		e.locate((*e.body)[start], node)
+	}
+}
+
+// Print the emitted program, with mappings to its source
+func (e *Emitter) print() (string, []Mapping) {
+	code, located := js.PrintWithMappings(e.program(), js.Options{})
+	mappings := make([]Mapping, len(located))
+	for i, m := range located {
+		mappings[i] = Mapping{
+			GeneratedLine: m.Generated.Line,
+			GeneratedCol:  m.Generated.Col,
+			SourceLine:    m.Source.Line,
+			SourceCol:     m.Source.Col,
+		}
+	}
+	return code, mappings
+}
+
+// Nodes can be typed nil pointers, e.g. missing parts of invalid code
+func isNil(node parser.Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,115 @@
+package emitter
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func decodeVLQ(t *testing.T, s string) []int {
+	values := []int{}
+	shift, value := 0, 0
+	for _, c := range s {
+		digit := strings.IndexRune(base64Chars, c)
+		if digit < 0 {
+			t.Fatalf("invalid base64 character %q", c)
+		}
+		value |= (digit & 31) << shift
+		shift += 5
+		if digit&32 != 0 {
+			continue
+		}
+		if value&1 == 1 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		shift, value = 0, 0
+	}
+	return values
+}
+
+func decodeMappings(t *testing.T, mappings string) []Mapping {
+	decoded := []Mapping{}
+	var sourceLine, sourceCol int
+	for line, segments := range strings.Split(mappings, ";") {
+		col := 0
+		for _, segment := range strings.Split(segments, ",") {
+			if segment == "" {
+				continue
+			}
+			values := decodeVLQ(t, segment)
+			if len(values) != 4 {
+				t.Fatalf("expected 4 fields, got %v", values)
+			}
+			col += values[0]
+			sourceLine += values[2]
+			sourceCol += values[3]
+			decoded = append(decoded, Mapping{line, col, sourceLine, sourceCol})
+		}
+	}
+	return decoded
+}
+
+func TestVLQ(t *testing.T) {
+	values := []int{0, 1, -1, 15, 16, -16, 1000, -123456}
+	var b strings.Builder
+	for _, v := range values {
+		writeVLQ(&b, v)
+	}
+	if !strings.HasPrefix(b.String(), "ACDegBhB") {
+		t.Fatalf("unexpected encoding: %v", b.String())
+	}
+	decoded := decodeVLQ(t, b.String())
+	for i := range values {
+		if decoded[i] != values[i] {
+			t.Fatalf("expected %v, got %v", values, decoded)
+		}
+	}
+}
+
+var identifier = regexp.MustCompile(`^[a-zA-Z_]\w*`)
+
+// Every mapped identifier should be emitted at one of the positions mapped to it
+// (statements are mapped too, e.g. 'const x' to 'x')
+func TestSourceMapMappings(t *testing.T) {
+	source := "_add :: (a number, b number) => {\n    a + b\n}\n_result := _add(1, 2)\nif _result > 2 {\n    _result = 0\n}"
+	program, errors := parser.ParseProgram(strings.NewReader(source), "main")
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors: %#v", errors)
+	}
+	output, mappings, _ := EmitProgramWithMappings(program, Options{})
+	m := NewSourceMap("main.js", "main", source, mappings)
+
+	decoded := decodeMappings(t, m.Mappings)
+	if len(decoded) != len(mappings) {
+		t.Fatalf("expected %v mappings, got %v", len(mappings), len(decoded))
+	}
+	sourceLines := strings.Split(source, "\n")
+	outputLines := strings.Split(output, "\n")
+	generated := map[parser.Position][]string{}
+	for _, d := range decoded {
+		at := parser.Position{Line: d.SourceLine, Col: d.SourceCol}
+		generated[at] = append(generated[at], outputLines[d.GeneratedLine][d.GeneratedCol:])
+	}
+	checked := 0
+	for at, texts := range generated {
+		name := identifier.FindString(sourceLines[at.Line][at.Col:])
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(texts, func(s string) bool { return strings.HasPrefix(s, name) }) {
+			t.Fatalf("'%v' at %v:%v is mapped to %q", name, at.Line+1, at.Col+1, texts)
+		}
+		checked++
+	}
+	if checked < 5 {
+		t.Fatalf("expected identifiers to be mapped, got %#v", decoded)
+	}
+	if len(m.SourcesContent) != 1 || m.SourcesContent[0] != source || m.Version != 3 {
+		t.Fatalf("unexpected source map: %#v", m)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -20,59 +21,94 @@ func fileDoesNotExists(path string) bool {
 	_, err := os.Stat(path)
 	return errors.Is(err, os.ErrNotExist)
 }
-func CreateStdName(rootDir string) string {
+func CreateStdName(rootDir string, extension string) string {
 	for {
-		name := createRandomString(8) + ".js"
-		if fileDoesNotExists(filepath.Join(rootDir, createRandomString(8))) {
+		name := createRandomString(8) + extension
+		if fileDoesNotExists(filepath.Join(rootDir, name)) {
 			return name
 		}
 	}
 }
 
-// returns the path to the .js file containing std lib
-func EmitStd(filePath string, flags StandardFlags) {
-	f, err := os.Create(filePath)
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
+type stdPart struct {
+	flag StandardFlags
+	name string
+	code string // declaration of name
+}
 
-	if hasFlag(flags, SumFlag) {
-		f.WriteString("export function Sum(t,v){this.tag=t;this.value=v}\n")
-	}
-	if hasFlag(flags, OptionFlag) {
-		f.WriteString("export class Option extends Sum{}\n")
-	}
-	if hasFlag(flags, PointerFlag) {
-		f.WriteString("export class Pointer{constructor(c,n){this.c=c;this.n=n}get(){return this.c?.[this.n]??this.n}set(v){this.c?(this.c[this.n]=v):(this.n=v)}}\n")
-	}
-	if hasFlag(flags, NodePointerFlag) {
-		f.WriteString("export class NodePointer{constructor(v){this._=v}get(){return this._}set(v){this._.parentNode?.replaceChild(this._,v);this._=v}}\n")
-	}
-	if hasFlag(flags, DeepEqualFlag) {
-		f.WriteString(`export let equals=(a,b,t=typeof a)=>(t==typeof b&&(t!="object"||a==null||b==null?a==b:a.constructor==b.constructor&&(a instanceof NodePointer?a.get()==b.get():!(Array.isArray(a)&&a.length-b.length)&&!Object.keys(a).find(k=>!equals(a[k],b[k])))))` + "\n")
+// Code of the part in the given language
+func (p stdPart) in(language Language) string {
+	if legacy, ok := legacyParts[p.flag]; ok && !language.has(ES2020) {
+		return legacy
 	}
-	if hasFlag(flags, WrapNodeMethodFlag) {
-		f.WriteString(`export let wrapNodeMethod=(o,m,r,n=NodePointer,f=(...a)=>o[m].apply(o,a.map(a=>a instanceof n?a.get():a)))=>(typeof o[m]!="function"?r?()=>new n(o[m]):()=>o[m]:r?(...a)=>new n(f(...a)):f)` + "\n")
-	}
-	if hasFlag(flags, BindFlag) {
-		f.WriteString("export let bind=(o,m)=>(o[m].bind(o))\n")
-	}
-	if hasFlag(flags, DocumentFlag) {
-		f.WriteString("export let getDocument=()=>new NodePointer(document)\n")
-	}
-	if hasFlag(flags, DocumentBodyFlag) {
-		f.WriteString(`export class DocumentBody extends Sum{}` + "\n")
-	}
-	if hasFlag(flags, DocumentGetBodyFlag) {
-		f.WriteString(`export let getDocumentBody=d=>(d instanceof NodePointer&&(d=d.get()),()=>d.body?new Option("Some",new DocumentBody(d.body instanceof HTMLBodyElement?"Body":"Frame",d.body)):new Option("None"))` + "\n")
-	}
-	if hasFlag(flags, DocumentSetBodyFlag) {
-		f.WriteString("export let setDocumentBody=d=>(d instanceof NodePointer&&(d=d.get()),b=>d.body=b.value)\n")
+	return p.code
+}
+
+// Parts are in dependency order
+var stdParts = []stdPart{
+	{SumFlag, "Sum", "function Sum(t,v){this.tag=t;this.value=v}"},
+	{OptionFlag, "Option", "class Option extends Sum{}"},
+	{PointerFlag, "Pointer", "class Pointer{constructor(c,n){this.c=c;this.n=n}get(){return this.c?.[this.n]??this.n}set(v){this.c?(this.c[this.n]=v):(this.n=v)}}"},
+	{NodePointerFlag, "NodePointer", "class NodePointer{constructor(v){this._=v}get(){return this._}set(v){this._.parentNode?.replaceChild(this._,v);this._=v}}"},
+	{DeepEqualFlag, "equals", `let equals=(a,b,t=typeof a)=>(t==typeof b&&(t!="object"||a==null||b==null?a==b:a.constructor==b.constructor&&(a instanceof NodePointer?a.get()==b.get():!(Array.isArray(a)&&a.length-b.length)&&!Object.keys(a).find(k=>!equals(a[k],b[k])))))`},
+	{WrapNodeMethodFlag, "wrapNodeMethod", `let wrapNodeMethod=(o,m,r,n=NodePointer,f=(...a)=>o[m].apply(o,a.map(a=>a instanceof n?a.get():a)))=>(typeof o[m]!="function"?r?()=>new n(o[m]):()=>o[m]:r?(...a)=>new n(f(...a)):f)`},
+	{BindFlag, "bind", "let bind=(o,m)=>(o[m].bind(o))"},
+	{DocumentFlag, "getDocument", "let getDocument=()=>new NodePointer(document)"},
+	{DocumentBodyFlag, "DocumentBody", `class DocumentBody extends Sum{}`},
+	{DocumentGetBodyFlag, "getDocumentBody", `let getDocumentBody=d=>(d instanceof NodePointer&&(d=d.get()),()=>d.body?new Option("Some",new DocumentBody(d.body instanceof HTMLBodyElement?"Body":"Frame",d.body)):new Option("None"))`},
+	{DocumentSetBodyFlag, "setDocumentBody", "let setDocumentBody=d=>(d instanceof NodePointer&&(d=d.get()),b=>d.body=b.value)"},
+	{CreateElementFlag, "createElement", `let createElement=s=>{let[a,t,i,c]=s.match(/^(\w[\w\-_]*)?(?:#(\w[\w\-_]*))?((?:\.\w[\w\-_]*)*)$/);if(!a)throw new Error("Invalid selector");let e=document.createElement(t||"div");if(i)e.id=i;if(c)e.classList.add(...c.split(".").slice(1));return e}`},
+	{TodoFlag, "todo", "let todo=()=>{throw new Error(\"Not implemented yet\")}"},
+	{UnreachableFlag, "unreachable", "let unreachable=()=>{throw new Error(\"Entered unreachable code\")}"},
+	{FromNullableFlag, "fromNullable", `let fromNullable=(v,f=v=>v)=>v==null?new Option("None"):new Option("Some",f(v))`},
+	{ToNullableFlag, "toNullable", `let toNullable=(o,f=v=>v)=>o.tag=="Some"?f(o.value):undefined`},
+	{FromTaggedFlag, "fromTagged", "let fromTagged=(C,v)=>new C(v.tag,v.value)"},
+	{NodeFsFlag, "nodeFs", `let nodeFs=(f,w=g=>(...a)=>{try{return g(...a)}catch(e){throw{error:()=>e.message}}})=>({readFile:w(p=>f.readFileSync(p,"utf8")),writeFile:w((p,d)=>{f.writeFileSync(p,d)}),readDir:w(p=>f.readdirSync(p)),mkdir:w(p=>{f.mkdirSync(p,{recursive:true})}),remove:w(p=>{f.rmSync(p,{recursive:true})}),exists:p=>f.existsSync(p)})`},
+	{NodeProcessFlag, "nodeProcess", "let nodeProcess=(p=globalThis.process)=>({argv:p.argv,env:n=>fromNullable(p.env[n]),cwd:()=>p.cwd(),exit:c=>p.exit(c)})"},
+	{RunAsyncFlag, "runAsync", `let runAsync=g=>new Promise((s,j)=>{let n=(k,v)=>{let r;try{r=g[k](v)}catch(e){return j(e)}r.done?s(r.value):Promise.resolve(r.value).then(v=>n("next",v),e=>n("throw",e))};n("next")})`},
+}
+
+// Parts without ES2020 syntax (optional chaining and nullish coalescing),
+// for older languages
+var legacyParts = map[StandardFlags]string{
+	PointerFlag:     "class Pointer{constructor(c,n){this.c=c;this.n=n}get(){let v=this.c==null?void 0:this.c[this.n];return v==null?this.n:v}set(v){this.c?(this.c[this.n]=v):(this.n=v)}}",
+	NodePointerFlag: "class NodePointer{constructor(v){this._=v}get(){return this._}set(v){let p=this._.parentNode;p!=null&&p.replaceChild(this._,v);this._=v}}",
+}
+
+// Code of the std parts needed by the flags, as an ES module
+func GetStd(flags StandardFlags, language Language) string {
+	var b strings.Builder
+	for _, part := range stdParts {
+		if hasFlag(flags, part.flag) {
+			b.WriteString("export " + part.in(language) + "\n")
+		}
 	}
-	if hasFlag(flags, CreateElementFlag) {
-		f.WriteString(`export let createElement=s=>{let[a,t,i,c]=s.match(/^(\w[\w\-_]*)?(?:#(\w[\w\-_]*))?((?:\.\w[\w\-_]*)*)$/);if(!a)throw new Error("Invalid selector");let e=document.createElement(t||"div");if(i)e.id=i;if(c)e.classList.add(...c.split(".").slice(1));return e}` + "\n")
+	return b.String()
+}
+
+// Code of the std parts needed by the flags, declared in a '__' namespace
+// so that they can be inlined in a bundle
+func GetInlinedStd(flags StandardFlags, language Language) string {
+	if flags == NoFlag {
+		return ""
+	}
+	var b strings.Builder
+	names := []string{}
+	b.WriteString("const __ = (() => {\n")
+	for _, part := range stdParts {
+		if hasFlag(flags, part.flag) {
+			b.WriteString(part.in(language) + "\n")
+			names = append(names, part.name)
+		}
 	}
+	b.WriteString("return { " + strings.Join(names, ", ") + " };\n")
+	b.WriteString("})();\n")
+	return b.String()
+}
+
+// Write the parts of the std lib needed by the flags to the given .js file
+func EmitStd(filePath string, flags StandardFlags, language Language) error {
+	return os.WriteFile(filePath, []byte(GetStd(flags, language)), 0644)
 }
 
 type StandardFlags = uint
@@ -93,6 +129,14 @@ const (
 	DocumentGetBodyFlag
 	DocumentSetBodyFlag
 	CreateElementFlag
+	TodoFlag
+	UnreachableFlag
+	FromNullableFlag
+	ToNullableFlag
+	FromTaggedFlag
+	NodeFsFlag
+	NodeProcessFlag
+	RunAsyncFlag
 )
 
 var flagDependencies = map[StandardFlags]StandardFlags{
@@ -102,6 +146,8 @@ var flagDependencies = map[StandardFlags]StandardFlags{
 	DocumentGetBodyFlag: DocumentBodyFlag,
 	DocumentSetBodyFlag: DocumentBodyFlag,
 	DocumentBodyFlag:    OptionFlag, // actually needs only Sum, but GetBody needs Option
+	FromNullableFlag:    OptionFlag,
+	NodeProcessFlag:     FromNullableFlag,
 }
 
 type stdEmitter struct {
@@ -134,3 +134,98 @@ export function setDocumentBody(document) {
 	if (document instanceof NodePointer) document = document.get();
 	return (body) => (document.body = body.value);
 }
+
+/**
+ * Converts a nullable JavaScript value to an option
+ * @param {(value: any) => any} convert converts the value, if any
+ */
+export function fromNullable(value, convert = (v) => v) {
+	return value == null ? new Option("None") : new Option("Some", convert(value));
+}
+
+/**
+ * Converts an option to a JavaScript value, undefined if there is none
+ * @param {Option} option
+ * @param {(value: any) => any} convert converts the value, if any
+ */
+export function toNullable(option, convert = (v) => v) {
+	return option.tag == "Some" ? convert(option.value) : undefined;
+}
+
+/**
+ * Rebuilds a sum type from a plain { tag, value } object
+ * @param {typeof Sum} constructor
+ */
+export function fromTagged(constructor, object) {
+	return new constructor(object.tag, object.value);
+}
+
+/**
+ * Wraps the Node.js 'fs' module, so that failing functions throw kiwi errors
+ * @param {typeof import("node:fs")} fs
+ */
+export function nodeFs(fs) {
+	const wrap =
+		(f) =>
+		(...args) => {
+			try {
+				return f(...args);
+			} catch (e) {
+				throw { error: () => e.message };
+			}
+		};
+	return {
+		readFile: wrap((path) => fs.readFileSync(path, "utf8")),
+		writeFile: wrap((path, data) => {
+			fs.writeFileSync(path, data);
+		}),
+		readDir: wrap((path) => fs.readdirSync(path)),
+		mkdir: wrap((path) => {
+			fs.mkdirSync(path, { recursive: true });
+		}),
+		remove: wrap((path) => {
+			fs.rmSync(path, { recursive: true });
+		}),
+		exists: (path) => fs.existsSync(path),
+	};
+}
+
+/**
+ * The Node.js process, with environment variables as options.
+ * The global is read explicitly since bundles may declare 'process'.
+ */
+export function nodeProcess(process = globalThis.process) {
+	return {
+		argv: process.argv,
+		env: (name) => fromNullable(process.env[name]),
+		cwd: () => process.cwd(),
+		exit: (code) => process.exit(code),
+	};
+}
+
+/**
+ * Runs a generator like an async function: yielded values are awaited.
+ * Used instead of async functions when targeting ES2015.
+ * @param {Generator} generator
+ */
+export function runAsync(generator) {
+	return new Promise((resolve, reject) => {
+		const next = (key, value) => {
+			let result;
+			try {
+				result = generator[key](value);
+			} catch (e) {
+				return reject(e);
+			}
+			if (result.done) {
+				resolve(result.value);
+			} else {
+				Promise.resolve(result.value).then(
+					(v) => next("next", v),
+					(e) => next("throw", e)
+				);
+			}
+		};
+		next("next");
+	});
+}
@@ -1,21 +1,13 @@
 package emitter
 
 import (
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
-func (e *Emitter) emitTupleExpression(t *parser.TupleExpression) {
+func (e *Emitter) emitTupleExpression(t *parser.TupleExpression) js.Expression {
 	if len(t.Elements) == 1 {
-		e.emitExpression(t.Elements[0])
-		return
+		return e.emitExpression(t.Elements[0])
 	}
-	e.write("[")
-	length := len(t.Elements)
-	for i, el := range t.Elements {
-		e.emitExpression(el)
-		if i != length-1 {
-			e.write(", ")
-		}
-	}
-	e.write("]")
+	return &js.ArrayExpression{Elements: e.emitExpressions(t.Elements)}
 }
@@ -3,48 +3,49 @@ package emitter
 import (
 	"fmt"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
-func (e *Emitter) emitUnaryExpression(u *parser.UnaryExpression) {
+func (e *Emitter) emitUnaryExpression(u *parser.UnaryExpression) js.Expression {
 	switch u.Operator.Kind() {
 	case parser.AsyncKeyword:
-		e.emitCallExpression(u.Operand.(*parser.CallExpression), false)
+		return e.emitCallExpression(u.Operand.(*parser.CallExpression), false)
 	case parser.AwaitKeyword:
-		e.write("await ")
-		e.emitExpression(u.Operand)
+		return e.await(e.emitExpression(u.Operand))
 	case parser.Bang:
-		e.write("!")
-		e.emitExpression(u.Operand)
+		return &js.UnaryExpression{Operator: "!", Argument: e.emitExpression(u.Operand)}
 	case parser.TryKeyword:
-		e.emitExpression(u.Operand)
+		return e.emitExpression(u.Operand)
 	case parser.BinaryAnd:
-		e.emitReference(u.Operand)
+		return e.emitReference(u.Operand)
 	case parser.Mul:
-		e.emitExpression(u.Operand)
+		operand := e.emitExpression(u.Operand)
 		if _, ok := u.Operand.Type().(parser.Ref).To.(parser.List); ok {
-			e.write(".clone()")
-		} else {
-			e.write("(1)")
+			return js.Call(js.Dot(operand, "clone"))
 		}
+		return js.Call(operand, &js.Literal{Raw: "1"})
+	default:
+		panic(fmt.Sprintf("Cannot emit operator '%v' (not implemented yet)", u.Operator.Text()))
 	}
 }
 
-func (e *Emitter) emitReference(expr parser.Expression) {
+func (e *Emitter) emitReference(expr parser.Expression) js.Expression {
+	pointer := "Pointer"
 	if implementsNode(expr.Type()) {
 		e.addFlag(NodePointerFlag)
-		e.write("new __.NodePointer(")
+		pointer = "NodePointer"
 	} else {
 		e.addFlag(PointerFlag)
-		e.write("new __.Pointer(")
 	}
+	var object js.Expression
 	identifier, isIdentifier := getRefIdentifier(expr)
 	if isIdentifier {
-		emitScope(e, identifier.GetScope())
+		object = emitScope(e, identifier.GetScope())
 	} else {
-		e.emitExpression(expr.(*parser.PropertyAccessExpression).Expr)
+		object = e.emitExpression(expr.(*parser.PropertyAccessExpression).Expr)
 	}
-	e.write(fmt.Sprintf(", \"%v\")", identifier.Text()))
+	return js.New(js.Dot(js.Name("__"), pointer), object, js.String(identifier.Text()))
 }
 func getRefIdentifier(expr parser.Expression) (*parser.Identifier, bool) {
 	switch expr := expr.(type) {
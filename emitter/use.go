@@ -1,50 +1,104 @@
 package emitter
 
 import (
+	"path/filepath"
 	"slices"
+	"strings"
 
+	"github.com/bmelicque/test-parser/js"
 	"github.com/bmelicque/test-parser/parser"
 )
 
 func (e *Emitter) emitUseStatement(u *parser.UseDirective) {
 	path := u.Source.Text()
 	path = path[1 : len(path)-1]
-	if parser.IsLocalPath(path) {
-		e.emitLocalImport(u)
+	if resolved, ok := parser.ResolvePath(e.path, path); ok {
+		if parser.IsDeclarationFile(resolved) {
+			e.emitExternalImport(u, resolved, getExternalImportPath(path))
+			return
+		}
+		if e.bundle != nil {
+			e.emitBundledUse(u, resolved)
+			return
+		}
+		e.emitLocalImport(u, getImportPath(e.path, resolved, path))
 		return
 	}
 	switch path {
 	case "dom":
 		if u.Star {
-			e.write("const ")
-			e.emitExpression(u.Names)
-			e.write(" = { createElement: __.createElement, document: __.getDocument, DocumentBody: __.DocumentBody }\n")
+			e.add(js.Declare("const", e.emitExpression(u.Names), &js.ObjectExpression{Properties: []*js.Property{
+				{Key: "createElement", Value: js.Dot(js.Name("__"), "createElement")},
+				{Key: "document", Value: js.Dot(js.Name("__"), "getDocument")},
+				{Key: "DocumentBody", Value: js.Dot(js.Name("__"), "DocumentBody")},
+			}}))
 			e.addFlag(CreateElementFlag | DocumentFlag | DocumentBodyFlag)
 			return
 		}
 		names := getUsedNames(u.Names)
 		if slices.Contains(names, "document") {
-			e.write("const document = __.getDocument;\n")
+			e.add(js.Declare("const", js.Name(e.getTopLevelName("document")), js.Dot(js.Name("__"), "getDocument")))
 			e.addFlag(DocumentFlag)
 		}
 		if slices.Contains(names, "DocumentBody") {
-			e.write("const DocumentBody = __.DocumentBody;\n")
+			e.add(js.Declare("const", js.Name(e.getTopLevelName("DocumentBody")), js.Dot(js.Name("__"), "DocumentBody")))
 			e.addFlag(DocumentBodyFlag)
 		}
 		if slices.Contains(names, "createElement") {
-			e.write("const createElement = __.createElement;\n")
+			e.add(js.Declare("const", js.Name(e.getTopLevelName("createElement")), js.Dot(js.Name("__"), "createElement")))
 			e.addFlag(CreateElementFlag)
 		}
+	case "node":
+		e.emitNodeUse(u)
 	case "io":
 		if u.Star {
-			e.write("const ")
-			e.emitExpression(u.Names)
-			e.write(" = console;\n")
-		} else {
-			e.write("const {")
-			e.emitExpression(u.Names)
-			e.write("} = console;\n")
+			e.add(js.Declare("const", e.emitExpression(u.Names), js.Name("console")))
+			return
+		}
+		pattern := &js.ObjectExpression{}
+		for _, name := range getUsedNames(u.Names) {
+			property := &js.Property{Key: name}
+			if bundled := e.getTopLevelName(name); bundled != name {
+				property.Value = js.Name(bundled)
+			}
+			pattern.Properties = append(pattern.Properties, property)
 		}
+		e.add(js.Declare("const", pattern, js.Name("console")))
+	}
+}
+
+// Node.js modules are imported from the runtime. Functions which can fail
+// are wrapped to throw kiwi errors.
+func (e *Emitter) emitNodeUse(u *parser.UseDirective) {
+	if u.Star {
+		name := e.getTopLevelName(u.Names.(*parser.Identifier).Text())
+		e.add(
+			&js.ImportDeclaration{Namespace: js.Name(name + "$fs"), Source: "node:fs"},
+			&js.ImportDeclaration{Namespace: js.Name(name + "$path"), Source: "node:path"},
+			js.Declare("const", js.Name(name), &js.ObjectExpression{Properties: []*js.Property{
+				{Key: "fs", Value: js.Call(js.Dot(js.Name("__"), "nodeFs"), js.Name(name+"$fs"))},
+				{Key: "path", Value: js.Name(name + "$path")},
+				{Key: "process", Value: js.Call(js.Dot(js.Name("__"), "nodeProcess"))},
+			}}),
+		)
+		e.addFlag(NodeFsFlag | NodeProcessFlag)
+		return
+	}
+	names := getUsedNames(u.Names)
+	if slices.Contains(names, "fs") {
+		local := e.getTopLevelName("fs")
+		e.add(
+			&js.ImportDeclaration{Namespace: js.Name(local + "$node"), Source: "node:fs"},
+			js.Declare("const", js.Name(local), js.Call(js.Dot(js.Name("__"), "nodeFs"), js.Name(local+"$node"))),
+		)
+		e.addFlag(NodeFsFlag)
+	}
+	if slices.Contains(names, "path") {
+		e.add(&js.ImportDeclaration{Namespace: js.Name(e.getTopLevelName("path")), Source: "node:path"})
+	}
+	if slices.Contains(names, "process") {
+		e.add(js.Declare("const", js.Name(e.getTopLevelName("process")), js.Call(js.Dot(js.Name("__"), "nodeProcess"))))
+		e.addFlag(NodeProcessFlag)
 	}
 }
 
@@ -63,17 +117,132 @@ func getUsedNames(n parser.Expression) []string {
 	}
 }
 
-func (e *Emitter) emitLocalImport(u *parser.UseDirective) {
-	e.write("import ")
+// Path of the imported file, relative to the importing one.
+// Output files keep the layout of source files.
+func getImportPath(from string, resolved string, source string) string {
+	if parser.IsLocalPath(source) {
+		return source
+	}
+	relative, err := filepath.Rel(filepath.Dir(from), resolved)
+	if err != nil {
+		return filepath.ToSlash(resolved)
+	}
+	relative = filepath.ToSlash(relative)
+	if !strings.HasPrefix(relative, ".") {
+		relative = "./" + relative
+	}
+	return relative
+}
+
+// Imported names that are not needed, or that don't exist at runtime, are
+// left out. If none is left, the module is still imported for its side
+// effects.
+func (e *Emitter) emitLocalImport(u *parser.UseDirective, path string) {
+	declaration := &js.ImportDeclaration{Source: path + e.extension}
 	if u.Star {
-		e.write("* as ")
-		e.emitExpression(u.Names)
+		declaration.Namespace = emitImportedName(e, u.Names.(*parser.Identifier))
 	} else {
-		e.write("{")
-		e.emitExpression(u.Names)
-		e.write("} ")
+		for _, name := range e.getImportedNames(u.Names) {
+			if !hasRuntimeValue(e, name) {
+				continue
+			}
+			specifier := &js.ImportSpecifier{Imported: emitImportedName(e, name)}
+			declaration.Specifiers = append(declaration.Specifiers, specifier)
+		}
+	}
+	e.add(declaration)
+}
+
+// Types only exist at runtime as classes (for objects and sums), or as the
+// holders of the methods of newtypes. Traits and other types are erased.
+func hasRuntimeValue(e *Emitter, name *parser.Identifier) bool {
+	if e.topLevel == nil {
+		return true
+	}
+	variable := e.topLevel.FindLocal(name.Text())
+	if variable == nil {
+		return true
+	}
+	t, ok := variable.Typing.(parser.Type)
+	if !ok {
+		return true
+	}
+	alias, ok := t.Value.(parser.TypeAlias)
+	if !ok {
+		return false
+	}
+	switch alias.Ref.(type) {
+	case parser.Object, parser.Sum:
+		return true
+	case parser.Newtype:
+		return len(alias.Methods) > 0
+	default:
+		return false
+	}
+}
+
+// Modules described by declaration files are not compiled: they are
+// imported as is, even in bundles. Their types are left out.
+func (e *Emitter) emitExternalImport(u *parser.UseDirective, resolved string, path string) {
+	declaration := &js.ImportDeclaration{Source: path}
+	if u.Star {
+		declaration.Namespace = emitImportedName(e, u.Names.(*parser.Identifier))
+		e.add(declaration)
+		return
+	}
+	types := 0
+	for _, name := range e.getImportedNames(u.Names) {
+		if parser.IsTypeOnly(resolved, name.Text()) {
+			types++
+			continue
+		}
+		specifier := &js.ImportSpecifier{Imported: js.Name(name.Text())}
+		if local := e.getTopLevelName(name.Text()); local != name.Text() {
+			specifier.Local = js.Name(local)
+		}
+		declaration.Specifiers = append(declaration.Specifiers, specifier)
+	}
+	if types > 0 && len(declaration.Specifiers) == 0 {
+		// only types are used, which don't exist at runtime
+		return
+	}
+	e.add(declaration)
+}
+
+// Imported names are top-level names of the importing module
+func emitImportedName(e *Emitter, name *parser.Identifier) *js.Identifier {
+	imported := js.Name(e.getTopLevelName(name.Text()))
+	e.locate(imported, name)
+	return imported
+}
+
+// Local declaration files describe the JavaScript file next to them, while
+// other sources are packages
+func getExternalImportPath(source string) string {
+	if !parser.IsLocalPath(source) {
+		return source
+	}
+	return strings.TrimSuffix(source, ".d.ts") + ".js"
+}
+
+func (e *Emitter) getImportedNames(n parser.Expression) []*parser.Identifier {
+	identifiers := []*parser.Identifier{}
+	switch n := n.(type) {
+	case *parser.Identifier:
+		identifiers = append(identifiers, n)
+	case *parser.TupleExpression:
+		for _, element := range n.Elements {
+			identifiers = append(identifiers, element.(*parser.Identifier))
+		}
+	}
+	if e.reach == nil {
+		return identifiers
+	}
+	used := []*parser.Identifier{}
+	for _, identifier := range identifiers {
+		if e.reach.IsUsed(e.path, identifier.Text()) {
+			used = append(used, identifier)
+		}
 	}
-	e.write("from ")
-	e.emitExpression(u.Source)
-	e.write(".js\n")
+	return used
 }
@@ -0,0 +1,126 @@
+package emitter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func TestEmitLocalImport(t *testing.T) {
+	dir := t.TempDir()
+	parseBundled(t, dir, "lib", "answer :: 42")
+	program := parseBundled(t, dir, "main", "use * as lib from \"./lib\"")
+	emitter := makeEmitter()
+	emitter.path = program.Path()
+	emitter.emit(program.Nodes()[0])
+	expected := "import * as lib from \"./lib.js\";\n"
+	if received := emitter.string(); received != expected {
+		t.Fatalf("expected output:\n%v\n\ngot:\n%v", expected, received)
+	}
+}
+
+func TestEmitAliasedImport(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"use": {"@app/": "./src/"}}`
+	os.WriteFile(filepath.Join(dir, parser.ConfigName), []byte(config), 0644)
+	os.Mkdir(filepath.Join(dir, "src"), 0755)
+	parseBundled(t, dir, "src/lib", "answer :: 42")
+	path := filepath.Join(dir, "pages", "home")
+
+	program, _ := parser.ParseProgram(strings.NewReader("use * as lib from \"@app/lib\""), path)
+	emitter := makeEmitter()
+	emitter.path = path
+	emitter.emit(program.Nodes()[0])
+	expected := "import * as lib from \"../src/lib.js\";\n"
+	if received := emitter.string(); received != expected {
+		t.Fatalf("expected output:\n%v\n\ngot:\n%v", expected, received)
+	}
+}
+
+func TestEmitShakenImport(t *testing.T) {
+	dir := t.TempDir()
+	lib := parseBundled(t, dir, "lib", "double :: (a number) => { a * 2 }\ntriple :: (a number) => { a * 3 }")
+	main := parseBundled(t, dir, "main", "use double, triple from \"./lib\"\n_n :: double(2)")
+	reach := parser.AnalyzeReachability([]parser.Program{lib, main}, []string{main.Path()})
+
+	output, _, _ := EmitProgramWithMappings(main, Options{Reach: reach})
+	if !strings.Contains(output, "import { double_ } from \"./lib.js\";\n") {
+		t.Fatalf("expected only used names to be imported, got:\n%v", output)
+	}
+	output, _, _ = EmitProgramWithMappings(lib, Options{Reach: reach})
+	if strings.Contains(output, "triple") {
+		t.Fatalf("expected unused declarations to be dropped, got:\n%v", output)
+	}
+}
+
+func TestEmitExternalImport(t *testing.T) {
+	dir := t.TempDir()
+	declarations := "export interface Options {\n    wait: number\n}\n"
+	declarations += "export declare function debounce(f: () => void, options: Options): () => void\n"
+	os.WriteFile(filepath.Join(dir, "timing.d.ts"), []byte(declarations), 0644)
+	main := parseBundled(t, dir, "main", "use debounce, Options from \"./timing\"\n_f :: debounce(() => {}, Options{wait: 10})")
+
+	output, _, _ := EmitProgramWithMappings(main, Options{})
+	if !strings.Contains(output, "import { debounce } from \"./timing.js\";\n") {
+		t.Fatalf("expected types to be left out of the import, got:\n%v", output)
+	}
+	if !strings.Contains(output, "debounce(() => {}, { wait: 10 })") {
+		t.Fatalf("expected declared object types to be plain objects, got:\n%v", output)
+	}
+}
+
+func TestEmitNodeUse(t *testing.T) {
+	parser.SetTarget("node")
+	defer parser.SetTarget("")
+	source := "use fs, path, process from \"node\""
+	expected := "import * as fs$node from \"node:fs\";\n"
+	expected += "const fs = __.nodeFs(fs$node);\n"
+	expected += "import * as path from \"node:path\";\n"
+	expected += "const process = __.nodeProcess();\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitNodeUseStar(t *testing.T) {
+	parser.SetTarget("node")
+	defer parser.SetTarget("")
+	source := "use * as node from \"node\""
+	expected := "import * as node$fs from \"node:fs\";\n"
+	expected += "import * as node$path from \"node:path\";\n"
+	expected += "const node = { fs: __.nodeFs(node$fs), path: node$path, process: __.nodeProcess() };\n"
+	testEmitter(t, source, expected, 0)
+}
+
+func TestEmitImportExtension(t *testing.T) {
+	dir := t.TempDir()
+	parseBundled(t, dir, "lib", "answer :: 42")
+	program := parseBundled(t, dir, "main", "use * as lib from \"./lib\"")
+	output, _, _ := EmitProgramWithMappings(program, Options{Extension: ".mjs"})
+	if !strings.Contains(output, "import * as lib from \"./lib.mjs\";\n") {
+		t.Fatalf("expected import with the output extension, got:\n%v", output)
+	}
+}
+
+func TestEmitTypeOnlyLocalImport(t *testing.T) {
+	dir := t.TempDir()
+	lib := "UserId :: new number\n"
+	lib += "(id UserId).add :: (n number) => { UserId{id.value + n} }\n"
+	lib += "Score :: new number\n"
+	lib += "Mode :: \"light\" | \"dark\"\n"
+	parseBundled(t, dir, "lib", lib)
+	source := "use UserId, Score, Mode from \"./lib\"\n"
+	source += "_add :: (id UserId) => { id.add(2) }\n"
+	source += "_score :: (s Score) => { s.value }\n"
+	source += "_mode :: (m Mode) => { m }\n"
+	main := parseBundled(t, dir, "main", source)
+
+	output, _, _ := EmitProgramWithMappings(main, Options{})
+	if !strings.Contains(output, "import { UserId } from \"./lib.js\";\n") {
+		t.Fatalf("expected types without runtime value to be left out of the import, got:\n%v", output)
+	}
+	if !strings.Contains(output, "UserId.add(id, 2)") {
+		t.Fatalf("expected the method of the imported newtype to be called, got:\n%v", output)
+	}
+}
@@ -62,3 +62,19 @@ func hasMapType(expr parser.Expression) bool {
 	alias, ok := t.Value.(parser.TypeAlias)
 	return ok && alias.Name == "#"
 }
+
+// Check if the type is a newtype, or a type expression for a newtype
+func isNewtype(t parser.ExpressionType) bool {
+	if ty, ok := t.(parser.Type); ok {
+		t = ty.Value
+	}
+	if ref, ok := t.(parser.Ref); ok {
+		t = ref.To
+	}
+	alias, ok := t.(parser.TypeAlias)
+	if !ok {
+		return false
+	}
+	_, ok = alias.Ref.(parser.Newtype)
+	return ok
+}
@@ -0,0 +1,71 @@
+// Package format normalizes the layout of kiwi source files.
+//
+// Lines are re-indented with 4 spaces per level of nesting of (), [] and {},
+// trailing whitespace is removed, consecutive blank lines are collapsed into
+// one, and the file ends with a single line break.
+package format
+
+import (
+	"strings"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+const indentation = "    "
+
+func Source(source string) string {
+	lines := strings.Split(source, "\n")
+	depths := getLineDepths(source, lines)
+
+	var b strings.Builder
+	blank := true // avoid leading blank lines
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = true
+			continue
+		}
+		if blank && b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		blank = false
+		b.WriteString(strings.Repeat(indentation, depths[i]))
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Get the indentation level of each line
+func getLineDepths(source string, lines []string) []int {
+	count := len(lines)
+	depths := make([]int, count)
+	tokenizer := parser.NewTokenizer(strings.NewReader(source))
+	depth := 0
+	line := 0 // last line whose depth is known
+	for token := tokenizer.Consume(); token.Kind() != parser.EOF; token = tokenizer.Consume() {
+		start := token.Loc().Start.Line - 1
+		for ; line < start && line < count; line++ {
+			depths[line+1] = depth
+		}
+		switch token.Kind() {
+		case parser.LeftParenthesis, parser.LeftBracket, parser.LeftBrace:
+			depth++
+		case parser.RightParenthesis, parser.RightBracket, parser.RightBrace:
+			depth = max(depth-1, 0)
+			if isFirstOnLine(lines, token.Loc().Start) {
+				depths[start] = depth
+			}
+		}
+	}
+	for ; line+1 < count; line++ {
+		depths[line+1] = depth
+	}
+	return depths
+}
+
+func isFirstOnLine(lines []string, pos parser.Position) bool {
+	line := lines[pos.Line-1]
+	end := min(pos.Col-1, len(line))
+	return strings.TrimSpace(line[:end]) == ""
+}
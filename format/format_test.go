@@ -0,0 +1,33 @@
+package format
+
+import "testing"
+
+func TestIndent(t *testing.T) {
+	source := "_f :: () => {\n_a := [\n1,\n  2,\n]\n\t\t_a\n}\n"
+	expected := "_f :: () => {\n    _a := [\n        1,\n        2,\n    ]\n    _a\n}\n"
+	if got := Source(source); got != expected {
+		t.Fatalf("Expected:\n%v\nGot:\n%v", expected, got)
+	}
+}
+
+func TestBlankLines(t *testing.T) {
+	source := "\n\n_a := 1   \n\n\n\n_b := _a"
+	expected := "_a := 1\n\n_b := _a\n"
+	if got := Source(source); got != expected {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestIgnoreBracesInStringsAndComments(t *testing.T) {
+	source := "_a := \"{\" // {\n_b := _a\n"
+	if got := Source(source); got != source {
+		t.Fatalf("Expected %q, got %q", source, got)
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	source := "_f :: () => {\n    // comment\n    if true {\n        1\n    } else {\n        2\n    }\n}\n"
+	if got := Source(source); got != source {
+		t.Fatalf("Expected:\n%v\nGot:\n%v", source, got)
+	}
+}
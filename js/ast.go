@@ -0,0 +1,356 @@
+// Package js describes JavaScript programs as syntax trees, which the
+// emitter builds and the printer turns into code.
+package js
+
+import "strconv"
+
+// Position in the source a node is built from. Lines and columns are 0-based.
+type Position struct {
+	Line int
+	Col  int
+}
+
+type Node interface {
+	at() *Position
+	locate(position Position)
+}
+
+// Embedded in nodes to link them to their source
+type located struct {
+	position *Position
+}
+
+func (l *located) at() *Position            { return l.position }
+func (l *located) locate(position Position) { l.position = &position }
+
+// Record that the node is built from the code at the given position, unless
+// it already was: positions of inner source nodes are more precise.
+// Printed nodes are mapped to it.
+func Locate(node Node, position Position) {
+	if node.at() == nil {
+		node.locate(position)
+	}
+}
+
+type Statement interface {
+	Node
+	statement()
+}
+
+type Expression interface {
+	Node
+	expression()
+}
+
+// A list of top-level statements
+type Program struct {
+	located
+	Body []Statement
+}
+
+/******************************
+ *  STATEMENTS                *
+ ******************************/
+
+type ExpressionStatement struct {
+	located
+	Expression Expression
+}
+
+// 'const x = 1, y = 2;', declared names may be patterns
+type VariableDeclaration struct {
+	located
+	Export       bool
+	Kind         string // "const" or "let"
+	Declarations []*Declarator
+}
+
+type Declarator struct {
+	Name Expression
+	Init Expression // nil if the variable is not initialized
+}
+
+type ClassDeclaration struct {
+	located
+	Export  bool
+	Name    string
+	Extends Expression // nil if none
+	Methods []*Method
+}
+
+type Method struct {
+	Name   string
+	Params []Expression
+	Body   *BlockStatement
+}
+
+type BlockStatement struct {
+	located
+	Body []Statement
+}
+
+type IfStatement struct {
+	located
+	Test       Expression
+	Consequent *BlockStatement
+	Alternate  Statement // nil, a block or another if statement
+}
+
+type WhileStatement struct {
+	located
+	Test Expression
+	Body *BlockStatement
+}
+
+// 'for (init; test; update) {}'
+type ForStatement struct {
+	located
+	Init   *VariableDeclaration
+	Test   Expression
+	Update Expression
+	Body   *BlockStatement
+}
+
+// 'for (let x of list) {}', the declaration has no initial value
+type ForOfStatement struct {
+	located
+	Left  *VariableDeclaration
+	Right Expression
+	Body  *BlockStatement
+}
+
+type SwitchStatement struct {
+	located
+	Discriminant Expression
+	Cases        []*SwitchCase
+}
+
+type SwitchCase struct {
+	Test       Expression // nil for the default case
+	Consequent []Statement
+}
+
+type TryStatement struct {
+	located
+	Block   *BlockStatement
+	Param   Expression
+	Handler *BlockStatement
+}
+
+type ReturnStatement struct {
+	located
+	Argument Expression // nil if nothing is returned
+}
+
+type ThrowStatement struct {
+	located
+	Argument Expression
+}
+
+type BreakStatement struct{ located }
+
+type ContinueStatement struct{ located }
+
+// 'import * as x from "source";' if there is a namespace,
+// 'import { a, b as c } from "source";' if there are specifiers,
+// 'import "source";' otherwise.
+type ImportDeclaration struct {
+	located
+	Namespace  *Identifier
+	Specifiers []*ImportSpecifier
+	Source     string
+}
+
+type ImportSpecifier struct {
+	Imported *Identifier
+	Local    *Identifier // nil if the same as the imported name
+}
+
+func (*ExpressionStatement) statement() {}
+func (*VariableDeclaration) statement() {}
+func (*ClassDeclaration) statement()    {}
+func (*BlockStatement) statement()      {}
+func (*IfStatement) statement()         {}
+func (*WhileStatement) statement()      {}
+func (*ForStatement) statement()        {}
+func (*ForOfStatement) statement()      {}
+func (*SwitchStatement) statement()     {}
+func (*TryStatement) statement()        {}
+func (*ReturnStatement) statement()     {}
+func (*ThrowStatement) statement()      {}
+func (*BreakStatement) statement()      {}
+func (*ContinueStatement) statement()   {}
+func (*ImportDeclaration) statement()   {}
+
+/******************************
+ *  EXPRESSIONS               *
+ ******************************/
+
+type Identifier struct {
+	located
+	Name string
+}
+
+// Numbers, strings or booleans, as written in the code
+type Literal struct {
+	located
+	Raw string
+}
+
+type ArrayExpression struct {
+	located
+	Elements []Expression
+}
+
+type ObjectExpression struct {
+	located
+	Properties []*Property
+}
+
+type Property struct {
+	Key   string
+	Value Expression // nil for shorthands (e.g. '{ x }')
+}
+
+// 'object.property', or 'object[property]' if computed
+type MemberExpression struct {
+	located
+	Object   Expression
+	Property Expression
+	Computed bool
+}
+
+type CallExpression struct {
+	located
+	Callee    Expression
+	Arguments []Expression
+}
+
+type NewExpression struct {
+	located
+	Callee    Expression
+	Arguments []Expression
+}
+
+// Prefix operators, like '!', '-' or 'typeof'
+type UnaryExpression struct {
+	located
+	Operator string
+	Argument Expression
+}
+
+// '++' and '--'
+type UpdateExpression struct {
+	located
+	Operator string
+	Prefix   bool
+	Argument Expression
+}
+
+// Arithmetic, comparison and logical operators
+type BinaryExpression struct {
+	located
+	Operator string
+	Left     Expression
+	Right    Expression
+}
+
+type AssignmentExpression struct {
+	located
+	Operator string // "=", "+=", "&&="...
+	Left     Expression
+	Right    Expression
+}
+
+type ConditionalExpression struct {
+	located
+	Test       Expression
+	Consequent Expression
+	Alternate  Expression
+}
+
+type ArrowFunction struct {
+	located
+	Async  bool
+	Params []Expression // identifiers, or assignments for default values
+	Body   Node         // a block statement or an expression
+}
+
+type FunctionExpression struct {
+	located
+	Async     bool
+	Generator bool
+	Params    []Expression
+	Body      *BlockStatement
+}
+
+type AwaitExpression struct {
+	located
+	Argument Expression
+}
+
+type YieldExpression struct {
+	located
+	Argument Expression
+}
+
+type SequenceExpression struct {
+	located
+	Expressions []Expression
+}
+
+func (*Identifier) expression()            {}
+func (*Literal) expression()               {}
+func (*ArrayExpression) expression()       {}
+func (*ObjectExpression) expression()      {}
+func (*MemberExpression) expression()      {}
+func (*CallExpression) expression()        {}
+func (*NewExpression) expression()         {}
+func (*UnaryExpression) expression()       {}
+func (*UpdateExpression) expression()      {}
+func (*BinaryExpression) expression()      {}
+func (*AssignmentExpression) expression()  {}
+func (*ConditionalExpression) expression() {}
+func (*ArrowFunction) expression()         {}
+func (*FunctionExpression) expression()    {}
+func (*AwaitExpression) expression()       {}
+func (*YieldExpression) expression()       {}
+func (*SequenceExpression) expression()    {}
+
+/******************************
+ *  SHORTHANDS                *
+ ******************************/
+
+func Name(name string) *Identifier {
+	return &Identifier{Name: name}
+}
+
+// A string literal with the given value
+func String(value string) *Literal {
+	return &Literal{Raw: strconv.Quote(value)}
+}
+
+// Access to the named property of an object, e.g. Dot(Name("a"), "b") for 'a.b'
+func Dot(object Expression, property string) *MemberExpression {
+	return &MemberExpression{Object: object, Property: Name(property)}
+}
+
+func Call(callee Expression, args ...Expression) *CallExpression {
+	return &CallExpression{Callee: callee, Arguments: args}
+}
+
+func New(callee Expression, args ...Expression) *NewExpression {
+	return &NewExpression{Callee: callee, Arguments: args}
+}
+
+func Assign(left Expression, right Expression) *AssignmentExpression {
+	return &AssignmentExpression{Operator: "=", Left: left, Right: right}
+}
+
+// Declare a single variable, initialized if init is not nil
+func Declare(kind string, name Expression, init Expression) *VariableDeclaration {
+	return &VariableDeclaration{
+		Kind:         kind,
+		Declarations: []*Declarator{{Name: name, Init: init}},
+	}
+}
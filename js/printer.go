@@ -0,0 +1,753 @@
+package js
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// How code is printed
+type Options struct {
+	// Leave out optional whitespace, for minified code. Line breaks are only
+	// kept where statements rely on them to end.
+	Compact bool
+}
+
+// Links a position in the printed code to a position in the source.
+// Printed columns count UTF-16 code units.
+type Mapping struct {
+	Generated Position
+	Source    Position
+}
+
+const indentation = "    "
+
+// Print a program, a statement or an expression
+func Print(node Node, options Options) string {
+	p := printer{options: options}
+	p.print(node)
+	return p.builder.String()
+}
+
+// Same as Print, but also returns the positions of located nodes, sorted by
+// printed position
+func PrintWithMappings(node Node, options Options) (string, []Mapping) {
+	p := printer{options: options, mappings: []Mapping{}}
+	p.print(node)
+	return p.builder.String(), p.mappings
+}
+
+type printer struct {
+	options  Options
+	builder  strings.Builder
+	depth    int
+	line     int
+	col      int
+	last     byte      // last printed byte
+	pending  *Position // source of the next printed code
+	mappings []Mapping // nil if not needed
+}
+
+func (p *printer) print(node Node) {
+	switch node := node.(type) {
+	case *Program:
+		p.statements(node.Body)
+		if p.options.Compact && len(node.Body) > 0 {
+			p.raw("\n")
+		}
+	case Statement:
+		p.statement(node)
+	case Expression:
+		p.expression(node, 0)
+	}
+}
+
+/******************************
+ *  WRITING                   *
+ ******************************/
+
+// Write code, separated from the previous code if they would be read as a
+// single token
+func (p *printer) write(code string) {
+	if code == "" {
+		return
+	}
+	if needsSpace(p.last, code[0]) {
+		p.raw(" ")
+	}
+	if p.pending != nil {
+		p.addMapping(*p.pending)
+		p.pending = nil
+	}
+	p.raw(code)
+}
+
+func (p *printer) raw(code string) {
+	p.builder.WriteString(code)
+	if i := strings.LastIndexByte(code, '\n'); i != -1 {
+		p.line += strings.Count(code, "\n")
+		p.col = 0
+		code = code[i+1:]
+	}
+	p.col += len(utf16.Encode([]rune(code)))
+	if code != "" {
+		p.last = code[len(code)-1]
+	} else {
+		p.last = '\n'
+	}
+}
+
+// Optional whitespace
+func (p *printer) space() {
+	if !p.options.Compact {
+		p.raw(" ")
+	}
+}
+
+func (p *printer) newline() {
+	if !p.options.Compact {
+		p.raw("\n")
+	}
+}
+
+func (p *printer) indent() {
+	if !p.options.Compact {
+		p.raw(strings.Repeat(indentation, p.depth))
+	}
+}
+
+// Write an infix operator, like ' = ' or ' => '
+func (p *printer) operator(operator string) {
+	p.space()
+	p.write(operator)
+	p.space()
+}
+
+func (p *printer) comma() {
+	p.write(",")
+	p.space()
+}
+
+func needsSpace(previous byte, next byte) bool {
+	if isWordByte(previous) && isWordByte(next) {
+		return true
+	}
+	// e.g. 'a - -b' or 'a + ++b'
+	return previous == next && (next == '+' || next == '-')
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+/******************************
+ *  MAPPINGS                  *
+ ******************************/
+
+// Record that the next printed code comes from the node, if it is located
+func (p *printer) mark(node Node) {
+	if p.mappings == nil {
+		return
+	}
+	if position := node.at(); position != nil {
+		p.pending = position
+	}
+}
+
+func (p *printer) addMapping(source Position) {
+	m := Mapping{Position{p.line, p.col}, source}
+	// only keep the innermost node starting at a given position
+	if n := len(p.mappings); n > 0 && p.mappings[n-1].Generated == m.Generated {
+		p.mappings[n-1] = m
+		return
+	}
+	p.mappings = append(p.mappings, m)
+}
+
+/******************************
+ *  STATEMENTS                *
+ ******************************/
+
+// Print statements on their own lines. Compact statements are only
+// separated by a line break if it ends the previous one.
+func (p *printer) statements(statements []Statement) {
+	for i, statement := range statements {
+		p.indent()
+		p.statement(statement)
+		if !p.options.Compact {
+			p.raw("\n")
+		} else if !hasSemicolon(statement) && endsWithExpression(statement) && i < len(statements)-1 {
+			p.raw("\n")
+		}
+	}
+}
+
+func (p *printer) statement(s Statement) {
+	p.mark(s)
+	switch s := s.(type) {
+	case *ExpressionStatement:
+		if startsLikeStatement(s.Expression) {
+			p.write("(")
+			p.expression(s.Expression, 0)
+			p.write(")")
+		} else {
+			p.expression(s.Expression, 0)
+		}
+	case *VariableDeclaration:
+		p.declaration(s)
+	case *ClassDeclaration:
+		p.class(s)
+	case *BlockStatement:
+		p.block(s)
+	case *IfStatement:
+		p.ifStatement(s)
+	case *WhileStatement:
+		p.write("while")
+		p.space()
+		p.write("(")
+		p.expression(s.Test, 0)
+		p.write(")")
+		p.space()
+		p.block(s.Body)
+	case *ForStatement:
+		p.forStatement(s)
+	case *ForOfStatement:
+		p.write("for")
+		p.space()
+		p.write("(")
+		p.declaration(s.Left)
+		p.operator("of")
+		p.expression(s.Right, precedenceAssignment)
+		p.write(")")
+		p.space()
+		p.block(s.Body)
+	case *SwitchStatement:
+		p.switchStatement(s)
+	case *TryStatement:
+		p.write("try")
+		p.space()
+		p.block(s.Block)
+		p.operator("catch")
+		p.write("(")
+		p.expression(s.Param, 0)
+		p.write(")")
+		p.space()
+		p.block(s.Handler)
+	case *ReturnStatement:
+		p.write("return")
+		if s.Argument != nil {
+			p.space()
+			p.expression(s.Argument, 0)
+		}
+	case *ThrowStatement:
+		p.write("throw")
+		p.space()
+		p.expression(s.Argument, 0)
+	case *BreakStatement:
+		p.write("break")
+	case *ContinueStatement:
+		p.write("continue")
+	case *ImportDeclaration:
+		p.importDeclaration(s)
+	}
+	if hasSemicolon(s) {
+		p.write(";")
+	}
+}
+
+// Statements ending with a function body are not followed by a semicolon
+// (e.g. 'const f = () => {}'), nor are statements ending with a block.
+func hasSemicolon(s Statement) bool {
+	switch s := s.(type) {
+	case *ExpressionStatement:
+		return !endsWithBody(s.Expression)
+	case *VariableDeclaration:
+		return !endsWithBody(s.Declarations[len(s.Declarations)-1].Init)
+	case *ReturnStatement,
+		*ThrowStatement,
+		*BreakStatement,
+		*ContinueStatement,
+		*ImportDeclaration:
+		return true
+	default:
+		return false
+	}
+}
+
+// Whether the statement ends with an expression, and may be continued by the
+// next line if it has no semicolon
+func endsWithExpression(s Statement) bool {
+	switch s.(type) {
+	case *ExpressionStatement, *VariableDeclaration:
+		return true
+	default:
+		return false
+	}
+}
+
+func endsWithBody(expr Expression) bool {
+	switch expr := expr.(type) {
+	case *ArrowFunction:
+		_, ok := expr.Body.(*BlockStatement)
+		return ok
+	case *FunctionExpression:
+		return true
+	case *AssignmentExpression:
+		return endsWithBody(expr.Right)
+	default:
+		return false
+	}
+}
+
+// Whether the expression starts with '{' or 'function', which would be read
+// as the start of a block or function declaration
+func startsLikeStatement(expr Expression) bool {
+	switch expr := expr.(type) {
+	case *ObjectExpression, *FunctionExpression:
+		return true
+	case *MemberExpression:
+		return startsLikeStatement(expr.Object)
+	case *CallExpression:
+		return startsLikeStatement(expr.Callee)
+	case *BinaryExpression:
+		return startsLikeStatement(expr.Left)
+	case *AssignmentExpression:
+		return startsLikeStatement(expr.Left)
+	case *ConditionalExpression:
+		return startsLikeStatement(expr.Test)
+	case *SequenceExpression:
+		return startsLikeStatement(expr.Expressions[0])
+	case *UpdateExpression:
+		return !expr.Prefix && startsLikeStatement(expr.Argument)
+	default:
+		return false
+	}
+}
+
+func (p *printer) block(b *BlockStatement) {
+	p.write("{")
+	if len(b.Body) == 0 {
+		p.write("}")
+		return
+	}
+	p.newline()
+	p.depth++
+	p.statements(b.Body)
+	p.depth--
+	p.indent()
+	p.write("}")
+}
+
+// Print a declaration without its semicolon
+func (p *printer) declaration(d *VariableDeclaration) {
+	if d.Export {
+		p.write("export")
+		p.space()
+	}
+	p.write(d.Kind)
+	p.space()
+	for i, declarator := range d.Declarations {
+		if i > 0 {
+			p.comma()
+		}
+		p.expression(declarator.Name, precedenceAssignment)
+		if declarator.Init != nil {
+			p.operator("=")
+			p.expression(declarator.Init, precedenceAssignment)
+		}
+	}
+}
+
+func (p *printer) class(c *ClassDeclaration) {
+	if c.Export {
+		p.write("export")
+		p.space()
+	}
+	p.write("class")
+	p.space()
+	p.write(c.Name)
+	if c.Extends != nil {
+		p.operator("extends")
+		p.expression(c.Extends, precedenceCall)
+	}
+	p.space()
+	p.write("{")
+	if len(c.Methods) == 0 {
+		p.write("}")
+		return
+	}
+	p.newline()
+	p.depth++
+	for _, method := range c.Methods {
+		p.indent()
+		p.write(method.Name)
+		p.params(method.Params)
+		p.space()
+		p.block(method.Body)
+		p.newline()
+	}
+	p.depth--
+	p.indent()
+	p.write("}")
+}
+
+func (p *printer) ifStatement(i *IfStatement) {
+	p.write("if")
+	p.space()
+	p.write("(")
+	p.expression(i.Test, 0)
+	p.write(")")
+	p.space()
+	p.block(i.Consequent)
+	if i.Alternate == nil {
+		return
+	}
+	p.operator("else")
+	p.statement(i.Alternate)
+}
+
+func (p *printer) forStatement(f *ForStatement) {
+	p.write("for")
+	p.space()
+	p.write("(")
+	if f.Init != nil {
+		p.declaration(f.Init)
+	}
+	p.write(";")
+	if f.Test != nil {
+		p.space()
+		p.expression(f.Test, 0)
+	}
+	p.write(";")
+	if f.Update != nil {
+		p.space()
+		p.expression(f.Update, 0)
+	}
+	p.write(")")
+	p.space()
+	p.block(f.Body)
+}
+
+// Cases with a single block are written on the line of their test
+// (e.g. 'case 1: {')
+func (p *printer) switchStatement(s *SwitchStatement) {
+	p.write("switch")
+	p.space()
+	p.write("(")
+	p.expression(s.Discriminant, 0)
+	p.write(")")
+	p.space()
+	p.write("{")
+	p.newline()
+	p.depth++
+	for _, c := range s.Cases {
+		p.indent()
+		if c.Test != nil {
+			p.write("case")
+			p.space()
+			p.expression(c.Test, 0)
+		} else {
+			p.write("default")
+		}
+		p.write(":")
+		if b, ok := getSingleBlock(c.Consequent); ok {
+			p.space()
+			p.statement(b)
+			p.newline()
+			continue
+		}
+		p.newline()
+		p.depth++
+		p.statements(c.Consequent)
+		p.depth--
+	}
+	p.depth--
+	p.indent()
+	p.write("}")
+}
+
+func getSingleBlock(statements []Statement) (*BlockStatement, bool) {
+	if len(statements) != 1 {
+		return nil, false
+	}
+	b, ok := statements[0].(*BlockStatement)
+	return b, ok
+}
+
+func (p *printer) importDeclaration(i *ImportDeclaration) {
+	p.write("import")
+	switch {
+	case i.Namespace != nil:
+		p.space()
+		p.write("*")
+		p.operator("as")
+		p.expression(i.Namespace, 0)
+		p.operator("from")
+	case len(i.Specifiers) > 0:
+		p.space()
+		p.write("{")
+		p.space()
+		for j, specifier := range i.Specifiers {
+			if j > 0 {
+				p.comma()
+			}
+			p.expression(specifier.Imported, 0)
+			if specifier.Local != nil {
+				p.operator("as")
+				p.expression(specifier.Local, 0)
+			}
+		}
+		p.space()
+		p.write("}")
+		p.operator("from")
+	default:
+		p.space()
+	}
+	p.write(strconv.Quote(i.Source))
+}
+
+/******************************
+ *  EXPRESSIONS               *
+ ******************************/
+
+// Operator precedence, as in MDN's table
+const (
+	precedenceSequence   = 1
+	precedenceAssignment = 2 // also conditionals, arrow functions and yield
+	precedencePrefix     = 14
+	precedencePostfix    = 15
+	precedenceCall       = 17 // also member access and new
+	precedencePrimary    = 18
+)
+
+var binaryPrecedences = map[string]int{
+	"||": 3, "??": 3,
+	"&&": 4,
+	"|":  5,
+	"^":  6,
+	"&":  7,
+	"==": 8, "!=": 8, "===": 8, "!==": 8,
+	"<": 9, "<=": 9, ">": 9, ">=": 9, "in": 9, "instanceof": 9,
+	"<<": 10, ">>": 10, ">>>": 10,
+	"+": 11, "-": 11,
+	"*": 12, "/": 12, "%": 12,
+	"**": 13,
+}
+
+func getPrecedence(expr Expression) int {
+	switch expr := expr.(type) {
+	case *SequenceExpression:
+		return precedenceSequence
+	case *AssignmentExpression,
+		*ConditionalExpression,
+		*ArrowFunction,
+		*YieldExpression:
+		return precedenceAssignment
+	case *BinaryExpression:
+		return binaryPrecedences[expr.Operator]
+	case *UnaryExpression, *AwaitExpression:
+		return precedencePrefix
+	case *UpdateExpression:
+		if expr.Prefix {
+			return precedencePrefix
+		}
+		return precedencePostfix
+	case *CallExpression, *NewExpression, *MemberExpression:
+		return precedenceCall
+	default:
+		return precedencePrimary
+	}
+}
+
+// Print an expression, in parentheses if its precedence is lower than the
+// given one
+func (p *printer) expression(expr Expression, precedence int) {
+	if getPrecedence(expr) < precedence {
+		p.write("(")
+		defer p.write(")")
+	}
+	p.mark(expr)
+	switch expr := expr.(type) {
+	case *Identifier:
+		p.write(expr.Name)
+	case *Literal:
+		p.write(expr.Raw)
+	case *ArrayExpression:
+		p.write("[")
+		p.list(expr.Elements)
+		p.write("]")
+	case *ObjectExpression:
+		p.object(expr)
+	case *MemberExpression:
+		p.expression(expr.Object, precedenceCall)
+		if expr.Computed {
+			p.write("[")
+			p.expression(expr.Property, 0)
+			p.write("]")
+		} else {
+			p.write(".")
+			p.expression(expr.Property, precedencePrimary)
+		}
+	case *CallExpression:
+		p.expression(expr.Callee, precedenceCall)
+		p.write("(")
+		p.list(expr.Arguments)
+		p.write(")")
+	case *NewExpression:
+		p.write("new")
+		p.space()
+		// 'new f()()' would call the result of 'new f()'
+		if containsCall(expr.Callee) {
+			p.expression(expr.Callee, precedencePrimary)
+		} else {
+			p.expression(expr.Callee, precedenceCall)
+		}
+		p.write("(")
+		p.list(expr.Arguments)
+		p.write(")")
+	case *UnaryExpression:
+		p.write(expr.Operator)
+		if isWordByte(expr.Operator[0]) {
+			p.space()
+		}
+		p.expression(expr.Argument, precedencePrefix)
+	case *UpdateExpression:
+		if expr.Prefix {
+			p.write(expr.Operator)
+		}
+		p.expression(expr.Argument, precedenceCall)
+		if !expr.Prefix {
+			p.write(expr.Operator)
+		}
+	case *BinaryExpression:
+		p.binary(expr)
+	case *AssignmentExpression:
+		p.expression(expr.Left, precedenceCall)
+		p.operator(expr.Operator)
+		p.expression(expr.Right, precedenceAssignment)
+	case *ConditionalExpression:
+		p.expression(expr.Test, precedenceAssignment+1)
+		p.operator("?")
+		p.expression(expr.Consequent, precedenceAssignment)
+		p.operator(":")
+		p.expression(expr.Alternate, precedenceAssignment)
+	case *ArrowFunction:
+		p.arrow(expr)
+	case *FunctionExpression:
+		if expr.Async {
+			p.write("async")
+			p.space()
+		}
+		p.write("function")
+		if expr.Generator {
+			p.write("*")
+		}
+		p.space()
+		p.params(expr.Params)
+		p.space()
+		p.block(expr.Body)
+	case *AwaitExpression:
+		p.write("await")
+		p.space()
+		p.expression(expr.Argument, precedencePrefix)
+	case *YieldExpression:
+		p.write("yield")
+		if expr.Argument != nil {
+			p.space()
+			p.expression(expr.Argument, precedenceAssignment)
+		}
+	case *SequenceExpression:
+		for i, e := range expr.Expressions {
+			if i > 0 {
+				p.comma()
+			}
+			p.expression(e, precedenceAssignment)
+		}
+	}
+}
+
+// Operands with the same precedence are grouped from the left, except
+// for '**'. Unary operands of '**' need parentheses.
+func (p *printer) binary(b *BinaryExpression) {
+	precedence := binaryPrecedences[b.Operator]
+	left, right := precedence, precedence+1
+	if b.Operator == "**" {
+		left, right = precedencePostfix, precedence
+	}
+	p.expression(b.Left, left)
+	p.operator(b.Operator)
+	p.expression(b.Right, right)
+}
+
+func (p *printer) arrow(a *ArrowFunction) {
+	if a.Async {
+		p.write("async")
+		p.space()
+	}
+	p.params(a.Params)
+	p.operator("=>")
+	switch body := a.Body.(type) {
+	case *BlockStatement:
+		p.block(body)
+	case Expression:
+		if startsLikeStatement(body) {
+			p.write("(")
+			p.expression(body, 0)
+			p.write(")")
+		} else {
+			p.expression(body, precedenceAssignment)
+		}
+	}
+}
+
+func (p *printer) params(params []Expression) {
+	p.write("(")
+	p.list(params)
+	p.write(")")
+}
+
+// Print comma-separated expressions
+func (p *printer) list(expressions []Expression) {
+	for i, expr := range expressions {
+		if i > 0 {
+			p.comma()
+		}
+		p.expression(expr, precedenceAssignment)
+	}
+}
+
+func (p *printer) object(o *ObjectExpression) {
+	if len(o.Properties) == 0 {
+		p.write("{}")
+		return
+	}
+	p.write("{")
+	p.space()
+	for i, property := range o.Properties {
+		if i > 0 {
+			p.comma()
+		}
+		p.write(property.Key)
+		if property.Value != nil {
+			p.write(":")
+			p.space()
+			p.expression(property.Value, precedenceAssignment)
+		}
+	}
+	p.space()
+	p.write("}")
+}
+
+// Whether the callee of a new expression contains a call, which would take
+// the arguments of new without parentheses
+func containsCall(expr Expression) bool {
+	switch expr := expr.(type) {
+	case *CallExpression:
+		return true
+	case *MemberExpression:
+		return containsCall(expr.Object)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,150 @@
+package js
+
+import "testing"
+
+func testPrint(t *testing.T, node Node, expected string) {
+	if received := Print(node, Options{}); received != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, received)
+	}
+}
+
+func testPrintCompact(t *testing.T, node Node, expected string) {
+	if received := Print(node, Options{Compact: true}); received != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, received)
+	}
+}
+
+func number(raw string) *Literal { return &Literal{Raw: raw} }
+
+func binary(left Expression, operator string, right Expression) *BinaryExpression {
+	return &BinaryExpression{Operator: operator, Left: left, Right: right}
+}
+
+func TestPrintPrecedence(t *testing.T) {
+	a, b, c := Name("a"), Name("b"), Name("c")
+	testPrint(t, binary(binary(a, "+", b), "*", c), "(a + b) * c")
+	testPrint(t, binary(a, "*", binary(b, "+", c)), "a * (b + c)")
+	testPrint(t, binary(binary(a, "*", b), "+", c), "a * b + c")
+	testPrint(t, binary(binary(a, "-", b), "-", c), "a - b - c")
+	testPrint(t, binary(a, "-", binary(b, "-", c)), "a - (b - c)")
+	testPrint(t, binary(a, "||", binary(b, "&&", c)), "a || b && c")
+	testPrint(t, binary(binary(a, "||", b), "&&", c), "(a || b) && c")
+}
+
+func TestPrintExponent(t *testing.T) {
+	a, b, c := Name("a"), Name("b"), Name("c")
+	testPrint(t, binary(a, "**", binary(b, "**", c)), "a ** b ** c")
+	testPrint(t, binary(binary(a, "**", b), "**", c), "(a ** b) ** c")
+	// '-a ** b' is a syntax error
+	negative := &UnaryExpression{Operator: "-", Argument: a}
+	testPrint(t, binary(negative, "**", b), "(-a) ** b")
+	testPrint(t, &UnaryExpression{Operator: "-", Argument: binary(a, "**", b)}, "-(a ** b)")
+}
+
+func TestPrintCallee(t *testing.T) {
+	arrow := &ArrowFunction{Body: number("1")}
+	testPrint(t, Call(arrow), "(() => 1)()")
+	testPrint(t, Call(Dot(Call(Name("f")), "g")), "f().g()")
+	testPrint(t, New(Call(Name("f"))), "new (f())()")
+	testPrint(t, Dot(New(Dot(Name("__"), "Option")), "tag"), "new __.Option().tag")
+}
+
+func TestPrintConditional(t *testing.T) {
+	nested := &ConditionalExpression{Test: Name("a"), Consequent: Name("b"), Alternate: Name("c")}
+	testPrint(t, &ConditionalExpression{Test: Name("x"), Consequent: Name("y"), Alternate: nested}, "x ? y : a ? b : c")
+	testPrint(t, &ConditionalExpression{Test: nested, Consequent: Name("y"), Alternate: Name("z")}, "(a ? b : c) ? y : z")
+}
+
+func TestPrintYield(t *testing.T) {
+	yield := &YieldExpression{Argument: Call(Name("f"))}
+	testPrint(t, Call(Name("g"), yield), "g(yield f())")
+	testPrint(t, binary(yield, "+", number("1")), "(yield f()) + 1")
+}
+
+func TestPrintObjectStatement(t *testing.T) {
+	object := &ObjectExpression{Properties: []*Property{{Key: "a", Value: number("1")}, {Key: "b"}}}
+	testPrint(t, &ExpressionStatement{Expression: Dot(object, "a")}, "({ a: 1, b }.a);")
+	testPrint(t, &ArrowFunction{Body: object}, "() => ({ a: 1, b })")
+}
+
+func TestPrintBlocks(t *testing.T) {
+	program := &Program{Body: []Statement{
+		Declare("const", Name("f"), &ArrowFunction{
+			Params: []Expression{Name("x")},
+			Body: &BlockStatement{Body: []Statement{
+				&IfStatement{
+					Test:       binary(Name("x"), ">", number("0")),
+					Consequent: &BlockStatement{Body: []Statement{&ReturnStatement{Argument: Name("x")}}},
+					Alternate:  &BlockStatement{},
+				},
+				&ReturnStatement{Argument: &UnaryExpression{Operator: "-", Argument: Name("x")}},
+			}},
+		}),
+		&ExpressionStatement{Expression: Call(Name("f"), number("1"))},
+	}}
+	expected := "const f = (x) => {\n"
+	expected += "    if (x > 0) {\n"
+	expected += "        return x;\n"
+	expected += "    } else {}\n"
+	expected += "    return -x;\n"
+	expected += "}\n"
+	expected += "f(1);\n"
+	testPrint(t, program, expected)
+	testPrintCompact(t, program, "const f=(x)=>{if(x>0){return x;}else{}return-x;}\nf(1);\n")
+}
+
+func TestPrintCompactSpaces(t *testing.T) {
+	minus := binary(Name("a"), "-", &UnaryExpression{Operator: "-", Argument: number("1")})
+	testPrintCompact(t, minus, "a- -1")
+	typeOf := binary(&UnaryExpression{Operator: "typeof", Argument: Name("x")}, "===", String("number"))
+	testPrintCompact(t, typeOf, "typeof x===\"number\"")
+	testPrintCompact(t, binary(Name("x"), "instanceof", Name("Map")), "x instanceof Map")
+}
+
+func TestPrintSwitch(t *testing.T) {
+	s := &SwitchStatement{
+		Discriminant: Dot(Name("_m"), "tag"),
+		Cases: []*SwitchCase{
+			{Test: String("Some"), Consequent: []Statement{&BlockStatement{Body: []Statement{&BreakStatement{}}}}},
+			{Consequent: []Statement{&ReturnStatement{}}},
+		},
+	}
+	expected := "switch (_m.tag) {\n"
+	expected += "    case \"Some\": {\n"
+	expected += "        break;\n"
+	expected += "    }\n"
+	expected += "    default:\n"
+	expected += "        return;\n"
+	expected += "}"
+	testPrint(t, s, expected)
+	testPrintCompact(t, s, "switch(_m.tag){case\"Some\":{break;}default:return;}")
+}
+
+func TestPrintImports(t *testing.T) {
+	testPrint(t, &ImportDeclaration{Namespace: Name("lib"), Source: "./lib.js"}, "import * as lib from \"./lib.js\";")
+	specifiers := []*ImportSpecifier{{Imported: Name("a")}, {Imported: Name("b"), Local: Name("c")}}
+	imports := &ImportDeclaration{Specifiers: specifiers, Source: "./lib.js"}
+	testPrint(t, imports, "import { a, b as c } from \"./lib.js\";")
+	testPrintCompact(t, imports, "import{a,b as c}from\"./lib.js\";")
+	testPrint(t, &ImportDeclaration{Source: "./lib.js"}, "import \"./lib.js\";")
+}
+
+func TestPrintMappings(t *testing.T) {
+	x := Name("x")
+	Locate(x, Position{Line: 3, Col: 4})
+	statement := &ExpressionStatement{Expression: binary(Name("a"), "+", x)}
+	Locate(statement, Position{Line: 3, Col: 0})
+	block := &BlockStatement{Body: []Statement{statement}}
+
+	code, mappings := PrintWithMappings(block, Options{})
+	if code != "{\n    a + x;\n}" {
+		t.Fatalf("unexpected code: %q", code)
+	}
+	expected := []Mapping{
+		{Generated: Position{1, 4}, Source: Position{3, 0}},
+		{Generated: Position{1, 8}, Source: Position{3, 4}},
+	}
+	if len(mappings) != len(expected) || mappings[0] != expected[0] || mappings[1] != expected[1] {
+		t.Fatalf("expected mappings %v, got %v", expected, mappings)
+	}
+}
@@ -0,0 +1,177 @@
+// Package lint runs configurable rules over type-checked programs.
+//
+// Findings can be silenced with a comment naming the rule, either at the
+// end of the reported line or on the line before:
+//
+//	// kiwi-ignore unused-variable
+//	x := 42
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+type Level int
+
+const (
+	Off Level = iota
+	Info
+	Warning
+	Error
+)
+
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "off":
+		return Off, nil
+	case "info":
+		return Info, nil
+	case "warning":
+		return Warning, nil
+	case "error":
+		return Error, nil
+	default:
+		return Off, fmt.Errorf("unknown lint level '%v', expected 'off', 'info', 'warning' or 'error'", s)
+	}
+}
+
+func (l Level) severity() parser.Severity {
+	switch l {
+	case Info:
+		return parser.InfoSeverity
+	case Warning:
+		return parser.WarningSeverity
+	default:
+		return parser.ErrorSeverity
+	}
+}
+
+// Levels by rule name, overriding the rules' defaults
+type Config map[string]Level
+
+// Get levels from the "lint" property of a project's kiwi.json.
+// Unknown rules and levels are reported as diagnostics.
+func ConfigFrom(project parser.Config) (Config, []parser.Diagnostic) {
+	config := Config{}
+	diagnostics := []parser.Diagnostic{}
+	names := make([]string, 0, len(project.Lint))
+	for name := range project.Lint {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		var err error
+		if IsRule(name) {
+			config[name], err = ParseLevel(project.Lint[name])
+		} else {
+			err = fmt.Errorf("unknown lint rule '%v'", name)
+		}
+		if err != nil {
+			diagnostics = append(diagnostics, parser.Diagnostic{
+				Severity: parser.ErrorSeverity,
+				Code:     "config",
+				Message:  err.Error(),
+				Location: project.Location("lint." + name),
+			})
+		}
+	}
+	return config, diagnostics
+}
+
+type Rule struct {
+	Name  string
+	Level Level // default level
+	Check func(c *Context)
+}
+
+// State shared by a rule while it checks a program
+type Context struct {
+	Program  parser.Program
+	rule     Rule
+	level    Level
+	findings []parser.Diagnostic
+}
+
+// Report a finding at the given location
+func (c *Context) Report(loc parser.Loc, message string, fixes ...parser.Fix) {
+	c.findings = append(c.findings, parser.Diagnostic{
+		Severity: c.level.severity(),
+		Code:     c.rule.Name,
+		Message:  message,
+		Location: parser.Location{Path: c.Program.Path(), Loc: loc},
+		Fixes:    fixes,
+	})
+}
+
+// Get a fix replacing the text at loc
+func (c *Context) Fix(message string, loc parser.Loc, newText string) parser.Fix {
+	edit := parser.TextEdit{Path: c.Program.Path(), Loc: loc, NewText: newText}
+	return parser.Fix{Message: message, Edits: []parser.TextEdit{edit}}
+}
+
+// Walk every node of the program
+func (c *Context) Walk(visit func(n parser.Node)) {
+	for _, node := range c.Program.Nodes() {
+		parser.Walk(node, func(n parser.Node, skip func()) {
+			if n == nil {
+				skip()
+				return
+			}
+			visit(n)
+		})
+	}
+}
+
+// Check if a rule with the given name exists
+func IsRule(name string) bool {
+	return slices.ContainsFunc(Rules, func(r Rule) bool { return r.Name == name })
+}
+
+// Run all enabled rules over the program
+func Run(program parser.Program, config Config) []parser.Diagnostic {
+	suppressed := getSuppressions(program.Comments())
+	diagnostics := []parser.Diagnostic{}
+	for _, rule := range Rules {
+		level, ok := config[rule.Name]
+		if !ok {
+			level = rule.Level
+		}
+		if level == Off {
+			continue
+		}
+		c := &Context{Program: program, rule: rule, level: level}
+		rule.Check(c)
+		for _, finding := range c.findings {
+			if !slices.Contains(suppressed[finding.Location.Loc.Start.Line], rule.Name) {
+				diagnostics = append(diagnostics, finding)
+			}
+		}
+	}
+	slices.SortStableFunc(diagnostics, func(a, b parser.Diagnostic) int {
+		if a.Location.Loc.Start.Line != b.Location.Loc.Start.Line {
+			return a.Location.Loc.Start.Line - b.Location.Loc.Start.Line
+		}
+		return a.Location.Loc.Start.Col - b.Location.Loc.Start.Col
+	})
+	return diagnostics
+}
+
+// Get suppressed rule names by line.
+// A '// kiwi-ignore rule-name' comment applies to its own line and the next one.
+func getSuppressions(comments []parser.Comment) map[int][]string {
+	suppressed := map[int][]string{}
+	for _, comment := range comments {
+		fields := strings.Fields(strings.ReplaceAll(comment.Text, ",", " "))
+		if len(fields) == 0 || fields[0] != "kiwi-ignore" {
+			continue
+		}
+		line := comment.Loc.Start.Line
+		suppressed[line] = append(suppressed[line], fields[1:]...)
+		suppressed[line+1] = append(suppressed[line+1], fields[1:]...)
+	}
+	return suppressed
+}
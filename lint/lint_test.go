@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func lint(t *testing.T, source string, config Config) []parser.Diagnostic {
+	program, errors := parser.ParseProgram(strings.NewReader(source), "main")
+	for _, err := range errors {
+		t.Logf("unexpected error: %v", err.Text())
+	}
+	return Run(program, config)
+}
+
+func testFindings(t *testing.T, diagnostics []parser.Diagnostic, expected ...string) {
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("Expected findings %v, got %#v", expected, diagnostics)
+	}
+	for i, d := range diagnostics {
+		if d.Code != expected[i] {
+			t.Fatalf("Expected finding %v, got %#v", expected[i], d)
+		}
+	}
+}
+
+func TestUnusedVariable(t *testing.T) {
+	source := "_f :: (a number, b number) => { a }\n_g := _f(1, 2)"
+	diagnostics := lint(t, source, nil)
+	testFindings(t, diagnostics, "unused-variable")
+	if diagnostics[0].Message != "Unused variable 'b'" {
+		t.Fatalf("Unexpected message: %v", diagnostics[0].Message)
+	}
+	if diagnostics[0].Severity != parser.WarningSeverity {
+		t.Fatalf("Expected warning, got %v", diagnostics[0].Severity)
+	}
+}
+
+func TestUnusedCaseParam(t *testing.T) {
+	source := "_describe :: (v number | string) => {\n"
+	source += "    match v {\n"
+	source += "        n number: \"number\"\n"
+	source += "        s string: s\n"
+	source += "    }\n"
+	source += "}\n"
+	source += "_d := _describe(3)"
+	testFindings(t, lint(t, source, nil))
+}
+
+func TestUnusedImport(t *testing.T) {
+	lib, _ := parser.ParseProgram(strings.NewReader("answer :: 42\n_b := answer"), "/project/lib")
+	parser.ExportProgram("/project/lib", lib)
+	program, _ := parser.ParseProgram(strings.NewReader("use answer from \"./lib\""), "/project/main")
+	testFindings(t, Run(program, nil), "unused-import")
+}
+
+func TestShadowedVariable(t *testing.T) {
+	source := "_a := 1\n_f :: (_a number) => { _a }\n_g := _f(_a)"
+	testFindings(t, lint(t, source, nil), "shadowed-variable")
+}
+
+func TestUnneededCatch(t *testing.T) {
+	source := "_a := 1 catch err { 0 }\n_b := _a"
+	testFindings(t, lint(t, source, nil), "unneeded-catch", "unused-variable")
+}
+
+func TestRedundantReference(t *testing.T) {
+	source := "_a := 1\n_b := *&_a\n_c := _b"
+	diagnostics := lint(t, source, nil)
+	testFindings(t, diagnostics, "redundant-reference")
+	if len(diagnostics[0].Fixes) != 1 {
+		t.Fatalf("Expected a fix, got %#v", diagnostics[0].Fixes)
+	}
+}
+
+func TestConstantCondition(t *testing.T) {
+	source := "_a := if true { 1 } else { 2 }\n_b := _a"
+	testFindings(t, lint(t, source, nil), "constant-condition")
+}
+
+func TestConstantLoopCondition(t *testing.T) {
+	source := "_f :: () => {\n    for true {\n        break\n    }\n    for false {}\n}"
+	testFindings(t, lint(t, source, nil), "constant-condition")
+}
+
+func TestConfigLevels(t *testing.T) {
+	source := "_a := if true { 1 } else { 2 }\n_b := _a"
+	testFindings(t, lint(t, source, Config{"constant-condition": Off}))
+
+	diagnostics := lint(t, source, Config{"constant-condition": Error})
+	if len(diagnostics) != 1 || diagnostics[0].Severity != parser.ErrorSeverity {
+		t.Fatalf("Expected 1 error, got %#v", diagnostics)
+	}
+}
+
+func TestIgnoreComment(t *testing.T) {
+	source := "// kiwi-ignore constant-condition\n_a := if true { 1 } else { 2 }\n_b := _a"
+	testFindings(t, lint(t, source, nil))
+
+	source = "_a := if true { 1 } else { 2 } // kiwi-ignore constant-condition\n_b := _a"
+	testFindings(t, lint(t, source, nil))
+
+	source = "// kiwi-ignore unused-variable\n_a := if true { 1 } else { 2 }\n_b := _a"
+	testFindings(t, lint(t, source, nil), "constant-condition")
+}
+
+func TestUnneededAsync(t *testing.T) {
+	source := "_f :: () => { 1 }\n_a := async _f()\n_b := _a"
+	diagnostics := lint(t, source, nil)
+	testFindings(t, diagnostics, "unneeded-async")
+	expected := parser.Loc{Start: parser.Position{Line: 2, Col: 7}, End: parser.Position{Line: 2, Col: 13}}
+	if len(diagnostics[0].Fixes) != 1 || diagnostics[0].Fixes[0].Edits[0].Loc != expected {
+		t.Fatalf("Expected fix removing 'async ', got %#v", diagnostics[0].Fixes)
+	}
+}
+
+func TestConfigFrom(t *testing.T) {
+	content := `{"lint": {"unused-variable": "off", "nope": "error", "shadowed-variable": "loud"}}`
+	project, _ := parser.ParseConfig(parser.ConfigName, []byte(content))
+	config, diagnostics := ConfigFrom(project)
+	if config["unused-variable"] != Off {
+		t.Fatalf("Expected unused-variable to be off, got %#v", config)
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected 2 diagnostics, got %#v", diagnostics)
+	}
+	if diagnostics[0].Location.Loc.Start.Col != 45 {
+		t.Fatalf("Expected diagnostic on 'nope' value, got %#v", diagnostics[0])
+	}
+}
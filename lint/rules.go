@@ -0,0 +1,228 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// All available rules, in reporting order
+var Rules = []Rule{
+	{Name: "unused-variable", Level: Warning, Check: checkUnusedVariables},
+	{Name: "unused-import", Level: Warning, Check: checkUnusedImports},
+	{Name: "shadowed-variable", Level: Warning, Check: checkShadowedVariables},
+	{Name: "unneeded-async", Level: Warning, Check: checkUnneededAsync},
+	{Name: "unneeded-catch", Level: Warning, Check: checkUnneededCatch},
+	{Name: "redundant-reference", Level: Warning, Check: checkRedundantReferences},
+	{Name: "constant-condition", Level: Warning, Check: checkConstantConditions},
+}
+
+// Get where the name is declared, without the rest of a param like 'name type'
+func getNameLoc(v *parser.Variable, name string) parser.Loc {
+	start := v.DeclaredAt().Start
+	return parser.Loc{Start: start, End: parser.Position{Line: start.Line, Col: start.Col + len(name)}}
+}
+
+// Local variables should be read at least once.
+// At top-level, public variables should be read (imported names are
+// reported by 'unused-import').
+func checkUnusedVariables(c *Context) {
+	imports := getImportsLocs(c.Program)
+	params := getCaseParamsLocs(c.Program)
+	for _, scope := range c.Program.Scopes() {
+		topLevel := scope == c.Program.Scope()
+		for _, name := range scope.Names() {
+			v := scope.FindLocal(name)
+			if len(v.Reads()) > 0 || topLevel && (name[0] == '_' || isImported(v, imports)) {
+				continue
+			}
+			if params[v.DeclaredAt()] {
+				continue
+			}
+			// type params of called functions are not declared in the source
+			if v.DeclaredAt() == (parser.Loc{}) {
+				continue
+			}
+			c.Report(getNameLoc(v, name), fmt.Sprintf("Unused variable '%v'", name))
+		}
+	}
+}
+
+func checkUnusedImports(c *Context) {
+	imports := getImportsLocs(c.Program)
+	scope := c.Program.Scope()
+	for _, name := range scope.Names() {
+		v := scope.FindLocal(name)
+		if len(v.Reads()) == 0 && isImported(v, imports) {
+			c.Report(getNameLoc(v, name), fmt.Sprintf("Unused import '%v'", name))
+		}
+	}
+}
+
+func getImportsLocs(program parser.Program) []parser.Loc {
+	locs := []parser.Loc{}
+	for _, node := range program.Nodes() {
+		switch node := node.(type) {
+		case *parser.UseDirective, *parser.ExternDeclaration:
+			locs = append(locs, node.Loc())
+		}
+	}
+	return locs
+}
+
+// Params of match cases are needed to test types (e.g. 'n number: 0'), even
+// when the matched value is not used
+func getCaseParamsLocs(program parser.Program) map[parser.Loc]bool {
+	locs := map[parser.Loc]bool{}
+	for _, node := range program.Nodes() {
+		parser.Walk(node, func(n parser.Node, skip func()) {
+			m, ok := n.(*parser.MatchExpression)
+			if !ok {
+				return
+			}
+			for _, c := range m.Cases {
+				if param, ok := c.Pattern.(*parser.Param); ok {
+					locs[param.Identifier.Loc()] = true
+				}
+			}
+		})
+	}
+	return locs
+}
+
+func isImported(v *parser.Variable, imports []parser.Loc) bool {
+	start := v.DeclaredAt().Start
+	for _, loc := range imports {
+		if !isBefore(start, loc.Start) && isBefore(start, loc.End) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBefore(a parser.Position, b parser.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Col < b.Col
+}
+
+func checkShadowedVariables(c *Context) {
+	for _, scope := range c.Program.Scopes() {
+		if scope == c.Program.Scope() {
+			continue
+		}
+		for _, name := range scope.Names() {
+			shadowed, ok := scope.Shadowed(name)
+			if !ok {
+				continue
+			}
+			v := scope.FindLocal(name)
+			line := shadowed.DeclaredAt().Start.Line
+			c.Report(getNameLoc(v, name), fmt.Sprintf("'%v' shadows a variable declared at line %v", name, line))
+		}
+	}
+}
+
+// 'async' is only useful on calls of async functions
+func checkUnneededAsync(c *Context) {
+	c.Walk(func(n parser.Node) {
+		u, ok := n.(*parser.UnaryExpression)
+		if !ok || u.Operator.Kind() != parser.AsyncKeyword {
+			return
+		}
+		call, ok := u.Operand.(*parser.CallExpression)
+		if !ok || call.Callee == nil {
+			return
+		}
+		f, ok := call.Callee.Type().(parser.Function)
+		if !ok || f.Async {
+			return
+		}
+		loc := parser.Loc{Start: u.Operator.Loc().Start, End: call.Loc().Start}
+		c.Report(u.Loc(), "Unneeded 'async' keyword", c.Fix("remove 'async'", loc, ""))
+	})
+}
+
+// 'catch' is only useful on results
+func checkUnneededCatch(c *Context) {
+	c.Walk(func(n parser.Node) {
+		catch, ok := n.(*parser.CatchExpression)
+		if !ok || catch.Left == nil {
+			return
+		}
+		switch t := catch.Left.Type().(type) {
+		case parser.Invalid:
+		case parser.TypeAlias:
+			if t.Name != "!" {
+				c.Report(catch.Loc(), "Unneeded catch (lhs is not a result type)")
+			}
+		default:
+			c.Report(catch.Loc(), "Unneeded catch (lhs is not a result type)")
+		}
+	})
+}
+
+// Report references to references ('&x' where x is already a reference),
+// and references that are immediately dereferenced ('*&x').
+func checkRedundantReferences(c *Context) {
+	c.Walk(func(n parser.Node) {
+		u, ok := n.(*parser.UnaryExpression)
+		if !ok || u.Operand == nil {
+			return
+		}
+		switch u.Operator.Kind() {
+		case parser.BinaryAnd:
+			if _, ok := u.Operand.Type().(parser.Ref); ok {
+				c.Report(u.Loc(), "Redundant reference: value is already a reference")
+			}
+		case parser.Mul:
+			operand, ok := u.Operand.(*parser.UnaryExpression)
+			if ok && operand.Operator.Kind() == parser.BinaryAnd && operand.Operand != nil {
+				c.Report(u.Loc(), "Redundant reference: reference is immediately dereferenced", c.Fix("remove '*&'", parser.Loc{
+					Start: u.Loc().Start,
+					End:   operand.Operand.Loc().Start,
+				}, ""))
+			}
+		}
+	})
+}
+
+func checkConstantConditions(c *Context) {
+	c.Walk(func(n parser.Node) {
+		var condition parser.Node
+		switch n := n.(type) {
+		case *parser.IfExpression:
+			condition = n.Condition
+		case *parser.ForExpression:
+			// 'for true { ... }' is the idiomatic infinite loop
+			if isTrueLiteral(n.Expr) {
+				return
+			}
+			condition = n.Expr
+		default:
+			return
+		}
+		if expr, ok := condition.(parser.Expression); ok && isConstant(expr) {
+			c.Report(condition.Loc(), "Condition is always the same")
+		}
+	})
+}
+
+func isTrueLiteral(node parser.Node) bool {
+	literal, ok := node.(*parser.Literal)
+	return ok && literal.Text() == "true"
+}
+
+func isConstant(expr parser.Expression) bool {
+	switch expr := expr.(type) {
+	case *parser.Literal:
+		_, ok := expr.Type().(parser.Boolean)
+		return ok
+	case *parser.ParenthesizedExpression:
+		return expr.Expr != nil && isConstant(expr.Expr)
+	case *parser.BinaryExpression:
+		_, left := expr.Left.(*parser.Literal)
+		_, right := expr.Right.(*parser.Literal)
+		return left && right
+	default:
+		return false
+	}
+}
@@ -0,0 +1,132 @@
+package lsp
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bmelicque/test-parser/lint"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Diagnostics found while checking a file
+type report struct {
+	path        string
+	diagnostics []Diagnostic
+}
+
+// Type checks documents and keeps track of their exports,
+// so that dependent files can be re-checked when they change.
+type checker struct {
+	documents *documents
+	programs  map[string]parser.Program
+	exports   map[string]string // textual representation of each file's exports
+}
+
+func newChecker(docs *documents) *checker {
+	return &checker{
+		documents: docs,
+		programs:  map[string]parser.Program{},
+		exports:   map[string]string{},
+	}
+}
+
+// Check a file and every opened document depending on it (if its exports changed).
+// Dependencies that were never checked are checked first, so that imports resolve.
+func (c *checker) check(path string) []report {
+	reports := []report{}
+	files, _ := parser.GetCompileOrderWith(path, c.documents.open)
+	for _, file := range files {
+		if _, ok := c.exports[file.Path]; ok || file.Path == path {
+			continue
+		}
+		reports = append(reports, c.checkFile(file.Path))
+	}
+
+	previous, checked := c.exports[path]
+	reports = append(reports, c.checkFile(path))
+	if checked && previous == c.exports[path] {
+		return reports
+	}
+	for _, dependent := range c.findDependents(path) {
+		reports = append(reports, c.checkFile(dependent))
+	}
+	return reports
+}
+
+// Find opened documents that depend (directly or not) on the given file,
+// in the order they should be checked.
+func (c *checker) findDependents(path string) []string {
+	dependents := []string{}
+	counts := map[string]int{}
+	for _, opened := range c.documents.paths() {
+		if opened == path {
+			continue
+		}
+		files, _ := parser.GetCompileOrderWith(opened, c.documents.open)
+		for _, file := range files {
+			if file.Path == path {
+				dependents = append(dependents, opened)
+				counts[opened] = len(files)
+				break
+			}
+		}
+	}
+	// a file has more dependencies than the files it depends on
+	sort.Slice(dependents, func(i, j int) bool {
+		return counts[dependents[i]] < counts[dependents[j]]
+	})
+	return dependents
+}
+
+func (c *checker) checkFile(path string) report {
+	r := report{path: path, diagnostics: []Diagnostic{}}
+
+	file, err := c.documents.open(path)
+	if err != nil {
+		return r
+	}
+	defer file.Close()
+	if parser.IsDeclarationFile(path) {
+		module, diagnostics := parser.ParseDeclarations(file, path)
+		parser.ExportDeclarations(path, module)
+		c.exports[path] = getExportsText(module)
+		for _, d := range diagnostics {
+			r.diagnostics = append(r.diagnostics, toDiagnostic(d))
+		}
+		return r
+	}
+	program, errors := parser.ParseDocument(file, path)
+	c.programs[path] = program
+	c.exports[path] = getExportsText(parser.ExportProgram(path, program))
+	for _, err := range errors {
+		r.diagnostics = append(r.diagnostics, toDiagnostic(err.Diagnostic(path)))
+	}
+	for _, finding := range lint.Run(program, nil) {
+		r.diagnostics = append(r.diagnostics, toDiagnostic(finding))
+	}
+	return r
+}
+
+func getExportsText(module parser.Module) string {
+	members := make([]string, len(module.Members))
+	for i, member := range module.Members {
+		members[i] = member.Name + " " + member.Type.Text()
+	}
+	slices.Sort(members)
+	return strings.Join(members, "\n")
+}
+
+// Checked programs, sorted by path
+func (c *checker) sortedPrograms() []parser.Program {
+	paths := make([]string, 0, len(c.programs))
+	for path := range c.programs {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	programs := make([]parser.Program, len(paths))
+	for i, path := range paths {
+		programs[i] = c.programs[path]
+	}
+	return programs
+}
@@ -0,0 +1,49 @@
+package lsp
+
+import (
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func (s *Server) completion(params TextDocumentPositionParams) (items []CompletionItem) {
+	items = []CompletionItem{}
+	path := uriToPath(params.TextDocument.URI)
+	text, ok := s.documents.get(path)
+	if !ok {
+		return items
+	}
+	// half-written programs may have unexpected shapes
+	defer func() {
+		if recover() != nil {
+			items = []CompletionItem{}
+		}
+	}()
+	for _, c := range parser.Complete(text, path, fromPosition(params.Position)) {
+		item := CompletionItem{Label: c.Label, Kind: getCompletionItemKind(c)}
+		if c.Type != nil {
+			item.Detail = c.Type.Text()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func getCompletionItemKind(c parser.Completion) int {
+	_, isFunction := c.Type.(parser.Function)
+	_, isType := c.Type.(parser.Type)
+	switch {
+	case c.Kind == parser.ModuleCompletion:
+		return completionModule
+	case c.Kind == parser.ConstructorCompletion:
+		return completionEnumMember
+	case c.Kind == parser.MemberCompletion && isFunction:
+		return completionMethod
+	case c.Kind == parser.MemberCompletion:
+		return completionField
+	case isFunction:
+		return completionFunction
+	case isType:
+		return completionClass
+	default:
+		return completionVariable
+	}
+}
@@ -0,0 +1,46 @@
+package lsp
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// In-memory store of the documents opened in the editor, indexed by path.
+// Unsaved changes take precedence over the file system.
+type documents struct {
+	texts map[string]string
+}
+
+func newDocuments() *documents {
+	return &documents{texts: map[string]string{}}
+}
+
+func (d *documents) set(path string, text string) { d.texts[path] = text }
+func (d *documents) remove(path string)           { delete(d.texts, path) }
+
+func (d *documents) get(path string) (string, bool) {
+	text, ok := d.texts[path]
+	return text, ok
+}
+
+func (d *documents) isOpen(path string) bool {
+	_, ok := d.texts[path]
+	return ok
+}
+
+// Read a file, from the store if opened, else from the file system
+func (d *documents) open(path string) (io.ReadCloser, error) {
+	if text, ok := d.texts[path]; ok {
+		return io.NopCloser(strings.NewReader(text)), nil
+	}
+	return os.Open(path)
+}
+
+func (d *documents) paths() []string {
+	paths := make([]string, 0, len(d.texts))
+	for path := range d.texts {
+		paths = append(paths, path)
+	}
+	return paths
+}
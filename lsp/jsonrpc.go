@@ -0,0 +1,79 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// A JSON-RPC message, which is either a request, a response or a notification.
+// Requests have both an ID and a method, notifications only have a method.
+type message struct {
+	JsonRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	parseError     = -32700
+	methodNotFound = -32601
+	invalidParams  = -32602
+	requestFailed  = -32803
+)
+
+func (m message) isRequest() bool { return m.ID != nil && m.Method != "" }
+
+// Returned when the content of a message is not valid JSON. Unlike I/O
+// errors, the next messages can still be read.
+type invalidContentError struct{ err error }
+
+func (e invalidContentError) Error() string { return "invalid message: " + e.err.Error() }
+
+// Read a message using the base protocol's headers, e.g.:
+//
+//	Content-Length: 42\r\n
+//	\r\n
+//	{...}
+func readMessage(r *bufio.Reader) (message, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return message{}, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return message{}, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return message{}, err
+	}
+	var m message
+	if err := json.Unmarshal(content, &m); err != nil {
+		return message{}, invalidContentError{err}
+	}
+	return m, nil
+}
+
+func writeMessage(w io.Writer, m message) error {
+	m.JsonRPC = "2.0"
+	content, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %v\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
@@ -0,0 +1,63 @@
+package lsp
+
+import (
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func (s *Server) query(params TextDocumentPositionParams) (symbol parser.Symbol, ok bool) {
+	program, ok := s.checker.programs[uriToPath(params.TextDocument.URI)]
+	if !ok {
+		return parser.Symbol{}, false
+	}
+	// half-written programs may have unexpected shapes
+	defer func() {
+		if recover() != nil {
+			symbol, ok = parser.Symbol{}, false
+		}
+	}()
+	return program.Query(fromPosition(params.Position))
+}
+
+// Returns nil if there is nothing to show
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	symbol, ok := s.query(params)
+	if !ok || symbol.Type == nil {
+		return nil
+	}
+	text := symbol.Type.Text()
+	if symbol.Name != "" {
+		text = symbol.Name + " " + text
+	}
+	r := toRange(symbol.Node.Loc())
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```kiwi\n" + text + "\n```"},
+		Range:    &r,
+	}
+}
+
+func (s *Server) definition(params TextDocumentPositionParams) *Location {
+	symbol, ok := s.query(params)
+	if !ok || symbol.Declaration == nil {
+		return nil
+	}
+	location := toLocation(*symbol.Declaration)
+	return &location
+}
+
+// References are searched in every checked file
+func (s *Server) references(params ReferenceParams) []Location {
+	locations := []Location{}
+	symbol, ok := s.query(params.TextDocumentPositionParams)
+	if !ok || symbol.Declaration == nil {
+		return locations
+	}
+	for _, program := range s.checker.sortedPrograms() {
+		for _, reference := range program.References(*symbol.Declaration) {
+			if !params.Context.IncludeDeclaration && reference == *symbol.Declaration {
+				continue
+			}
+			locations = append(locations, toLocation(reference))
+		}
+	}
+	return locations
+}
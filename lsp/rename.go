@@ -0,0 +1,30 @@
+package lsp
+
+import (
+	"github.com/bmelicque/test-parser/parser"
+)
+
+// Returns a nil edit if there is nothing to rename,
+// or an error if the renaming would break the program.
+func (s *Server) rename(params RenameParams) (edit *WorkspaceEdit, err *parser.ParserError) {
+	path := uriToPath(params.TextDocument.URI)
+	if _, ok := s.checker.programs[path]; !ok {
+		return nil, nil
+	}
+	// half-written programs may have unexpected shapes
+	defer func() {
+		if recover() != nil {
+			edit, err = nil, nil
+		}
+	}()
+	edits, err := parser.Rename(s.checker.sortedPrograms(), path, fromPosition(params.Position), params.NewName)
+	if err != nil || edits == nil {
+		return nil, err
+	}
+	edit = &WorkspaceEdit{Changes: map[string][]TextEdit{}}
+	for _, e := range edits {
+		uri := pathToURI(e.Path)
+		edit.Changes[uri] = append(edit.Changes[uri], TextEdit{Range: toRange(e.Loc), NewText: e.NewText})
+	}
+	return edit, nil
+}
@@ -0,0 +1,211 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents *documents
+	checker   *checker
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	docs := newDocuments()
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		documents: docs,
+		checker:   newChecker(docs),
+	}
+}
+
+// Serve LSP requests until the 'exit' notification is received
+// or the input is closed.
+func Serve(in io.Reader, out io.Writer) error {
+	return NewServer(in, out).Run()
+}
+
+func (s *Server) Run() error {
+	for {
+		m, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		var invalid invalidContentError
+		if errors.As(err, &invalid) {
+			if err := s.respondParseError(invalid.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if m.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(m); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(m message) error {
+	switch m.Method {
+	case "initialize":
+		return s.respond(m, InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:   textDocumentSyncFull,
+				HoverProvider:      true,
+				DefinitionProvider: true,
+				ReferencesProvider: true,
+				RenameProvider:     true,
+				CompletionProvider: &CompletionOptions{TriggerCharacters: []string{".", "\""}},
+			},
+			ServerInfo: ServerInfo{Name: "kiwi"},
+		})
+	case "shutdown":
+		return s.respond(m, nil)
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		return s.didOpen(params)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		return s.didChange(params)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		return s.didClose(params)
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		return s.respond(m, s.hover(params))
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		return s.respond(m, s.definition(params))
+	case "textDocument/references":
+		var params ReferenceParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		return s.respond(m, s.references(params))
+	case "textDocument/rename":
+		var params RenameParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		edit, err := s.rename(params)
+		if err != nil {
+			return s.respondError(m, requestFailed, err.Text())
+		}
+		return s.respond(m, edit)
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return s.respondError(m, invalidParams, err.Error())
+		}
+		return s.respond(m, s.completion(params))
+	default:
+		// unknown notifications (like 'initialized') are ignored
+		if m.isRequest() {
+			return s.respondError(m, methodNotFound, fmt.Sprintf("method '%v' not found", m.Method))
+		}
+		return nil
+	}
+}
+
+func (s *Server) didOpen(params DidOpenTextDocumentParams) error {
+	path := uriToPath(params.TextDocument.URI)
+	s.documents.set(path, params.TextDocument.Text)
+	return s.publish(s.checker.check(path))
+}
+
+func (s *Server) didChange(params DidChangeTextDocumentParams) error {
+	changes := params.ContentChanges
+	if len(changes) == 0 {
+		return nil
+	}
+	path := uriToPath(params.TextDocument.URI)
+	s.documents.set(path, changes[len(changes)-1].Text)
+	return s.publish(s.checker.check(path))
+}
+
+func (s *Server) didClose(params DidCloseTextDocumentParams) error {
+	path := uriToPath(params.TextDocument.URI)
+	s.documents.remove(path)
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// Publish the diagnostics of every opened document that was checked
+func (s *Server) publish(reports []report) error {
+	for _, r := range reports {
+		if !s.documents.isOpen(r.path) {
+			continue
+		}
+		err := s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         pathToURI(r.path),
+			Diagnostics: r.diagnostics,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) respond(request message, result any) error {
+	content, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, message{ID: request.ID, Result: content})
+}
+
+func (s *Server) respondError(request message, code int, msg string) error {
+	if !request.isRequest() {
+		return nil
+	}
+	return writeMessage(s.out, message{
+		ID:    request.ID,
+		Error: &responseError{Code: code, Message: msg},
+	})
+}
+
+// The request could not be read, so the response has a null ID
+func (s *Server) respondParseError(msg string) error {
+	id := json.RawMessage("null")
+	return writeMessage(s.out, message{
+		ID:    &id,
+		Error: &responseError{Code: parseError, Message: msg},
+	})
+}
+
+func (s *Server) notify(method string, params any) error {
+	content, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, message{Method: method, Params: content})
+}
@@ -0,0 +1,294 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Scripted JSON-RPC client, talking to a server running in a goroutine
+type testClient struct {
+	t      *testing.T
+	writer io.WriteCloser
+	reader *bufio.Reader
+	done   chan error
+	nextID int
+}
+
+func newTestClient(t *testing.T) *testClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &testClient{
+		t:      t,
+		writer: clientOut,
+		reader: bufio.NewReader(clientIn),
+		done:   make(chan error),
+	}
+	go func() {
+		err := Serve(serverIn, serverOut)
+		serverOut.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() {
+		c.notify("exit", nil)
+		if err := <-c.done; err != nil {
+			t.Errorf("server stopped with error: %v", err)
+		}
+	})
+	return c
+}
+
+func (c *testClient) send(m message) {
+	if err := writeMessage(c.writer, m); err != nil {
+		c.t.Fatalf("could not send message: %v", err)
+	}
+}
+
+func (c *testClient) notify(method string, params any) {
+	content, _ := json.Marshal(params)
+	c.send(message{Method: method, Params: content})
+}
+
+func (c *testClient) request(method string, params any) message {
+	c.nextID++
+	id := json.RawMessage(fmtKey(c.nextID))
+	content, _ := json.Marshal(params)
+	c.send(message{ID: &id, Method: method, Params: content})
+	return c.receive()
+}
+
+func (c *testClient) receive() message {
+	m, err := readMessage(c.reader)
+	if err != nil {
+		c.t.Fatalf("could not read message: %v", err)
+	}
+	return m
+}
+
+// Read the next notification, which should be diagnostics for the given file
+func (c *testClient) receiveDiagnostics(path string) []Diagnostic {
+	m := c.receive()
+	if m.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("Expected diagnostics, got %#v", m)
+	}
+	var params PublishDiagnosticsParams
+	json.Unmarshal(m.Params, &params)
+	if params.URI != pathToURI(path) {
+		c.t.Fatalf("Expected diagnostics for %v, got %v", pathToURI(path), params.URI)
+	}
+	return params.Diagnostics
+}
+
+func (c *testClient) open(path string, text string) {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: pathToURI(path), Version: 1, Text: text},
+	})
+}
+
+func (c *testClient) change(path string, text string) {
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: pathToURI(path)},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: text}},
+	})
+}
+
+func fmtKey(id int) string {
+	b, _ := json.Marshal(id)
+	return string(b)
+}
+
+func TestInitialize(t *testing.T) {
+	c := newTestClient(t)
+	response := c.request("initialize", map[string]any{})
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got %v", response.Error.Message)
+	}
+	var result InitializeResult
+	json.Unmarshal(response.Result, &result)
+	if result.Capabilities.TextDocumentSync != textDocumentSyncFull {
+		t.Fatalf("Expected full document sync, got %#v", result)
+	}
+	response = c.request("shutdown", nil)
+	if string(response.Result) != "null" {
+		t.Fatalf("Expected null result, got %v", string(response.Result))
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	c := newTestClient(t)
+	response := c.request("unknown/method", nil)
+	if response.Error == nil || response.Error.Code != methodNotFound {
+		t.Fatalf("Expected method not found error, got %#v", response)
+	}
+}
+
+func TestInvalidJSON(t *testing.T) {
+	c := newTestClient(t)
+	if _, err := io.WriteString(c.writer, "Content-Length: 5\r\n\r\n{oops"); err != nil {
+		t.Fatalf("could not send message: %v", err)
+	}
+	response := c.receive()
+	if response.Error == nil || response.Error.Code != parseError {
+		t.Fatalf("Expected parse error, got %#v", response)
+	}
+	// the server keeps serving
+	response = c.request("shutdown", nil)
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got %v", response.Error.Message)
+	}
+}
+
+func TestDiagnosticsOnChange(t *testing.T) {
+	c := newTestClient(t)
+	path := filepath.Join(t.TempDir(), "main")
+
+	c.open(path, "_a := 1\n_b := _a + \"hello\"")
+	diagnostics := c.receiveDiagnostics(path)
+	if len(diagnostics) == 0 {
+		t.Fatalf("Expected diagnostics")
+	}
+	expected := Range{Start: Position{1, 11}, End: Position{1, 18}}
+	if diagnostics[0].Range != expected {
+		t.Fatalf("Expected range %v, got %v", expected, diagnostics[0].Range)
+	}
+	if diagnostics[0].Severity != severityError || diagnostics[0].Code == "" {
+		t.Fatalf("Expected coded error, got %#v", diagnostics[0])
+	}
+
+	c.change(path, "_a := 1\n_b := _a + 2")
+	diagnostics = c.receiveDiagnostics(path)
+	if len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+}
+
+func TestRecheckDependents(t *testing.T) {
+	c := newTestClient(t)
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	main := filepath.Join(dir, "main")
+	source := "answer :: 42\n_double := answer * 2"
+	os.WriteFile(lib, []byte(source), 0644)
+
+	c.open(main, "use answer from \"./lib\"\n_a := answer + 1")
+	if diagnostics := c.receiveDiagnostics(main); len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+
+	c.open(lib, source)
+	if diagnostics := c.receiveDiagnostics(lib); len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+
+	c.change(lib, "answer :: true\n_not := !answer")
+	if diagnostics := c.receiveDiagnostics(lib); len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+	if diagnostics := c.receiveDiagnostics(main); len(diagnostics) == 0 {
+		t.Fatalf("Expected main to be re-checked with errors")
+	}
+}
+
+func TestHover(t *testing.T) {
+	c := newTestClient(t)
+	path := filepath.Join(t.TempDir(), "main")
+	c.open(path, "_a := 1\n_b := _a + 2")
+	c.receiveDiagnostics(path)
+
+	response := c.request("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: pathToURI(path)},
+		Position:     Position{1, 7},
+	})
+	var hover Hover
+	json.Unmarshal(response.Result, &hover)
+	if hover.Contents.Value != "```kiwi\n_a number\n```" {
+		t.Fatalf("Unexpected hover content: %q", hover.Contents.Value)
+	}
+}
+
+func TestDefinitionAndReferences(t *testing.T) {
+	c := newTestClient(t)
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	main := filepath.Join(dir, "main")
+	c.open(lib, "answer :: 42\n_double := answer * 2")
+	c.receiveDiagnostics(lib)
+	c.open(main, "use answer from \"./lib\"\n_a := answer + 1")
+	c.receiveDiagnostics(main)
+
+	position := TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: pathToURI(main)},
+		Position:     Position{1, 7},
+	}
+	response := c.request("textDocument/definition", position)
+	var location Location
+	json.Unmarshal(response.Result, &location)
+	expected := Location{
+		URI:   pathToURI(lib),
+		Range: Range{Start: Position{0, 0}, End: Position{0, 6}},
+	}
+	if location != expected {
+		t.Fatalf("Expected %#v, got %#v", expected, location)
+	}
+
+	response = c.request("textDocument/references", ReferenceParams{
+		TextDocumentPositionParams: position,
+		Context:                    ReferenceContext{IncludeDeclaration: false},
+	})
+	var locations []Location
+	json.Unmarshal(response.Result, &locations)
+	// read in lib, use directive and read in main
+	if len(locations) != 3 {
+		t.Fatalf("Expected 3 references, got %#v", locations)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newTestClient(t)
+	path := filepath.Join(t.TempDir(), "main")
+	c.open(path, "_list := []number{1, 2}\n_list.")
+	c.receiveDiagnostics(path)
+
+	response := c.request("textDocument/completion", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: pathToURI(path)},
+		Position:     Position{1, 6},
+	})
+	var items []CompletionItem
+	json.Unmarshal(response.Result, &items)
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 list methods, got %#v", items)
+	}
+	if items[0].Label != "get" || items[0].Kind != completionMethod || items[0].Detail == "" {
+		t.Fatalf("Unexpected completion item: %#v", items[0])
+	}
+}
+
+func TestRename(t *testing.T) {
+	c := newTestClient(t)
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	main := filepath.Join(dir, "main")
+	c.open(lib, "answer :: 42\n_double := answer * 2")
+	c.receiveDiagnostics(lib)
+	c.open(main, "use answer from \"./lib\"\n_a := answer + 1")
+	c.receiveDiagnostics(main)
+
+	position := TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: pathToURI(main)},
+		Position:     Position{1, 7},
+	}
+	response := c.request("textDocument/rename", RenameParams{position, "response"})
+	var edit WorkspaceEdit
+	json.Unmarshal(response.Result, &edit)
+	if len(edit.Changes[pathToURI(lib)]) != 2 || len(edit.Changes[pathToURI(main)]) != 2 {
+		t.Fatalf("Expected 2 edits per file, got %#v", edit)
+	}
+
+	response = c.request("textDocument/rename", RenameParams{position, "_a"})
+	if response.Error == nil || response.Error.Code != requestFailed {
+		t.Fatalf("Expected collision to be refused, got %#v", response)
+	}
+}
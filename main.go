@@ -1,15 +1,17 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
-	"github.com/bmelicque/test-parser/emitter"
+	"github.com/bmelicque/test-parser/lint"
 	"github.com/bmelicque/test-parser/parser"
-	"golang.org/x/net/html"
-	"golang.org/x/net/html/atom"
 )
 
 type TokenKind int
@@ -23,170 +25,258 @@ const (
 	AssignmentOperator
 )
 
-func main() {
-	source := os.Args[1]
-	outDir := os.Args[2]
+// Set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+const (
+	exitOK      = 0
+	exitFailure = 1 // diagnostics with errors, or I/O failure
+	exitUsage   = 2
+)
+
+const usage = `Usage: kiwi <command> [flags] [arguments]
+
+Commands:
+  build    compile a program to JavaScript
+  check    type-check and lint a program without emitting anything
+  run      compile a program and run it with Node.js
+  fmt      format source files
+  init     create a new project
+  rename   rename a symbol across a program
+  lsp      start the language server on stdin/stdout
+  version  print the compiler version
+
+Run 'kiwi <command> --help' for more information on a command.
+`
 
-	transpile(source, outDir)
+var commands = map[string]func(args []string, stderr io.Writer) error{
+	"build":   runBuild,
+	"check":   runCheck,
+	"run":     runRun,
+	"fmt":     runFmt,
+	"init":    runInit,
+	"rename":  runRename,
+	"lsp":     runLsp,
+	"version": runVersion,
 }
 
-type chunk struct {
-	parser.Program
-	path string
+// Arguments of each command, as shown after its name in usage lines
+var commandUsages = map[string]string{
+	"build":   "[flags] [entries...]",
+	"check":   "[flags] [entries...]",
+	"run":     "[flags] [entry]",
+	"fmt":     "[--check] <files...>",
+	"init":    "[dir]",
+	"rename":  "[flags] <file>:<line>:<col> <new name>",
+	"lsp":     "",
+	"version": "",
 }
 
-func transpile(rootPath string, outDir string) {
-	outDir = filepath.Dir(outDir)
-	files, _ := parser.GetCompileOrder(rootPath)
-	htmlPath := filepath.Join(filepath.Dir(rootPath), "index.html")
-	h := parseHtml(htmlPath)
-	outPath := rootPath[:len(rootPath)-len(filepath.Ext(rootPath))] + ".js"
-	appendScript(h, filepath.Base(outPath))
-
-	chunks := []chunk{}
-	errors := []parser.ParserError{}
-	for _, f := range files {
-		program, errs := parser.ParseFile(f.Path)
-		chunks = append(chunks, chunk{
-			path:    getOutPath(rootPath, f.Path, outDir),
-			Program: program,
-		})
-		errors = append(errors, errs...)
-	}
-
-	if len(errors) > 0 {
-		logErrors(errors)
-		return
-	}
-
-	emptyOutDir(outDir)
-	emitHtml(outDir, h)
-	std := emitter.CreateStdName(filepath.Dir(rootPath))
-	std = filepath.Join(outDir, std)
-	var flags emitter.StandardFlags
-	for _, chunk := range chunks {
-		flags |= writeChunk(chunk, std)
-	}
-	emitter.EmitStd(std, flags)
+// Returned when a command has already reported why it failed
+// (e.g. diagnostics with errors)
+var errReported = errors.New("failed")
+
+// Returned for invalid arguments
+type usageError struct{ message string }
+
+func (e usageError) Error() string { return e.message }
+
+func main() {
+	os.Exit(execute(os.Args[1:], os.Stderr))
 }
 
-func parseHtml(path string) *html.Node {
-	f, err := os.Open(path)
-	if err != nil {
-		panic(err)
+func execute(args []string, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		fmt.Fprint(os.Stdout, usage)
+		return exitOK
+	}
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "kiwi: unknown command '%v'\n\n%v", name, usage)
+		return exitUsage
 	}
-	defer f.Close()
 
-	doc, err := html.Parse(f)
-	if err != nil {
-		fmt.Println("Cannot parse index.html: ", err)
-		return nil
+	err := run(args[1:], stderr)
+	var u usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errReported):
+		return exitFailure
+	case errors.As(err, &u):
+		fmt.Fprintf(stderr, "kiwi %v: %v\nUsage: kiwi %v %v\n", name, u.message, name, commandUsages[name])
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "kiwi %v: %v\n", name, err)
+		return exitFailure
 	}
-	return doc
 }
 
-func appendScript(n *html.Node, outName string) {
-	h := findHtmlNode(n, func(n *html.Node) bool {
-		return n.Type == html.ElementNode && n.Data == "head"
-	})
-	if h == nil {
-		fmt.Println("Could not find head")
-		return
-	}
-	scriptNode := &html.Node{
-		Type:     html.ElementNode,
-		DataAtom: atom.Lookup([]byte("script")),
-		Data:     "script",
-		Attr: []html.Attribute{
-			{Key: "type", Val: "module"},
-			{Key: "crossorigin"},
-			{Key: "src", Val: outName},
-		},
-	}
-	h.AppendChild(scriptNode)
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: kiwi %v %v\n", name, commandUsages[name])
+		fs.PrintDefaults()
+	}
+	return fs
 }
 
-// TODO: remove recursivity (use ParentNode if no NextSibling, beware no to go to parent of starting node)
-func findHtmlNode(n *html.Node, predicate func(*html.Node) bool) *html.Node {
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if predicate(c) {
-			return c
+// Parse flags, which may appear before or after positional arguments.
+// Returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{err.Error()}
 		}
-		if n := findHtmlNode(c, predicate); n != nil {
-			return n
+		if fs.NArg() == 0 {
+			return positional, nil
 		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
-	return nil
 }
 
-func emitHtml(outDir string, n *html.Node) {
-	f, err := os.Create(filepath.Join(outDir, "index.html"))
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-
-	// TODO: handle error?
-	html.Render(f, n)
-	f.Sync()
+// Options shared by commands compiling a program
+type buildOptions struct {
+	entry        string // set with --entry, see entries
+	defaultEntry string // used if there is no entry in arguments or kiwi.json
+	entries      []string
+	outDir       string
+	projectDir   string // directory of kiwi.json, or the working directory
+	html         string
+	target       string // environment
+	language     string // ECMAScript version, set with the target
+	shebang      bool   // make entries executable with Node.js
+	sourceMap    bool
+	bundle       bool
+	treeShake    bool // drop declarations the program does not need
+	declarations bool // emit .d.ts files
+	minify       bool
+	format       string    // diagnostics format
+	stderr       io.Writer // where diagnostics are written
+	lint         lint.Config
+	diagnostics  []parser.Diagnostic // found in kiwi.json
 }
 
-func getOutPath(rootPath, filePath, outDir string) string {
-	relative, _ := filepath.Rel(filepath.Dir(rootPath), filePath)
-	outFile := filepath.Join(outDir, relative)
-	ext := len(filepath.Ext(outFile))
-	return outFile[:len(outFile)-ext] + ".js"
+func addBuildFlags(fs *flag.FlagSet, o *buildOptions) {
+	addEntryFlag(fs, o)
+	fs.StringVar(&o.outDir, "out", "dist", "output `directory`")
+	addTargetFlag(fs, o)
+	fs.BoolVar(&o.shebang, "shebang", false, "start entries with a Node.js shebang and make them executable (node target)")
+	fs.BoolVar(&o.sourceMap, "sourcemap", false, "emit source maps (not supported with --bundle or --minify)")
+	fs.BoolVar(&o.bundle, "bundle", false, "emit a single file per entry, with the files it uses")
+	fs.BoolVar(&o.treeShake, "tree-shake", false, "drop declarations the program does not use")
+	fs.BoolVar(&o.minify, "minify", false, "minify the output")
+	fs.BoolVar(&o.declarations, "declarations", false, "emit TypeScript declaration files (keeps all exports; not supported with --bundle)")
+	addFormatFlag(fs, &o.format)
 }
 
-func writeChunk(chunk chunk, stdPath string) emitter.StandardFlags {
-	f, err := os.Create(chunk.path)
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
+func addEntryFlag(fs *flag.FlagSet, o *buildOptions) {
+	fs.StringVar(&o.entry, "entry", "", "entry `file` of the program (or arguments, or kiwi.json's entries)")
+}
 
-	stdPath, _ = filepath.Rel(filepath.Dir(chunk.path), stdPath)
-	stdPath = stdPath[:len(stdPath)-3]
-	stdPath = filepath.ToSlash(stdPath)
-	if stdPath[0] != '.' {
-		stdPath = "./" + stdPath
-	}
-	output, flags := emitter.EmitProgram(chunk.Program)
-	_, err = f.WriteString(output)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if flags != emitter.NoFlag {
-		f.WriteString("import * as __ from \"" + stdPath + ".js\";\n")
-	}
-	f.Sync()
-	return flags
+func addTargetFlag(fs *flag.FlagSet, o *buildOptions) {
+	targets := strings.Join(append(slices.Clone(parser.Targets), parser.Languages...), ", ")
+	fs.StringVar(&o.target, "target", "browser", "target environment and/or language, like 'node,es2018': "+targets)
 }
 
-func logErrors(errors []parser.ParserError) {
-	for _, err := range errors {
-		line := err.Node.Loc().Start.Line
-		col := err.Node.Loc().Start.Col
-		msg := err.Text()
-		fmt.Printf("Error at line %v, col. %v: %v\n", line, col, msg)
-	}
+func addFormatFlag(fs *flag.FlagSet, format *string) {
+	fs.StringVar(format, "format", "text", "diagnostics format: text or json")
 }
 
-func emptyOutDir(dir string) {
-	d, err := os.Open(dir)
+// Complete options with the project's kiwi.json (for flags that were not set)
+// and validate them. Positional arguments are entries.
+func (o *buildOptions) load(fs *flag.FlagSet, args []string) error {
+	if o.format != "text" && o.format != "json" {
+		return usageError{fmt.Sprintf("unknown format '%v', expected 'text' or 'json'", o.format)}
+	}
+	config, diagnostics, err := parser.LoadConfig(".")
 	if err != nil {
-		panic(err)
+		return err
+	}
+	levels, lintDiagnostics := lint.ConfigFrom(config)
+	o.diagnostics = append(diagnostics, lintDiagnostics...)
+	o.lint = levels
+	if parser.HasErrors(o.diagnostics) {
+		if err := logDiagnostics(o.stderr, o.diagnostics, o.format); err != nil {
+			return err
+		}
+		return errReported
 	}
-	defer d.Close()
 
-	names, err := d.Readdirnames(-1)
-	if err != nil {
-		panic(err)
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["out"] {
+		o.outDir = config.OutDir
 	}
-	for _, name := range names {
-		err = os.RemoveAll(filepath.Join(dir, name))
+	environment, language := config.Target, config.Language
+	if set["target"] {
+		env, lang, err := parser.ParseTarget(o.target)
 		if err != nil {
-			panic(err)
+			return usageError{err.Error()}
+		}
+		if env != "" {
+			environment = env
+		}
+		if lang != "" {
+			language = lang
 		}
 	}
+	o.target, o.language = environment, language
+	o.html = config.Html
+	o.projectDir = "."
+	if config.Path != "" {
+		o.projectDir = filepath.Dir(config.Path)
+	}
+	o.entries = args
+	if o.entry != "" {
+		o.entries = append([]string{o.entry}, o.entries...)
+	}
+	if len(o.entries) == 0 {
+		o.entries = config.Entries
+	}
+	if len(o.entries) == 0 && o.defaultEntry != "" {
+		o.entries = []string{o.defaultEntry}
+	}
+
+	if len(o.entries) == 0 {
+		return usageError{"missing entry file"}
+	}
+	if o.html == "" {
+		o.html = filepath.Join(filepath.Dir(o.entries[0]), "index.html")
+	}
+	parser.SetTarget(o.target)
+	if o.shebang && o.target != "node" {
+		return usageError{"--shebang is only supported for the node target"}
+	}
+	if o.sourceMap && o.bundle {
+		return usageError{"source maps are not supported for bundles yet"}
+	}
+	if o.declarations && o.bundle {
+		return usageError{"declarations are not supported for bundles yet"}
+	}
+	if o.sourceMap && o.minify {
+		return usageError{"source maps are not supported for minified output yet"}
+	}
+	return nil
+}
+
+// Extension of output files. Node.js needs '.mjs' to load ES modules
+// without a package.json.
+func (o buildOptions) extension() string {
+	if o.target == "node" {
+		return ".mjs"
+	}
+	return ".js"
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Run the command in a new directory holding the given files. Names ending
+// with '/' are directories.
+func runInDir(t *testing.T, files map[string]string, args ...string) (int, string) {
+	dir := t.TempDir()
+	for name, content := range files {
+		var err error
+		if strings.HasSuffix(name, "/") {
+			err = os.Mkdir(dir+"/"+name, 0755)
+		} else {
+			err = os.WriteFile(dir+"/"+name, []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	stderr := &bytes.Buffer{}
+	code := execute(args, stderr)
+	return code, stderr.String()
+}
+
+func TestCheckMissingImport(t *testing.T) {
+	files := map[string]string{"main": "use * as lib from \"./missing\"\n_x :: lib.x\n"}
+	code, stderr := runInDir(t, files, "check", "main")
+	if code != exitFailure {
+		t.Fatalf("Expected exit code %v, got %v:\n%v", exitFailure, code, stderr)
+	}
+	if !strings.Contains(stderr, "Cannot resolve path to \"./missing\"") {
+		t.Fatalf("Expected the missing import to be reported, got:\n%v", stderr)
+	}
+}
+
+func TestCheckUnreadableImport(t *testing.T) {
+	files := map[string]string{
+		"main": "use * as lib from \"./lib\"\n_x :: lib.x\n",
+		"lib/": "",
+	}
+	code, stderr := runInDir(t, files, "check", "main")
+	if code != exitFailure {
+		t.Fatalf("Expected exit code %v, got %v:\n%v", exitFailure, code, stderr)
+	}
+	if !strings.Contains(stderr, "Cannot read file") {
+		t.Fatalf("Expected the unreadable import to be reported, got:\n%v", stderr)
+	}
+}
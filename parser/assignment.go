@@ -68,6 +68,7 @@ func (p *Parser) parseAssignment() Node {
 	formatMethodDef(p, a)
 	if operator.Kind() == Declare && isTypePattern(expr) {
 		p.error(expr, NonConstantTypeDeclaration)
+		p.fix("use '::' instead", operator.Loc(), "::")
 	}
 	return a
 }
@@ -174,6 +175,7 @@ func formatMethodDef(p *Parser, a *Assignment) {
 	}
 	if a.Operator.Kind() == Declare {
 		p.error(a, NonConstantMethodDeclaration)
+		p.fix("use '::' instead", a.Operator.Loc(), "::")
 	} else if a.Operator.Kind() != Define {
 		return
 	}
@@ -243,12 +245,17 @@ func reportDuplicatedFields(p *Parser, b *BracedExpression) {
 			declarations[name] = append(declarations[name], identifier)
 		}
 	}
-	for _, identifiers := range declarations {
+	for name, identifiers := range declarations {
 		if len(identifiers) == 1 {
 			continue
 		}
 		for _, identifier := range identifiers {
-			p.error(identifier, DuplicateIdentifier)
+			p.error(identifier, DuplicateIdentifier, name)
+			for _, other := range identifiers {
+				if other != identifier {
+					p.label(other.Loc(), "other declaration of '"+name+"'")
+				}
+			}
 		}
 	}
 }
@@ -285,8 +292,8 @@ func typeCheckAssignment(p *Parser, a *Assignment) {
 
 	switch pattern := a.Pattern.(type) {
 	case *Identifier:
-		if !pattern.typing.Extends(a.Value.Type()) {
-			p.error(pattern, CannotAssignType, pattern.typing, a.Value.Type())
+		if t := getContextualType(pattern.typing, a.Value); !pattern.typing.Extends(t) {
+			p.error(pattern, CannotAssignType, pattern.typing, t)
 		}
 		reportAssignmentToConstant(p, a)
 	case *TupleExpression:
@@ -302,10 +309,11 @@ func typeCheckAssignment(p *Parser, a *Assignment) {
 	case *UnaryExpression:
 		readDeref(p, pattern)
 		t := pattern.Operand.Type().(Ref)
-		if t.To.Extends(a.Value.Type()) {
+		received := getContextualType(t.To, a.Value)
+		if t.To.Extends(received) {
 			return
 		}
-		p.error(pattern, CannotAssignType, t.To, a.Value.Type())
+		p.error(pattern, CannotAssignType, t.To, received)
 	default:
 		p.error(a.Pattern, InvalidPattern)
 	}
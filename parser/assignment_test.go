@@ -217,7 +217,7 @@ func TestCheckAssignmentPattern(t *testing.T) {
 	}
 
 	scope := NewScope(ProgramScope)
-	scope.Add("module", Loc{}, Module{Object{Members: []ObjectMember{
+	scope.Add("module", Loc{}, Module{Object: Object{Members: []ObjectMember{
 		{"member", Number{}},
 	}}})
 	scope.Add("object", Loc{}, TypeAlias{
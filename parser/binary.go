@@ -58,7 +58,8 @@ func (expr *BinaryExpression) Type() ExpressionType {
 		LessEqual,
 		GreaterEqual,
 		Equal,
-		NotEqual:
+		NotEqual,
+		IsKeyword:
 		return Boolean{}
 	case Bang:
 		left := expr.Left.Type()
@@ -77,6 +78,8 @@ func (expr *BinaryExpression) Type() ExpressionType {
 		return Type{makeResultType(right, left)}
 	case Hash:
 		return getBinaryHashType(expr)
+	case BinaryOr:
+		return getBinaryUnionType(expr)
 	case InKeyword:
 		return Void{}
 	default:
@@ -98,6 +101,26 @@ func getBinaryHashType(expr *BinaryExpression) ExpressionType {
 	return Type{makeMapType(left, right)}
 }
 
+func getBinaryUnionType(expr *BinaryExpression) ExpressionType {
+	return Type{makeUnion(getUnionOperandType(expr.Left), getUnionOperandType(expr.Right))}
+}
+
+// Union operands are either types or value literals (e.g. '"light" | "dark"')
+func getUnionOperandType(operand Expression) ExpressionType {
+	if operand == nil {
+		return Invalid{}
+	}
+	if isType(operand) {
+		return operand.Type().(Type).Value
+	}
+	if literal, ok := Unwrap(operand).(*Literal); ok {
+		if t, ok := literal.literalType(); ok {
+			return t
+		}
+	}
+	return Invalid{}
+}
+
 /******************************
  *  PARSING HELPER FUNCTIONS  *
  ******************************/
@@ -129,7 +152,7 @@ func parseRHS(p *Parser, fallback func(p *Parser) Expression) Expression {
 	return right
 }
 func parseBinaryType(p *Parser) Expression {
-	return parseBinary(p, []TokenKind{Bang, Hash}, parseBinaryFallback)
+	return parseBinary(p, []TokenKind{Bang, Hash, BinaryOr}, parseBinaryFallback)
 }
 func parseBinaryFallback(p *Parser) Expression { return p.parseUnaryExpression() }
 func parseLogicalOr(p *Parser) Expression {
@@ -139,7 +162,7 @@ func parseLogicalAnd(p *Parser) Expression {
 	return parseBinary(p, []TokenKind{LogicalAnd}, parseEquality)
 }
 func parseEquality(p *Parser) Expression {
-	return parseBinary(p, []TokenKind{Equal, NotEqual}, parseComparison)
+	return parseBinary(p, []TokenKind{Equal, NotEqual, IsKeyword}, parseComparison)
 }
 func parseComparison(p *Parser) Expression {
 	return parseBinary(p, []TokenKind{Less, LessEqual, GreaterEqual, Greater}, parseAddition)
@@ -167,7 +190,7 @@ func (b *BinaryExpression) typeCheck(p *Parser) {
 		b.Left.typeCheck(p)
 	}
 	if b.Right != nil {
-		b.Right.typeCheck(p)
+		typeCheckRightOperand(p, b)
 	}
 	switch b.Operator.Kind() {
 	case
@@ -192,13 +215,32 @@ func (b *BinaryExpression) typeCheck(p *Parser) {
 		Equal,
 		NotEqual:
 		p.typeCheckComparisonExpression(b.Left, b.Right)
+	case IsKeyword:
+		p.typeCheckTypeTest(b.Left, b.Right)
 	case Bang, Hash:
 		checkBinaryType(p, b.Left, b.Right)
+	case BinaryOr:
+		checkUnionType(p, b.Left, b.Right)
 	default:
 		panic(fmt.Sprintf("operator '%v' not implemented", b.Operator.Kind()))
 	}
 }
 
+// In logical expressions, the right operand is only evaluated depending on
+// the left one, so types tested in the left operand can be refined
+// (e.g. 'x is number && x > 0').
+func typeCheckRightOperand(p *Parser, b *BinaryExpression) {
+	switch b.Operator.Kind() {
+	case LogicalAnd, LogicalOr:
+		p.pushScope(NewScope(BlockScope))
+		narrowCondition(p.scope, b.Left, b.Operator.Kind() == LogicalAnd)
+		b.Right.typeCheck(p)
+		p.dropScope()
+	default:
+		b.Right.typeCheck(p)
+	}
+}
+
 func (p *Parser) typeCheckLogicalExpression(left Expression, right Expression) {
 	if left != nil && !(Boolean{}).Extends(left.Type()) {
 		p.error(left, BooleanExpected, left.Type())
@@ -212,13 +254,36 @@ func (p *Parser) typeCheckComparisonExpression(left Expression, right Expression
 	if left == nil || right == nil {
 		return
 	}
-	leftType := left.Type()
-	rightType := right.Type()
-	if !Match(leftType, rightType) {
+	leftType := getContextualType(right.Type(), left)
+	rightType := getContextualType(left.Type(), right)
+	if !Match(leftType, rightType) && !overlaps(leftType, rightType) {
 		dummy := &BinaryExpression{Left: left, Right: right}
 		p.error(dummy, MismatchedTypes, leftType, rightType)
 	}
 }
+
+// check expressions like 'value is Type'
+func (p *Parser) typeCheckTypeTest(left Expression, right Expression) {
+	if left == nil || right == nil {
+		return
+	}
+	if isType(left) {
+		p.error(left, ValueExpected)
+		return
+	}
+	t, ok := right.Type().(Type)
+	if !ok {
+		p.error(right, TypeExpected)
+		return
+	}
+	if isTraitType(t.Value) {
+		p.error(right, TraitTypeTest, t.Value)
+		return
+	}
+	if !left.Type().Extends(t.Value) {
+		p.error(right, NotInUnion, t.Value, left.Type())
+	}
+}
 func (p *Parser) typeCheckConcatExpression(left Expression, right Expression) {
 	var leftType ExpressionType
 	if left != nil {
@@ -264,3 +329,20 @@ func checkBinaryType(p *Parser, left Expression, right Expression) {
 		p.error(right, TypeExpected)
 	}
 }
+func checkUnionType(p *Parser, left Expression, right Expression) {
+	for _, operand := range []Expression{left, right} {
+		if operand != nil {
+			if _, ok := getUnionOperandType(operand).(Invalid); ok && !isType(operand) {
+				p.error(operand, TypeExpected)
+			}
+		}
+	}
+}
+
+// Literal types can be compared to their base type (e.g. '"light"' and string)
+func overlaps(a ExpressionType, b ExpressionType) bool {
+	if !containsLiteralType(a) && !containsLiteralType(b) {
+		return false
+	}
+	return a.Extends(b) || b.Extends(a)
+}
@@ -123,6 +123,40 @@ func TestCheckBinaryExpression(t *testing.T) {
 			wantError:    false,
 			expectedType: "(string!number)",
 		},
+		{
+			name: "valid union type",
+			expr: &BinaryExpression{
+				Left:     &Literal{token{kind: StringKeyword}},
+				Operator: token{kind: BinaryOr},
+				Right:    &Literal{token{kind: NumberKeyword}},
+			},
+			wantError:    false,
+			expectedType: "(string | number)",
+		},
+		{
+			name: "union with literal",
+			expr: &BinaryExpression{
+				Left:     &Literal{token{kind: StringKeyword}},
+				Operator: token{kind: BinaryOr},
+				Right:    &Literal{literal{kind: NumberLiteral, value: "42"}},
+			},
+			wantError:    false,
+			expectedType: "(string | 42)",
+		},
+		{
+			name: "union with non-type",
+			expr: &BinaryExpression{
+				Left:     &Literal{token{kind: StringKeyword}},
+				Operator: token{kind: BinaryOr},
+				Right: &BinaryExpression{
+					Left:     &Literal{literal{kind: NumberLiteral, value: "1"}},
+					Operator: token{kind: Add},
+					Right:    &Literal{literal{kind: NumberLiteral, value: "2"}},
+				},
+			},
+			wantError:    true,
+			expectedType: "(string)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -311,3 +345,22 @@ func TestCheckComparisonExpression(t *testing.T) {
 		)
 	}
 }
+
+func TestCheckLiteralComparison(t *testing.T) {
+	mode := Union{[]ExpressionType{
+		LiteralType{String{}, "\"light\""},
+		LiteralType{String{}, "\"dark\""},
+	}}
+
+	parser := MakeParser(strings.NewReader("mode == \"light\""))
+	parser.scope.Add("mode", Loc{}, mode)
+	expr := parser.parseBinaryExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+
+	parser = MakeParser(strings.NewReader("mode == \"blue\""))
+	parser.scope.Add("mode", Loc{}, mode)
+	expr = parser.parseBinaryExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 1)
+}
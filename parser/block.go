@@ -23,9 +23,13 @@ func (b *Block) getChildren() []Node {
 
 func (b *Block) typeCheck(p *Parser) {
 	b.scope = p.scope
-	for i := range b.Statements {
-		b.Statements[i].typeCheck(p)
+	flow := flowGraph{}
+	for _, statement := range b.Statements {
+		statement.typeCheck(p)
+		flow.add(statement)
+		flow.narrow(p.scope)
 	}
+	reportUnreachableCode(p, b.Statements, flow)
 	if len(b.Statements) == 0 {
 		return
 	}
@@ -50,6 +54,9 @@ func (b *Block) Type() ExpressionType {
 	if len(b.Statements) == 0 {
 		return Void{}
 	}
+	if slices.IndexFunc(b.Statements, IsExiting) != -1 {
+		return Never{}
+	}
 	last := b.Statements[len(b.Statements)-1]
 	expr, ok := last.(Expression)
 	if !ok {
@@ -71,7 +78,6 @@ func (p *Parser) parseBlock() *Block {
 		}
 		p.DiscardLineBreaks()
 	}
-	reportUnreachableCode(p, statements)
 
 	if p.Peek().Kind() != RightBrace {
 		p.error(&Literal{p.Peek()}, RightBraceExpected)
@@ -81,24 +87,13 @@ func (p *Parser) parseBlock() *Block {
 	return &Block{statements, p.scope, Loc{start, end}}
 }
 
-func reportUnreachableCode(p *Parser, statements []Node) {
-	var foundExit, foundUnreachable bool
-	var unreachable Loc
-	for _, statement := range statements {
-		if foundExit {
-			foundUnreachable = true
-			if unreachable.Start == (Position{}) {
-				unreachable.Start = statement.Loc().Start
-			}
-			unreachable.End = statement.Loc().End
-		}
-		if _, ok := statement.(*Exit); ok {
-			foundExit = true
-		}
-	}
-	if foundUnreachable {
-		p.error(&Block{loc: unreachable}, UnreachableCode)
+func reportUnreachableCode(p *Parser, statements []Node, flow flowGraph) {
+	i := flow.firstUnreachable()
+	if i == -1 {
+		return
 	}
+	loc := Loc{statements[i].Loc().Start, statements[len(statements)-1].Loc().End}
+	p.error(&Block{loc: loc}, UnreachableCode)
 }
 
 func (b *Block) Scope() *Scope {
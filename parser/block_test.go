@@ -55,9 +55,24 @@ func TestUnreachableCode(t *testing.T) {
 	str += "}"
 	parser := MakeParser(strings.NewReader(str))
 	parser.pushScope(NewScope(FunctionScope))
-	parser.parseBlock()
+	block := parser.parseBlock()
+	block.typeCheck(parser)
 
 	if len(parser.errors) != 1 {
 		t.Fatalf("Expected 1 error, got %#v", parser.errors)
 	}
 }
+
+func TestUnreachableCodeAfterTodo(t *testing.T) {
+	str := "{\n"
+	str += "    todo()\n"
+	str += "    42\n"
+	str += "}"
+	parser := MakeParser(strings.NewReader(str))
+	block := parser.parseBlock()
+	block.typeCheck(parser)
+	testParserErrors(t, parser, 1)
+	if _, ok := block.Type().(Never); !ok {
+		t.Fatalf("Expected never, got %v", block.Type().Text())
+	}
+}
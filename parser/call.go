@@ -57,7 +57,7 @@ func typeCheckFunctionCall(p *Parser, c *CallExpression) {
 
 	params := function.Params.Elements
 	typeCheckFunctionArguments(p, c.Args.Expr.(*TupleExpression), params)
-	validateArgumentsNumber(p, c.Args.Expr.(*TupleExpression), params)
+	validateArgumentsNumber(p, c.Args.Expr.(*TupleExpression), params, function.Optional)
 	t, ok := function.Returned.build(p.scope, nil)
 	if !ok {
 		p.error(c, MissingTypeArgs)
@@ -88,13 +88,15 @@ func typeCheckFunctionArgument(p *Parser, expected *ExpressionType, received Exp
 		p.error(received, ExpressionExpected)
 		return
 	}
-	t := received.Type()
+	t := getContextualType(*expected, received)
 	built, ok := (*expected).build(p.scope, t)
 	*expected = built
 	if !ok {
 		p.error(received, MissingTypeArgs)
 	}
-	if !built.Extends(t) {
+	if isUncheckedEventName(built, t) {
+		p.error(received, UncheckedEventName)
+	} else if !built.Extends(t) {
 		p.error(received, CannotAssignType, built, t)
 	}
 }
@@ -108,12 +110,13 @@ func typeCheckFunctionHOFArgument(p *Parser, expected ExpressionType, received *
 	}
 }
 
-// Make sure that the correct number of arguments were passed to the function
-func validateArgumentsNumber(p *Parser, args *TupleExpression, params []ExpressionType) {
+// Make sure that the correct number of arguments were passed to the function,
+// where the last optional params can be left out
+func validateArgumentsNumber(p *Parser, args *TupleExpression, params []ExpressionType, optional int) {
 	if len(params) < len(args.Elements) {
 		p.error(args, TooManyElements, len(params), len(args.Elements))
 	}
-	if len(params) > len(args.Elements) {
-		p.error(args, MissingElements, len(params), len(args.Elements))
+	if len(params)-optional > len(args.Elements) {
+		p.error(args, MissingElements, len(params)-optional, len(args.Elements))
 	}
 }
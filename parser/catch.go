@@ -40,43 +40,39 @@ func (c *CatchExpression) typeCheck(p *Parser) {
 	c.Left.typeCheck(p)
 	p.pushScope(NewScope(BlockScope))
 	defer p.dropScope()
-	happy, err, ok := getCatchTypes(c.Left)
-	if !ok {
-		p.error(c, UnneededCatch)
-	}
+	happy, err := getCatchTypes(c.Left)
 	if c.Identifier != nil {
 		p.scope.Add(c.Identifier.Text(), c.Identifier.Loc(), err)
 	}
 	c.typeCheckBody(p, happy)
 }
 
-// returns (Left, Right, ok), with CatchExpression being:
+// returns (Left, Right), with CatchExpression being:
 // Left catch (identifier Right) {}
-func getCatchTypes(result Expression) (ExpressionType, ExpressionType, bool) {
+func getCatchTypes(result Expression) (ExpressionType, ExpressionType) {
 	if result == nil {
-		return Invalid{}, Invalid{}, true
+		return Invalid{}, Invalid{}
 	}
 	alias, ok := result.Type().(TypeAlias)
 	if !ok || alias.Name != "!" {
-		t := result.Type()
-		return t, Invalid{}, t == (Invalid{})
+		return result.Type(), Invalid{}
 	}
 	happy := alias.Ref.(Sum).getMember("Ok")
 	err := alias.Ref.(Sum).getMember("Err")
-	return happy, err, true
+	return happy, err
 }
 func (c *CatchExpression) typeCheckBody(p *Parser, happy ExpressionType) {
 	if c.Body == nil {
 		return
 	}
 	c.Body.typeCheck(p)
-	if !IsExiting(c.Body) && !happy.Extends(c.Body.Type()) {
+	if !happy.Extends(c.Body.Type()) {
 		p.error(c.Body.reportedNode(), CannotAssignType, happy, c.Body.Type())
 	}
 }
 
 func (c *CatchExpression) Type() ExpressionType {
-	t, _, _ := getCatchTypes(c.Left)
+	t, _ := getCatchTypes(c.Left)
 	return t
 }
 
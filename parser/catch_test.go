@@ -166,8 +166,9 @@ func TestCheckCatchExpressionNotResult(t *testing.T) {
 	}
 	expr.typeCheck(parser)
 
-	if len(parser.errors) != 1 {
-		t.Fatalf("Expected 1 error, got %v: %#v", len(parser.errors), parser.errors)
+	// unneeded catch is reported by the linter
+	if len(parser.errors) != 0 {
+		t.Fatalf("Expected no errors, got %v: %#v", len(parser.errors), parser.errors)
 	}
 	if _, ok := expr.Type().(Number); !ok {
 		t.Fatalf("Expected number")
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type CompletionKind uint8
+
+const (
+	VariableCompletion CompletionKind = iota
+	MemberCompletion
+	ConstructorCompletion
+	ModuleCompletion
+)
+
+type Completion struct {
+	Label string
+	Kind  CompletionKind
+	Type  ExpressionType
+}
+
+// Identifier inserted at the cursor when completing after a dot,
+// so that the receiver can be parsed.
+const completionPlaceholder = "_complete"
+
+var wordBeforeCursor = regexp.MustCompile(`[_a-zA-Z][a-zA-Z0-9]*$`)
+var useSourceBeforeCursor = regexp.MustCompile(`^\s*use\s.*\sfrom\s+"([^"]*)$`)
+
+// Get completion candidates at the given position of the source.
+// The source is expected to be incomplete at the cursor, like 'value.'
+func Complete(source string, path string, pos Position) []Completion {
+	lines := strings.Split(source, "\n")
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return []Completion{}
+	}
+	line := lines[pos.Line-1]
+	before := line[:min(max(pos.Col-1, 0), len(line))]
+
+	if m := useSourceBeforeCursor.FindStringSubmatch(before); m != nil {
+		return completeModuleNames(m[1])
+	}
+
+	prefix := wordBeforeCursor.FindString(before)
+	start := Position{pos.Line, pos.Col - len(prefix)}
+	if strings.HasSuffix(before[:len(before)-len(prefix)], ".") {
+		if prefix == "" {
+			lines[pos.Line-1] = before + completionPlaceholder + line[len(before):]
+		}
+		source = strings.Join(lines, "\n")
+		program, _ := ParseDocument(strings.NewReader(source), path)
+		return filterCompletions(completeMembers(program, start), prefix)
+	}
+
+	program, _ := ParseDocument(strings.NewReader(source), path)
+	isLineStart := strings.TrimSpace(before[:len(before)-len(prefix)]) == ""
+	if m := findEnclosingMatch(program, start); m != nil && isLineStart {
+		return filterCompletions(getConstructors(m.Value.Type()), prefix)
+	}
+	return filterCompletions(completeVariables(program, start), prefix)
+}
+
+func completeModuleNames(prefix string) []Completion {
+	completions := []Completion{}
+	for _, name := range libNames {
+		module, _ := getLib(name)
+		completions = append(completions, Completion{name, ModuleCompletion, module})
+	}
+	return filterCompletions(completions, prefix)
+}
+
+// pos is the position of the property being typed
+func completeMembers(program Program, pos Position) []Completion {
+	node, ancestors := program.NodeAt(pos)
+	if node == nil || len(ancestors) == 0 {
+		return []Completion{}
+	}
+	access, ok := ancestors[len(ancestors)-1].(*PropertyAccessExpression)
+	if !ok || access.Property != node || access.Expr == nil {
+		return []Completion{}
+	}
+	return getMembers(access.Expr.Type())
+}
+
+// Get the members which can be accessed on a value of the given type
+func getMembers(t ExpressionType) []Completion {
+	completions := []Completion{}
+	add := func(name string, typing ExpressionType) {
+		completions = append(completions, Completion{name, MemberCompletion, typing})
+	}
+	addObjectMembers := func(o Object) {
+		for _, members := range [][]ObjectMember{o.Embedded, o.Members, o.Defaults} {
+			for _, member := range members {
+				add(member.Name, member.Type)
+			}
+		}
+	}
+
+	switch t := deref(t).(type) {
+	case Type:
+		return getConstructors(t.Value)
+	case TypeAlias:
+		for name, method := range t.Methods {
+			add(name, method)
+		}
+		switch ref := t.Ref.(type) {
+		case Object:
+			addObjectMembers(ref)
+		case Trait:
+			for name, member := range ref.Members {
+				add(name, member)
+			}
+		case Newtype:
+			add("value", ref.Underlying)
+		}
+	case Object:
+		addObjectMembers(t)
+	case Module:
+		addObjectMembers(t.Object)
+	case List:
+		for _, name := range []string{"has", "get", "set"} {
+			add(name, getListMethod(t, name))
+		}
+	}
+	return completions
+}
+
+func getConstructors(t ExpressionType) []Completion {
+	completions := []Completion{}
+	alias, ok := t.(TypeAlias)
+	if !ok {
+		return completions
+	}
+	sum, ok := alias.Ref.(Sum)
+	if !ok {
+		return completions
+	}
+	for name := range sum.Members {
+		completions = append(completions, Completion{name, ConstructorCompletion, sum.getMember(name)})
+	}
+	return completions
+}
+
+func findEnclosingMatch(program Program, pos Position) *MatchExpression {
+	node, ancestors := program.NodeAt(pos)
+	if m, ok := node.(*MatchExpression); ok {
+		return m
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		m, ok := ancestors[i].(*MatchExpression)
+		if !ok {
+			continue
+		}
+		for _, c := range m.Cases {
+			if c.Pattern == node {
+				return m
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// Get the variables visible at the given position, walking up the scopes
+func completeVariables(program Program, pos Position) []Completion {
+	scope := program.scope
+	node, ancestors := program.NodeAt(pos)
+	for _, n := range append(ancestors, node) {
+		if b, ok := n.(*Block); ok && b.scope != nil {
+			scope = b.scope
+		}
+	}
+
+	completions := []Completion{}
+	seen := map[string]bool{}
+	for s := scope; s != nil; s = s.outer {
+		for name, v := range s.variables {
+			if seen[name] || name == completionPlaceholder {
+				continue
+			}
+			seen[name] = true
+			if v.declaredAt != (Loc{}) && !v.declaredAt.Start.isBefore(pos) {
+				continue
+			}
+			completions = append(completions, Completion{name, VariableCompletion, v.Typing})
+		}
+	}
+	return completions
+}
+
+// Keep completions starting with prefix, sorted by label
+func filterCompletions(completions []Completion, prefix string) []Completion {
+	filtered := []Completion{}
+	for _, c := range completions {
+		if strings.HasPrefix(c.Label, prefix) {
+			filtered = append(filtered, c)
+		}
+	}
+	slices.SortFunc(filtered, func(a, b Completion) int {
+		return strings.Compare(a.Label, b.Label)
+	})
+	return filtered
+}
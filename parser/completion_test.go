@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"testing"
+)
+
+func getCompletionLabels(completions []Completion) []string {
+	labels := make([]string, len(completions))
+	for i := range completions {
+		labels[i] = completions[i].Label
+	}
+	return labels
+}
+
+func testCompletion(t *testing.T, source string, pos Position, expected []string) []Completion {
+	completions := Complete(source, "", pos)
+	labels := getCompletionLabels(completions)
+	if len(labels) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, labels)
+	}
+	for i := range expected {
+		if labels[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, labels)
+		}
+	}
+	return completions
+}
+
+func TestCompleteObjectMembers(t *testing.T) {
+	source := "_Point :: { x number, y number }\n"
+	source += "_p := _Point{ x: 1, y: 2 }\n"
+	source += "_p."
+	completions := testCompletion(t, source, Position{3, 4}, []string{"x", "y"})
+	if _, ok := completions[0].Type.(Number); !ok {
+		t.Fatalf("Expected number, got %#v", completions[0].Type)
+	}
+}
+
+func TestCompleteMembersWithPrefix(t *testing.T) {
+	source := "_list := []number{1, 2}\n"
+	source += "_list.ha"
+	testCompletion(t, source, Position{2, 9}, []string{"has"})
+}
+
+func TestCompleteModuleMembers(t *testing.T) {
+	source := "use * as io from \"io\"\n"
+	source += "io."
+	completions := Complete(source, "", Position{2, 4})
+	if len(completions) == 0 {
+		t.Fatalf("Expected members of io")
+	}
+}
+
+func TestCompleteVariables(t *testing.T) {
+	source := "_a := 1\n"
+	source += "_f :: (param number) => {\n"
+	source += "    _b := param\n"
+	source += "    \n"
+	source += "    _c := 2\n"
+	source += "}\n"
+	source += "_d := 3"
+	completions := Complete(source, "", Position{4, 5})
+	labels := getCompletionLabels(completions)
+	for _, expected := range []string{"_a", "param", "_b", "None"} {
+		if !contains(labels, expected) {
+			t.Fatalf("Expected %v in %v", expected, labels)
+		}
+	}
+	for _, unexpected := range []string{"_c", "_d"} {
+		if contains(labels, unexpected) {
+			t.Fatalf("Expected %v not to be in %v", unexpected, labels)
+		}
+	}
+}
+
+func TestCompleteMatchConstructors(t *testing.T) {
+	source := "_f :: (option ?number) => {\n"
+	source += "    match option {\n"
+	source += "        So\n"
+	source += "    }\n"
+	source += "}"
+	testCompletion(t, source, Position{3, 11}, []string{"Some"})
+}
+
+func TestCompleteModuleNames(t *testing.T) {
+	testCompletion(t, "use * as lib from \"d", Position{1, 21}, []string{"dom"})
+}
+
+func contains(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
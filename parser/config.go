@@ -0,0 +1,472 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ConfigName = "kiwi.json"
+
+var Targets = []string{"browser", "node"}
+
+// ECMAScript versions that output can be lowered to, oldest first
+var Languages = []string{"es2015", "es2018", "es2020", "esnext"}
+
+// Split a target like "node,es2018" into its environment and language.
+// Parts that are not specified are empty.
+func ParseTarget(target string) (environment string, language string, err error) {
+	for _, part := range strings.Split(target, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case slices.Contains(Targets, part) && environment == "":
+			environment = part
+		case slices.Contains(Languages, part) && language == "":
+			language = part
+		default:
+			expected := strings.Join(append(slices.Clone(Targets), Languages...), ", ")
+			return "", "", fmt.Errorf("unknown target '%v', expected one of: %v", part, expected)
+		}
+	}
+	return environment, language, nil
+}
+
+// Target environment of all parsed files, instead of the one of their project
+// (e.g. set with a command line flag). Empty if there is none.
+var targetOverride string
+
+func SetTarget(target string) { targetOverride = target }
+
+// Target environment of the file at the given path
+func getTarget(path string) string {
+	if targetOverride != "" {
+		return targetOverride
+	}
+	return projectConfig(filepath.Dir(path)).Target
+}
+
+// Project configuration, read from a kiwi.json file.
+// Paths are relative to the current directory, or absolute.
+type Config struct {
+	Path     string            // path of the kiwi.json file, empty if there is none
+	Entries  []string          // entry files
+	OutDir   string            // output directory
+	Html     string            // HTML template, next to the first entry if empty
+	Target   string            // target environment
+	Language string            // ECMAScript version of the output
+	Lint     map[string]string // lint levels by rule name
+	Use      map[string]string // path alias prefixes, like "@app/": "./src/"
+	Libs     []string          // extra roots to look for libraries
+	locs     map[string]Loc    // location of properties' values, by key (e.g. "lint.rule")
+}
+
+// Default configuration of a project in the given directory
+func DefaultConfig(dir string) Config {
+	return Config{
+		Entries:  []string{},
+		OutDir:   filepath.Join(dir, "dist"),
+		Target:   "browser",
+		Language: "esnext",
+		Lint:     map[string]string{},
+		Use:      map[string]string{},
+		Libs:     []string{},
+		locs:     map[string]Loc{},
+	}
+}
+
+// Directory containing the configuration file
+func (c Config) Dir() string {
+	return filepath.Dir(c.Path)
+}
+
+// Location of a property in the configuration file (e.g. "target", "lint.rule").
+// Used to report invalid values.
+func (c Config) Location(key string) Location {
+	return Location{Path: c.Path, Loc: c.locs[key]}
+}
+
+// Find the configuration file of the project containing the given directory,
+// looking in parent directories, and load it.
+// If there is no file, the default configuration is returned.
+// Errors in the file are returned as diagnostics.
+func LoadConfig(dir string) (Config, []Diagnostic, error) {
+	path, err := FindConfig(dir)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if path == "" {
+		return DefaultConfig(dir), nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	config, diagnostics := ParseConfig(path, content)
+	return config, diagnostics, nil
+}
+
+// Find the closest kiwi.json in the given directory or its parents.
+// Returns an empty path if there is none.
+// The path is relative if the given directory is.
+func FindConfig(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		path := filepath.Join(d, ConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			if filepath.IsAbs(dir) {
+				return path, nil
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Rel(cwd, path)
+		}
+		if filepath.Dir(d) == d {
+			return "", nil
+		}
+	}
+}
+
+type configProperty struct {
+	key    Loc
+	value  Loc
+	offset int // offset of the value in the file
+	raw    json.RawMessage
+}
+
+// Parse and validate the content of the configuration file at the given path
+func ParseConfig(path string, content []byte) (Config, []Diagnostic) {
+	c := DefaultConfig(filepath.Dir(path))
+	c.Path = path
+	r := configReader{config: &c, content: content, diagnostics: []Diagnostic{}}
+	keys, properties := r.readProperties(0, len(content))
+	for _, key := range keys {
+		r.readProperty(key, properties[key])
+	}
+	return c, r.diagnostics
+}
+
+type configReader struct {
+	config      *Config
+	content     []byte
+	diagnostics []Diagnostic
+}
+
+func (r *configReader) report(severity Severity, loc Loc, message string, args ...any) *Diagnostic {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Severity: severity,
+		Code:     "config",
+		Message:  fmt.Sprintf(message, args...),
+		Location: Location{Path: r.config.Path, Loc: loc},
+	})
+	return &r.diagnostics[len(r.diagnostics)-1]
+}
+
+// Read the properties of the object between the given offsets, keeping track
+// of their locations. Returns keys in order of appearance.
+func (r *configReader) readProperties(start int, end int) ([]string, map[string]configProperty) {
+	keys := []string{}
+	properties := map[string]configProperty{}
+	decoder := json.NewDecoder(bytes.NewReader(r.content[start:end]))
+	offset := func() int { return start + int(decoder.InputOffset()) }
+	begin := r.skip(start, "")
+	if token, err := decoder.Token(); err != nil {
+		r.reportSyntaxError(err, start, offset())
+		return keys, properties
+	} else if token != json.Delim('{') {
+		r.report(ErrorSeverity, r.loc(begin, offset()), "object expected")
+		return keys, properties
+	}
+	for decoder.More() {
+		keyStart := r.skip(offset(), ",")
+		token, err := decoder.Token()
+		if err != nil {
+			r.reportSyntaxError(err, start, offset())
+			return keys, properties
+		}
+		key := token.(string)
+		keyEnd := offset()
+		valueStart := r.skip(keyEnd, ":")
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			r.reportSyntaxError(err, start, offset())
+			return keys, properties
+		}
+		property := configProperty{
+			key:    r.loc(keyStart, keyEnd),
+			value:  r.loc(valueStart, offset()),
+			offset: valueStart,
+			raw:    raw,
+		}
+		if _, ok := properties[key]; ok {
+			r.report(ErrorSeverity, property.key, "duplicate property '%v'", key)
+			continue
+		}
+		keys = append(keys, key)
+		properties[key] = property
+	}
+	if _, err := decoder.Token(); err != nil {
+		r.reportSyntaxError(err, start, offset())
+	}
+	return keys, properties
+}
+
+func (r *configReader) reportSyntaxError(err error, start int, offset int) {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		offset = start + int(syntax.Offset) - 1
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		offset = len(r.content)
+	}
+	offset = max(0, min(offset, len(r.content)))
+	message := strings.TrimPrefix(err.Error(), "json: ")
+	r.report(ErrorSeverity, r.loc(offset, min(offset+1, len(r.content))), "invalid JSON: %v", message)
+}
+
+// Skip whitespace and the given separators, starting at offset
+func (r *configReader) skip(offset int, separators string) int {
+	for offset < len(r.content) {
+		b := r.content[offset]
+		if !strings.ContainsRune(" \t\r\n"+separators, rune(b)) {
+			break
+		}
+		offset++
+	}
+	return offset
+}
+
+func (r *configReader) loc(start int, end int) Loc {
+	return Loc{Start: r.position(start), End: r.position(end)}
+}
+
+func (r *configReader) position(offset int) Position {
+	before := r.content[:offset]
+	line := bytes.Count(before, []byte{'\n'}) + 1
+	col := offset - bytes.LastIndexByte(before, '\n')
+	return Position{Line: line, Col: col}
+}
+
+var configKeys = []string{"entries", "outDir", "html", "target", "lint", "use", "libs"}
+
+func (r *configReader) readProperty(key string, property configProperty) {
+	c := r.config
+	c.locs[key] = property.value
+	dir := c.Dir()
+	switch key {
+	case "entries":
+		if entries, ok := r.readStrings(property, "entries"); ok {
+			for _, entry := range entries {
+				c.Entries = append(c.Entries, filepath.Join(dir, entry))
+			}
+		}
+	case "outDir":
+		if s, ok := r.readString(property); ok {
+			c.OutDir = filepath.Join(dir, s)
+		}
+	case "html":
+		if s, ok := r.readString(property); ok {
+			c.Html = filepath.Join(dir, s)
+		}
+	case "target":
+		s, ok := r.readString(property)
+		if !ok {
+			return
+		}
+		environment, language, err := ParseTarget(s)
+		if err != nil {
+			r.report(ErrorSeverity, property.value, "%v", err)
+			return
+		}
+		if environment != "" {
+			c.Target = environment
+		}
+		if language != "" {
+			c.Language = language
+		}
+	case "lint":
+		if _, levels, ok := r.readObject(property, "lint"); ok {
+			c.Lint = levels
+		}
+	case "use":
+		prefixes, aliases, ok := r.readObject(property, "use")
+		if !ok {
+			return
+		}
+		for _, prefix := range prefixes {
+			path := aliases[prefix]
+			loc := c.locs["use."+prefix]
+			if !strings.HasSuffix(prefix, "/") {
+				r.report(ErrorSeverity, loc, "alias '%v' should end with '/'", prefix)
+				continue
+			}
+			if !IsLocalPath(path) {
+				r.report(ErrorSeverity, loc, "aliased path '%v' should start with './', '../' or '/'", path)
+				continue
+			}
+			c.Use[prefix] = filepath.Join(dir, path)
+		}
+	case "libs":
+		if libs, ok := r.readStrings(property, "libs"); ok {
+			for _, lib := range libs {
+				c.Libs = append(c.Libs, filepath.Join(dir, lib))
+			}
+		}
+	default:
+		d := r.report(WarningSeverity, property.key, "unknown property '%v'", key)
+		if closest, ok := findClosest(key, configKeys); ok {
+			d.Notes = []string{fmt.Sprintf("did you mean '%v'?", closest)}
+			d.Fixes = []Fix{{
+				Message: fmt.Sprintf("replace with '%v'", closest),
+				Edits:   []TextEdit{{Path: c.Path, Loc: property.key, NewText: strconv.Quote(closest)}},
+			}}
+		}
+	}
+}
+
+func (r *configReader) readString(property configProperty) (string, bool) {
+	var s string
+	if err := json.Unmarshal(property.raw, &s); err != nil {
+		r.report(ErrorSeverity, property.value, "string expected")
+		return "", false
+	}
+	if s == "" {
+		r.report(ErrorSeverity, property.value, "non-empty string expected")
+		return "", false
+	}
+	return s, true
+}
+
+func (r *configReader) readStrings(property configProperty, key string) ([]string, bool) {
+	var list []string
+	if err := json.Unmarshal(property.raw, &list); err != nil {
+		r.report(ErrorSeverity, property.value, "list of strings expected")
+		return nil, false
+	}
+	for i, s := range list {
+		if s == "" {
+			r.report(ErrorSeverity, property.value, "'%v' cannot contain empty strings (at index %v)", key, i)
+			return nil, false
+		}
+	}
+	return list, true
+}
+
+// Read an object of strings, keeping track of its properties' locations.
+// Also returns keys in order of appearance.
+func (r *configReader) readObject(property configProperty, key string) ([]string, map[string]string, bool) {
+	if len(property.raw) == 0 || property.raw[0] != '{' {
+		r.report(ErrorSeverity, property.value, "object expected")
+		return nil, nil, false
+	}
+	keys, properties := r.readProperties(property.offset, property.offset+len(property.raw))
+
+	valid := []string{}
+	values := map[string]string{}
+	for _, k := range keys {
+		p := properties[k]
+		r.config.locs[key+"."+k] = p.value
+		var s string
+		if err := json.Unmarshal(p.raw, &s); err != nil {
+			r.report(ErrorSeverity, p.value, "string expected")
+			continue
+		}
+		valid = append(valid, k)
+		values[k] = s
+	}
+	return valid, values, true
+}
+
+type cachedConfig struct {
+	config  Config
+	modTime time.Time
+}
+
+var configs = map[string]cachedConfig{}
+
+// Configuration of the project containing the given directory.
+// Invalid properties are ignored, since they are reported when building.
+func projectConfig(dir string) Config {
+	path, err := FindConfig(dir)
+	if err != nil || path == "" {
+		return DefaultConfig(dir)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return DefaultConfig(dir)
+	}
+	if cached, ok := configs[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.config
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DefaultConfig(dir)
+	}
+	config, _ := ParseConfig(path, content)
+	configs[path] = cachedConfig{config, info.ModTime()}
+	return config
+}
+
+// Resolve the file used by a 'use' directive in the given file.
+// Sources are either local paths, aliased paths (see Config.Use), or files in
+// the project's library roots.
+// Sources can also refer to declaration files ('x' for 'x.d.ts').
+// Returns false for standard libs and sources that cannot be resolved.
+func ResolvePath(from string, source string) (string, bool) {
+	if IsLocalPath(source) {
+		return withDeclarations(filepath.Join(filepath.Dir(from), source)), true
+	}
+	if slices.Contains(libNames, source) {
+		return "", false
+	}
+	config := projectConfig(filepath.Dir(from))
+	if path, ok := config.resolveAlias(source); ok {
+		return withDeclarations(path), true
+	}
+	for _, root := range config.Libs {
+		path := withDeclarations(filepath.Join(root, source))
+		if fileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Use the declaration file describing the path if there is no file at path
+func withDeclarations(path string) string {
+	if !fileExists(path) && fileExists(path+".d.ts") {
+		return path + ".d.ts"
+	}
+	return path
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Longest matching alias wins
+func (c Config) resolveAlias(source string) (string, bool) {
+	var prefix string
+	for p := range c.Use {
+		if strings.HasPrefix(source, p) && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return "", false
+	}
+	return filepath.Join(c.Use[prefix], source[len(prefix):]), true
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	content := `{
+    "entries": ["main", "admin"],
+    "outDir": "out",
+    "use": {"@app/": "./src/"},
+    "libs": ["lib"]
+}`
+	config, diagnostics := ParseConfig(filepath.Join("project", ConfigName), []byte(content))
+	if len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+	if len(config.Entries) != 2 || config.Entries[1] != filepath.Join("project", "admin") {
+		t.Fatalf("Expected entries relative to the config, got %#v", config.Entries)
+	}
+	if config.OutDir != filepath.Join("project", "out") || config.Target != "browser" {
+		t.Fatalf("Unexpected config: %#v", config)
+	}
+	resolved, ok := config.resolveAlias("@app/views/home")
+	if !ok || resolved != filepath.Join("project", "src", "views", "home") {
+		t.Fatalf("Expected alias to be resolved, got '%v'", resolved)
+	}
+}
+
+func TestConfigSchemaErrors(t *testing.T) {
+	content := `{
+    "entries": "main",
+    "target": "deno",
+    "outdir": "out"
+}`
+	_, diagnostics := ParseConfig(ConfigName, []byte(content))
+	if len(diagnostics) != 3 {
+		t.Fatalf("Expected 3 diagnostics, got %#v", diagnostics)
+	}
+	expected := Loc{Start: Position{2, 16}, End: Position{2, 22}}
+	if diagnostics[0].Location.Loc != expected {
+		t.Fatalf("Expected %v, got %v", expected, diagnostics[0].Location.Loc)
+	}
+	if diagnostics[2].Severity != WarningSeverity || len(diagnostics[2].Fixes) != 1 {
+		t.Fatalf("Expected a warning suggesting 'outDir', got %#v", diagnostics[2])
+	}
+}
+
+func TestConfigSyntaxError(t *testing.T) {
+	_, diagnostics := ParseConfig(ConfigName, []byte("{\n    \"entries\": [\n"))
+	if len(diagnostics) != 1 || diagnostics[0].Location.Loc.Start.Line != 3 {
+		t.Fatalf("Expected a syntax error at the end, got %#v", diagnostics)
+	}
+}
+
+func TestResolveAliasedPath(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"use": {"@app/": "./src/"}, "libs": ["vendor"]}`
+	os.WriteFile(filepath.Join(dir, ConfigName), []byte(config), 0644)
+	os.MkdirAll(filepath.Join(dir, "vendor"), 0755)
+	os.WriteFile(filepath.Join(dir, "vendor", "math"), []byte(""), 0644)
+	main := filepath.Join(dir, "main")
+
+	if path, ok := ResolvePath(main, "@app/lib"); !ok || path != filepath.Join(dir, "src", "lib") {
+		t.Fatalf("Expected aliased path, got '%v'", path)
+	}
+	if path, ok := ResolvePath(main, "math"); !ok || path != filepath.Join(dir, "vendor", "math") {
+		t.Fatalf("Expected library path, got '%v'", path)
+	}
+	if _, ok := ResolvePath(main, "io"); ok {
+		t.Fatalf("Expected std lib not to resolve to a file")
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	environment, language, err := ParseTarget("node,es2018")
+	if err != nil || environment != "node" || language != "es2018" {
+		t.Fatalf("Expected node and es2018, got '%v', '%v', %v", environment, language, err)
+	}
+	environment, language, err = ParseTarget("es2015")
+	if err != nil || environment != "" || language != "es2015" {
+		t.Fatalf("Expected es2015 only, got '%v', '%v', %v", environment, language, err)
+	}
+	if _, _, err := ParseTarget("node,browser"); err == nil {
+		t.Fatalf("Expected an error for two environments")
+	}
+}
+
+func TestConfigTargetLanguage(t *testing.T) {
+	config, diagnostics := ParseConfig(ConfigName, []byte(`{"target": "es2020"}`))
+	if len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+	if config.Target != "browser" || config.Language != "es2020" {
+		t.Fatalf("Expected browser and es2020, got '%v', '%v'", config.Target, config.Language)
+	}
+}
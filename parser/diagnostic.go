@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Severity int
+
+const (
+	ErrorSeverity Severity = iota
+	WarningSeverity
+	InfoSeverity
+)
+
+func (s Severity) String() string {
+	switch s {
+	case ErrorSeverity:
+		return "error"
+	case WarningSeverity:
+		return "warning"
+	case InfoSeverity:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// A secondary span attached to a diagnostic
+type Label struct {
+	Location
+	Message string `json:"message"`
+}
+
+// A machine-applicable suggestion
+type Fix struct {
+	Message string     `json:"message"`
+	Edits   []TextEdit `json:"edits"`
+}
+
+// A checker message ready to be displayed
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Location Location `json:"location"` // primary span
+	Labels   []Label  `json:"labels,omitempty"`
+	Notes    []string `json:"notes,omitempty"`
+	Fixes    []Fix    `json:"fixes,omitempty"`
+}
+
+// Stable code for the error kind, like 'K0042'
+func (p ParserError) Code() string {
+	return fmt.Sprintf("K%04d", p.Kind)
+}
+
+// Only errors should prevent the program from being emitted
+func (p ParserError) Severity() Severity {
+	switch p.Kind {
+	case UnreachableCode, UncheckedEventName:
+		return WarningSeverity
+	default:
+		return ErrorSeverity
+	}
+}
+
+// Build the diagnostic for an error found in the file at the given path
+func (p ParserError) Diagnostic(path string) Diagnostic {
+	d := Diagnostic{
+		Severity: p.Severity(),
+		Code:     p.Code(),
+		Message:  p.Text(),
+		Location: Location{Path: path},
+		Notes:    p.Notes,
+	}
+	if p.Node != nil {
+		d.Location.Loc = p.Node.Loc()
+	}
+	for _, label := range p.Labels {
+		if label.Path == "" {
+			label.Path = path
+		}
+		d.Labels = append(d.Labels, label)
+	}
+	for _, fix := range p.Fixes {
+		edits := make([]TextEdit, len(fix.Edits))
+		for i, edit := range fix.Edits {
+			if edit.Path == "" {
+				edit.Path = path
+			}
+			edits[i] = edit
+		}
+		d.Fixes = append(d.Fixes, Fix{fix.Message, edits})
+	}
+	return d
+}
+
+// Check if any of the diagnostics should fail the build
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == ErrorSeverity {
+			return true
+		}
+	}
+	return false
+}
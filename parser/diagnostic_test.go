@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticCode(t *testing.T) {
+	err := ParserError{Node: &Block{}, Kind: DuplicateIdentifier, Complements: [2]interface{}{"a"}}
+	code := err.Diagnostic("main").Code
+	if len(code) != 5 || code[0] != 'K' {
+		t.Fatalf("Expected code like 'K0042', got '%v'", code)
+	}
+}
+
+func TestDiagnosticSeverity(t *testing.T) {
+	err := ParserError{Node: &Block{}, Kind: UnreachableCode}
+	diagnostic := err.Diagnostic("main")
+	if diagnostic.Severity != WarningSeverity {
+		t.Fatalf("Expected unreachable code to be a warning, got %v", diagnostic.Severity)
+	}
+	if HasErrors([]Diagnostic{diagnostic}) {
+		t.Fatalf("Expected warnings not to fail the build")
+	}
+}
+
+func TestDiagnosticLabels(t *testing.T) {
+	_, errors := ParseProgram(strings.NewReader("Point :: { x number, x number }"), "main")
+	if len(errors) == 0 {
+		t.Fatalf("Expected errors, got none")
+	}
+	diagnostic := errors[0].Diagnostic("main")
+	if diagnostic.Message != "Duplicate identifier 'x'" {
+		t.Fatalf("Unexpected message: %v", diagnostic.Message)
+	}
+	if len(diagnostic.Labels) != 1 {
+		t.Fatalf("Expected 1 label, got %#v", diagnostic.Labels)
+	}
+	if diagnostic.Labels[0].Path != "main" || diagnostic.Labels[0].Loc == diagnostic.Location.Loc {
+		t.Fatalf("Expected label on the other declaration, got %#v", diagnostic.Labels[0])
+	}
+}
+
+func TestDiagnosticFix(t *testing.T) {
+	_, errors := ParseProgram(strings.NewReader("Point := { x number }"), "main")
+	if len(errors) == 0 {
+		t.Fatalf("Expected errors, got none")
+	}
+	diagnostic := errors[0].Diagnostic("main")
+	if len(diagnostic.Fixes) != 1 {
+		t.Fatalf("Expected 1 fix, got %#v", diagnostic.Fixes)
+	}
+	expected := TextEdit{"main", Loc{Position{1, 7}, Position{1, 9}}, "::"}
+	if edits := diagnostic.Fixes[0].Edits; len(edits) != 1 || edits[0] != expected {
+		t.Fatalf("Expected %v, got %v", expected, edits)
+	}
+}
+
+func TestDiagnosticJSON(t *testing.T) {
+	diagnostic := Diagnostic{
+		Severity: WarningSeverity,
+		Code:     "K0001",
+		Message:  "message",
+		Location: Location{"main", Loc{Position{1, 1}, Position{1, 2}}},
+	}
+	b, _ := json.Marshal(diagnostic)
+	expected := `{"severity":"warning","code":"K0001","message":"message","location":{"path":"main","loc":{"start":{"line":1,"col":1},"end":{"line":1,"col":2}}}}`
+	if string(b) != expected {
+		t.Fatalf("Expected %v, got %v", expected, string(b))
+	}
+}
+
+func TestDiagnosticMissingType(t *testing.T) {
+	err := ParserError{Node: &Block{}, Kind: PropertyDoesNotExist, Complements: [2]interface{}{"x", nil}}
+	if text := err.Text(); text != "Property 'x' does not exist on type invalid" {
+		t.Fatalf("Unexpected message: %v", text)
+	}
+}
@@ -0,0 +1,1113 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TypeScript declaration files (.d.ts) describe JavaScript libraries, which
+// kiwi programs can use like other modules.
+//
+// Only a pragmatic subset is read: interfaces, type aliases, classes,
+// functions and variables, with primitive, literal, list, tuple, union,
+// object and function types. Other constructs are reported with a warning,
+// and read as opaque types (which can be passed around but not inspected).
+
+func IsDeclarationFile(path string) bool {
+	return strings.HasSuffix(path, ".d.ts")
+}
+
+// Read the declaration file at path and make it available to files using it
+func ParseDeclarationFile(path string) (Module, []Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Module{}, nil, err
+	}
+	defer file.Close()
+
+	module, diagnostics := ParseDeclarations(file, path)
+	ExportDeclarations(path, module)
+	return module, diagnostics, nil
+}
+
+// Make the module read from a declaration file available to files using it
+func ExportDeclarations(path string, module Module) {
+	filesExports[path] = module
+}
+
+// Is the name declared by the declaration file at path only a type (which
+// doesn't exist at runtime)?
+func IsTypeOnly(path string, name string) bool {
+	return slices.Contains(filesExports[path].typeOnly, name)
+}
+
+// Get the params of the constructor of a class read from a declaration
+// file, as the object which builds its instances
+func GetDeclaredConstructor(alias TypeAlias) (Object, bool) {
+	params, ok := filesExports[alias.From].constructors[alias.Name]
+	return params, ok
+}
+
+// Read the public declarations of a declaration file.
+// Unsupported constructs are reported as warnings.
+func ParseDeclarations(reader io.Reader, path string) (Module, []Diagnostic) {
+	content, err := io.ReadAll(reader)
+	r := declarationReader{
+		path:         path,
+		tokens:       tokenizeDeclarations(string(content)),
+		types:        map[string][]int{},
+		values:       map[string]int{},
+		classes:      map[string]bool{},
+		constructors: map[string]Object{},
+		locs:         map[string]Loc{},
+		resolved:     map[string]TypeAlias{},
+		resolving:    map[string]bool{},
+		diagnostics:  []Diagnostic{},
+	}
+	if err != nil {
+		r.warn(Loc{}, "cannot read declarations: %v", err)
+	}
+	for r.peek().kind != dtsEOF {
+		r.readStatement()
+	}
+
+	module := Module{
+		Object:       newObject(),
+		path:         path,
+		scope:        NewScope(ProgramScope),
+		constructors: r.constructors,
+	}
+	for _, name := range r.names {
+		if name[0] == '_' {
+			continue
+		}
+		var t ExpressionType
+		if _, ok := r.types[name]; ok {
+			t = Type{r.resolveType(name)}
+			if _, ok := r.values[name]; !ok && !r.classes[name] {
+				module.typeOnly = append(module.typeOnly, name)
+			}
+		} else {
+			t = r.readValue(r.values[name])
+		}
+		module.addMember(name, t)
+		module.scope.Add(name, r.locs[name], t)
+	}
+	return module, r.diagnostics
+}
+
+type dtsTokenKind uint8
+
+const (
+	dtsEOF dtsTokenKind = iota
+	dtsName
+	dtsString
+	dtsTemplate
+	dtsNumber
+	dtsPunctuation
+)
+
+type dtsToken struct {
+	kind    dtsTokenKind
+	text    string
+	loc     Loc
+	newline bool // preceded by a line break
+}
+
+// Split declarations into names, literals and punctuation.
+// Comments are dropped.
+func tokenizeDeclarations(content string) []dtsToken {
+	tokens := []dtsToken{}
+	position := Position{Line: 1, Col: 1}
+	advance := func(n int, i int) {
+		for _, c := range content[i : i+n] {
+			if c == '\n' {
+				position.Line++
+				position.Col = 1
+			} else {
+				position.Col++
+			}
+		}
+	}
+	newline := false
+	for i := 0; i < len(content); {
+		c := content[i]
+		start := position
+		n := 1
+		kind := dtsPunctuation
+		switch {
+		case c == '\n':
+			newline = true
+			advance(1, i)
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			advance(1, i)
+			i++
+			continue
+		case strings.HasPrefix(content[i:], "//"):
+			n = strings.IndexByte(content[i:], '\n')
+			if n == -1 {
+				n = len(content) - i
+			}
+			advance(n, i)
+			i += n
+			continue
+		case strings.HasPrefix(content[i:], "/*"):
+			n = strings.Index(content[i+2:], "*/")
+			if n == -1 {
+				n = len(content) - i
+			} else {
+				n += 4
+			}
+			newline = newline || strings.Contains(content[i:i+n], "\n")
+			advance(n, i)
+			i += n
+			continue
+		case c == '"' || c == '\'' || c == '`':
+			for n < len(content)-i && content[i+n] != c {
+				if content[i+n] == '\\' {
+					n++
+				}
+				n++
+			}
+			n = min(n+1, len(content)-i)
+			kind = dtsString
+			if c == '`' {
+				kind = dtsTemplate
+			}
+		case c >= '0' && c <= '9':
+			for n < len(content)-i && isDeclarationNameByte(content[i+n]) || n < len(content)-i && content[i+n] == '.' {
+				n++
+			}
+			kind = dtsNumber
+		case isDeclarationNameByte(c):
+			for n < len(content)-i && isDeclarationNameByte(content[i+n]) {
+				n++
+			}
+			kind = dtsName
+		case strings.HasPrefix(content[i:], "=>"):
+			n = 2
+		case strings.HasPrefix(content[i:], "..."):
+			n = 3
+		}
+		advance(n, i)
+		tokens = append(tokens, dtsToken{
+			kind:    kind,
+			text:    content[i : i+n],
+			loc:     Loc{Start: start, End: position},
+			newline: newline,
+		})
+		newline = false
+		i += n
+	}
+	return append(tokens, dtsToken{kind: dtsEOF, loc: Loc{Start: position, End: position}, newline: true})
+}
+
+func isDeclarationNameByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// Keywords starting top-level statements, used to find where statements
+// end when semicolons are left out
+var declarationKeywords = []string{
+	"abstract", "class", "const", "declare", "default", "enum", "export",
+	"function", "global", "import", "interface", "let", "module",
+	"namespace", "type", "var",
+}
+
+type declarationReader struct {
+	path         string
+	tokens       []dtsToken
+	i            int                  // index of the next token
+	names        []string             // declared names, in order
+	types        map[string][]int     // declarations of types (interfaces can be merged), by name
+	values       map[string]int       // declarations of values, by name
+	classes      map[string]bool      // types which are also values
+	constructors map[string]Object    // params of classes' constructors, by name
+	locs         map[string]Loc       // where names are declared
+	resolved     map[string]TypeAlias // types already read
+	resolving    map[string]bool      // types being read, which can refer to themselves
+	generics     []map[string]bool    // type params in scope
+	self         []string             // interfaces and classes being read, for 'this'
+	diagnostics  []Diagnostic
+}
+
+func (r *declarationReader) peek() dtsToken { return r.tokens[r.i] }
+func (r *declarationReader) peekAt(offset int) dtsToken {
+	return r.tokens[min(r.i+offset, len(r.tokens)-1)]
+}
+func (r *declarationReader) next() dtsToken {
+	t := r.tokens[r.i]
+	if t.kind != dtsEOF {
+		r.i++
+	}
+	return t
+}
+
+// Consume the next token if it has the given text
+func (r *declarationReader) accept(text string) bool {
+	if r.peek().text == text && r.peek().kind != dtsString {
+		r.next()
+		return true
+	}
+	return false
+}
+
+func (r *declarationReader) expect(text string) {
+	if !r.accept(text) {
+		t := r.peek()
+		r.warn(t.loc, "'%v' expected", text)
+	}
+}
+
+func (r *declarationReader) warn(loc Loc, message string, args ...any) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Severity: WarningSeverity,
+		Code:     "declarations",
+		Message:  fmt.Sprintf(message, args...),
+		Location: Location{Path: r.path, Loc: loc},
+	})
+}
+
+// Location from the token at index start to the last consumed one
+func (r *declarationReader) span(start int) Loc {
+	end := max(r.i-1, start)
+	return Loc{Start: r.tokens[start].loc.Start, End: r.tokens[end].loc.End}
+}
+
+// A type that cannot be read, reported with a warning
+func (r *declarationReader) opaque(start int, message string, args ...any) TypeAlias {
+	r.warn(r.span(start), message+", using an opaque type", args...)
+	return TypeAlias{Name: "unknown", From: r.path, Ref: newObject()}
+}
+
+// Skip tokens until the end of the current statement
+func (r *declarationReader) skipStatement() {
+	depth := 0
+	for {
+		t := r.peek()
+		switch {
+		case t.kind == dtsEOF:
+			return
+		case depth == 0 && t.text == ";":
+			r.next()
+			return
+		case depth == 0 && t.newline && t.kind == dtsName && slices.Contains(declarationKeywords, t.text):
+			return
+		}
+		r.next()
+		depth += getDepthChange(t)
+	}
+}
+
+// Skip tokens until the end of the current member of an object type
+func (r *declarationReader) skipMember() {
+	depth := 0
+	for {
+		t := r.peek()
+		switch {
+		case t.kind == dtsEOF:
+			return
+		case depth == 0 && (t.text == ";" || t.text == ","):
+			r.next()
+			return
+		case depth == 0 && getDepthChange(t) < 0:
+			return
+		case depth == 0 && t.newline && r.i > 0 && !isContinued(r.tokens[r.i-1], t):
+			return
+		}
+		r.next()
+		depth += getDepthChange(t)
+	}
+}
+
+func getDepthChange(t dtsToken) int {
+	if t.kind != dtsPunctuation {
+		return 0
+	}
+	switch t.text {
+	case "(", "[", "{":
+		return 1
+	case ")", "]", "}":
+		return -1
+	}
+	return 0
+}
+
+// Does a line break between the tokens leave the type unfinished?
+func isContinued(prev dtsToken, next dtsToken) bool {
+	switch prev.text {
+	case ":", "|", "&", "=>", ",", "(", "[", "{", "<", "=":
+		return true
+	}
+	switch next.text {
+	case "|", "&", "=>", ")", "]", ">":
+		return true
+	}
+	return false
+}
+
+func (r *declarationReader) readStatement() {
+	start := r.peek()
+	switch start.text {
+	case ";":
+		r.next()
+	case "export":
+		r.next()
+		switch next := r.peek(); next.text {
+		case "default", "{", "*", "=", "as":
+			r.warn(next.loc, "'export %v' is not supported, ignoring it", next.text)
+			r.skipStatement()
+		default:
+			r.readStatement()
+		}
+	case "declare", "abstract":
+		r.next()
+		r.readStatement()
+	case "interface", "type", "class":
+		r.next()
+		if name, ok := r.readDeclaredName(); ok {
+			r.types[name] = append(r.types[name], r.i-2)
+			r.classes[name] = r.classes[name] || start.text == "class"
+		}
+		r.skipStatement()
+	case "function", "const", "let", "var":
+		r.next()
+		name, ok := r.readDeclaredName()
+		if _, exists := r.values[name]; ok && exists {
+			r.warn(r.tokens[r.i-1].loc, "overloads are not supported, using the first signature of '%v'", name)
+		} else if ok {
+			r.values[name] = r.i - 2
+		}
+		r.skipStatement()
+	case "import":
+		r.warn(start.loc, "imports are not supported, ignoring it")
+		r.skipStatement()
+	default:
+		if start.kind == dtsName {
+			r.warn(start.loc, "'%v' declarations are not supported, ignoring it", start.text)
+		} else {
+			r.warn(start.loc, "unexpected '%v'", start.text)
+		}
+		r.next()
+		r.skipStatement()
+	}
+}
+
+// Read the name of a declaration, and remember where it is declared
+func (r *declarationReader) readDeclaredName() (string, bool) {
+	t := r.next()
+	if t.kind != dtsName {
+		r.warn(t.loc, "name expected")
+		return "", false
+	}
+	if _, ok := r.locs[t.text]; !ok {
+		r.names = append(r.names, t.text)
+		r.locs[t.text] = t.loc
+	}
+	return t.text, true
+}
+
+// Read the type of a value declared at the given token index
+func (r *declarationReader) readValue(index int) ExpressionType {
+	r.i = index
+	keyword := r.next()
+	r.next() // name
+	if keyword.text == "function" {
+		return r.readSignature(":")
+	}
+	if r.accept(":") {
+		return r.readType()
+	}
+	return Invalid{}
+}
+
+// Read the declarations of a type, once
+func (r *declarationReader) resolveType(name string) TypeAlias {
+	if alias, ok := r.resolved[name]; ok {
+		return alias
+	}
+	if r.resolving[name] {
+		// reference to itself, while reading its own declaration
+		return TypeAlias{Name: name, From: r.path, Ref: newObject()}
+	}
+	r.resolving[name] = true
+	i, generics, self := r.i, r.generics, r.self
+	r.generics, r.self = nil, nil
+
+	alias := TypeAlias{Name: name, From: r.path}
+	object := newObject()
+	for _, index := range r.types[name] {
+		r.i = index
+		keyword := r.next()
+		r.next() // name
+		params := r.readTypeParams()
+		if alias.Params == nil {
+			alias.Params = params
+		}
+		if keyword.text == "type" {
+			r.expect("=")
+			start := r.i
+			t := r.readType()
+			if isOpaque(t) {
+				t = newObject()
+			}
+			alias.Ref = t
+			if len(r.types[name]) > 1 {
+				r.warn(r.span(start), "type '%v' is declared more than once", name)
+			}
+		} else {
+			class := keyword.text == "class"
+			inherited := r.readHeritage(&object, &alias)
+			r.self = append(r.self, name)
+			_, constructor := r.readObjectBody(&object, &alias, class)
+			r.self = r.self[:len(r.self)-1]
+			if class {
+				r.constructors[name] = getConstructorParams(constructor, inherited)
+			}
+		}
+		r.generics = r.generics[:len(r.generics)-1]
+	}
+	if alias.Ref == nil {
+		alias.Ref = object
+	}
+	fillSelfReferences(alias)
+
+	r.i, r.generics, r.self = i, generics, self
+	delete(r.resolving, name)
+	r.resolved[name] = alias
+	return alias
+}
+
+// Methods referring to their own type (like fluent methods returning 'this')
+// were read with a placeholder, which is replaced once the type is known.
+// Members keep the placeholder, since types are built from their members.
+func fillSelfReferences(alias TypeAlias) {
+	replace := func(t ExpressionType) ExpressionType {
+		if placeholder, ok := t.(TypeAlias); ok && placeholder.Name == alias.Name && placeholder.From == alias.From {
+			return alias
+		}
+		return t
+	}
+	for name, method := range alias.Methods {
+		f, ok := method.(Function)
+		if !ok {
+			continue
+		}
+		f.Returned = replace(f.Returned)
+		for i, param := range f.Params.Elements {
+			f.Params.Elements[i] = replace(param)
+		}
+		alias.Methods[name] = f
+	}
+}
+
+func isOpaque(t ExpressionType) bool {
+	alias, ok := t.(TypeAlias)
+	return ok && alias.Name == "unknown"
+}
+
+// Read type params like '<T extends U = V>' and bring them in scope (even
+// if there are none). Constraints and defaults are ignored.
+func (r *declarationReader) readTypeParams() []Generic {
+	params := []Generic{}
+	names := map[string]bool{}
+	if r.accept("<") {
+		for r.peek().kind == dtsName {
+			name := r.next().text
+			params = append(params, Generic{Name: name})
+			names[name] = true
+			if r.accept("extends") {
+				r.readType()
+			}
+			if r.accept("=") {
+				r.readType()
+			}
+			if !r.accept(",") {
+				break
+			}
+		}
+		r.expect(">")
+	}
+	r.generics = append(r.generics, names)
+	return params
+}
+
+// Members and methods of extended types are copied, classes' 'implements'
+// clauses are ignored.
+// Returns the constructor's params of the extended class, if any.
+func (r *declarationReader) readHeritage(object *Object, alias *TypeAlias) Object {
+	inherited := newObject()
+	if r.accept("extends") {
+		for {
+			start := r.i
+			t := r.readPostfixType()
+			parent, ok := t.(TypeAlias)
+			ref, isObject := parent.Ref.(Object)
+			if ok && isObject {
+				addMissingMembers(object, ref)
+				for name, method := range parent.Methods {
+					alias.registerMethod(name, method)
+				}
+				if params, ok := r.constructors[parent.Name]; ok && parent.From == r.path {
+					inherited = params
+				}
+			} else if !isOpaque(t) {
+				r.warn(r.span(start), "only object types can be extended")
+			}
+			if !r.accept(",") {
+				break
+			}
+		}
+	}
+	if r.accept("implements") {
+		for {
+			r.readPostfixType()
+			if !r.accept(",") {
+				break
+			}
+		}
+	}
+	return inherited
+}
+
+// Instances of classes are built with their constructor's params, as an
+// object where optional params are defaults. Classes without constructor
+// use the one they inherit.
+func getConstructorParams(constructor *dtsMember, inherited Object) Object {
+	if constructor == nil {
+		return inherited
+	}
+	o := newObject()
+	for i, param := range constructor.params {
+		if i < constructor.required {
+			o.addMember(param.Name, param.Type)
+		} else {
+			o.addDefault(param.Name, param.Type)
+		}
+	}
+	return o
+}
+
+func addMissingMembers(object *Object, from Object) {
+	for _, member := range from.Members {
+		if _, ok := object.GetOwned(member.Name); !ok {
+			object.addMember(member.Name, member.Type)
+		}
+	}
+	for _, member := range from.Defaults {
+		if _, ok := object.GetOwned(member.Name); !ok {
+			object.addDefault(member.Name, member.Type)
+		}
+	}
+}
+
+// Read the body of an interface, a class or an object type.
+// Optional properties are read as defaults, since they can be left out when
+// building the object. Methods are registered on the alias if there is
+// one, otherwise they are regular members.
+// Returns the type of values of the index signature and the constructor of
+// classes, if any.
+func (r *declarationReader) readObjectBody(object *Object, alias *TypeAlias, class bool) (ExpressionType, *dtsMember) {
+	var index ExpressionType
+	var constructor *dtsMember
+	r.expect("{")
+	for r.peek().kind != dtsEOF && r.peek().text != "}" {
+		start := r.i
+		member, ok := r.readMember()
+		switch {
+		case !ok:
+		case member.name == "[index]":
+			index = member.typing
+		case member.modifiers["private"] || member.modifiers["protected"] || member.modifiers["set"]:
+		case class && member.name == "constructor":
+			if constructor == nil {
+				constructor = &member
+			}
+		case member.modifiers["static"]:
+			r.warn(r.span(start), "static members are not supported, ignoring '%v'", member.name)
+		case member.modifiers["method"] && alias != nil:
+			if _, ok := alias.Methods[member.name]; !ok {
+				alias.registerMethod(member.name, member.typing)
+			}
+		case member.modifiers["optional"]:
+			object.addDefault(member.name, member.typing)
+		default:
+			object.addMember(member.name, member.typing)
+		}
+		if !r.accept(";") {
+			r.accept(",")
+		}
+	}
+	r.expect("}")
+	return index, constructor
+}
+
+type dtsMember struct {
+	name      string
+	typing    ExpressionType
+	modifiers map[string]bool // including 'optional' and 'method'
+	params    []ObjectMember  // params of constructors
+	required  int             // number of required params of constructors
+}
+
+var memberModifiers = []string{
+	"abstract", "accessor", "declare", "get", "override", "private",
+	"protected", "public", "readonly", "set", "static",
+}
+
+// Read a member like 'name?: Type' or 'method(): Type'.
+// Index signatures like '[key: string]: Type' are named '[index]'.
+func (r *declarationReader) readMember() (dtsMember, bool) {
+	modifiers := map[string]bool{}
+	for slices.Contains(memberModifiers, r.peek().text) && (isMemberName(r.peekAt(1)) || r.peekAt(1).text == "[") {
+		modifiers[r.next().text] = true
+	}
+	start := r.i
+	t := r.peek()
+	switch {
+	case t.text == "[":
+		if r.peekAt(1).kind == dtsName && r.peekAt(2).text == ":" {
+			r.next()
+			r.next()
+			r.next()
+			key := r.readType()
+			r.expect("]")
+			r.accept("?")
+			r.expect(":")
+			value := r.readType()
+			if key != (String{}) {
+				r.warn(r.span(start), "only string keys are supported in index signatures, ignoring it")
+				return dtsMember{}, false
+			}
+			return dtsMember{name: "[index]", typing: value, modifiers: modifiers}, true
+		}
+		r.skipMember()
+		r.warn(r.span(start), "computed and mapped members are not supported, ignoring it")
+		return dtsMember{}, false
+	case t.text == "(" || t.text == "<" || t.text == "new" && r.peekAt(1).text == "(":
+		r.skipMember()
+		r.warn(r.span(start), "call and construct signatures are not supported, ignoring it")
+		return dtsMember{}, false
+	case !isMemberName(t):
+		r.next()
+		r.skipMember()
+		r.warn(r.span(start), "member expected")
+		return dtsMember{}, false
+	}
+
+	r.next()
+	name := t.text
+	if t.kind == dtsString {
+		name, _ = strconv.Unquote(toDoubleQuoted(t.text))
+	}
+	modifiers["optional"] = r.accept("?")
+	var typing ExpressionType = Invalid{}
+	switch {
+	case name == "constructor" && r.peek().text == "(":
+		params, required := r.readParams()
+		return dtsMember{name: name, modifiers: modifiers, params: params, required: required}, true
+	case r.peek().text == "(" || r.peek().text == "<":
+		f := r.readSignature(":")
+		if modifiers["get"] {
+			typing = f.Returned
+		} else {
+			typing = f
+			modifiers["method"] = true
+		}
+	case r.accept(":"):
+		typing = r.readType()
+	}
+	if t.kind == dtsString && !isValidName(name) {
+		r.warn(r.span(start), "'%v' is not a valid name, ignoring it", name)
+		return dtsMember{}, false
+	}
+	return dtsMember{name: name, typing: typing, modifiers: modifiers}, true
+}
+
+func isMemberName(t dtsToken) bool {
+	return t.kind == dtsName || t.kind == dtsString || t.kind == dtsNumber
+}
+
+func isValidName(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isDeclarationNameByte(name[i]) || name[i] == '$' {
+			return false
+		}
+	}
+	return true
+}
+
+// Single-quoted strings can be unquoted as double-quoted ones
+func toDoubleQuoted(text string) string {
+	if text[0] != '\'' {
+		return text
+	}
+	inner := strings.ReplaceAll(text[1:len(text)-1], "\\'", "'")
+	return "\"" + strings.ReplaceAll(inner, "\"", "\\\"") + "\""
+}
+
+// Read a signature like '<T>(a: T): T' (with ':' as arrow) or
+// '<T>(a: T) => T' (with '=>').
+// Signatures without return type return any value.
+func (r *declarationReader) readSignature(arrow string) Function {
+	typeParams := r.readTypeParams()
+	defer func() { r.generics = r.generics[:len(r.generics)-1] }()
+	params, required := r.readParams()
+	types := make([]ExpressionType, len(params))
+	for i, param := range params {
+		types[i] = param.Type
+	}
+	var returned ExpressionType = Invalid{}
+	if r.accept(arrow) {
+		returned = r.readReturnedType()
+	}
+	return Function{
+		TypeParams: typeParams,
+		Params:     &Tuple{types},
+		Returned:   returned,
+		Optional:   len(params) - required,
+	}
+}
+
+// Optional params can be left out of calls when they are trailing ones,
+// 'this' params are ignored, and so are rest params (kiwi functions have a
+// fixed number of params).
+// Returns the params and the number of params before the first optional one.
+func (r *declarationReader) readParams() ([]ObjectMember, int) {
+	params := []ObjectMember{}
+	required := -1
+	r.expect("(")
+	for r.peek().kind != dtsEOF && r.peek().text != ")" {
+		start := r.i
+		rest := r.accept("...")
+		name := r.next()
+		if name.text == "{" || name.text == "[" {
+			r.i--
+			r.skipBalanced()
+		}
+		optional := r.accept("?")
+		var t ExpressionType = Invalid{}
+		if r.accept(":") {
+			t = r.readType()
+		}
+		switch {
+		case name.text == "this":
+		case rest:
+			r.warn(r.span(start), "rest params are not supported, ignoring it")
+		default:
+			if optional && required == -1 {
+				required = len(params)
+			}
+			paramName := name.text
+			if name.kind != dtsName {
+				paramName = fmt.Sprintf("arg%v", len(params))
+			}
+			params = append(params, ObjectMember{paramName, t})
+		}
+		if !r.accept(",") {
+			break
+		}
+	}
+	r.expect(")")
+	if required == -1 {
+		required = len(params)
+	}
+	return params, required
+}
+
+// Skip a bracketed group of tokens, starting at the opening bracket
+func (r *declarationReader) skipBalanced() {
+	depth := 0
+	for {
+		t := r.next()
+		depth += getDepthChange(t)
+		if depth <= 0 || t.kind == dtsEOF {
+			return
+		}
+	}
+}
+
+// Type predicates like 'x is T' are booleans
+func (r *declarationReader) readReturnedType() ExpressionType {
+	start := r.i
+	if r.peek().text == "asserts" && r.peekAt(1).kind == dtsName {
+		r.next()
+		r.next()
+		if r.accept("is") {
+			r.readType()
+		}
+		return r.opaque(start, "assertion signatures are not supported")
+	}
+	if r.peek().kind == dtsName && r.peekAt(1).text == "is" {
+		r.next()
+		r.next()
+		r.readType()
+		return Boolean{}
+	}
+	return r.readType()
+}
+
+func (r *declarationReader) readType() ExpressionType {
+	start := r.i
+	r.accept("|")
+	types := []ExpressionType{r.readIntersectionType()}
+	for r.accept("|") {
+		types = append(types, r.readIntersectionType())
+	}
+	if r.peek().text == "extends" && !r.peek().newline {
+		r.next()
+		r.readType()
+		r.expect("?")
+		r.readType()
+		r.expect(":")
+		r.readType()
+		return r.opaque(start, "conditional types are not supported")
+	}
+	return makeUnion(types...)
+}
+
+func (r *declarationReader) readIntersectionType() ExpressionType {
+	start := r.i
+	r.accept("&")
+	t := r.readPostfixType()
+	if r.peek().text != "&" {
+		return t
+	}
+	for r.accept("&") {
+		r.readPostfixType()
+	}
+	return r.opaque(start, "intersection types are not supported")
+}
+
+func (r *declarationReader) readPostfixType() ExpressionType {
+	start := r.i
+	t := r.readPrimaryType()
+	for r.peek().text == "[" && !r.peek().newline {
+		r.next()
+		if r.accept("]") {
+			t = List{t}
+			continue
+		}
+		r.readType()
+		r.expect("]")
+		t = r.opaque(start, "indexed access types are not supported")
+	}
+	return t
+}
+
+func (r *declarationReader) readPrimaryType() ExpressionType {
+	start := r.i
+	t := r.peek()
+	switch t.kind {
+	case dtsString:
+		r.next()
+		value, _ := strconv.Unquote(toDoubleQuoted(t.text))
+		return LiteralType{Base: String{}, Value: strconv.Quote(value)}
+	case dtsTemplate:
+		r.next()
+		return String{}
+	case dtsNumber:
+		r.next()
+		return LiteralType{Base: Number{}, Value: t.text}
+	case dtsName:
+		return r.readTypeName()
+	}
+	switch t.text {
+	case "(":
+		if r.isFunctionType() {
+			return r.readSignature("=>")
+		}
+		r.next()
+		inner := r.readType()
+		r.expect(")")
+		return inner
+	case "<":
+		return r.readSignature("=>")
+	case "{":
+		object := newObject()
+		index, _ := r.readObjectBody(&object, nil, false)
+		if index == nil {
+			return object
+		}
+		if len(object.Members) > 0 || len(object.Defaults) > 0 {
+			r.warn(r.span(start), "objects with both members and an index signature are not supported, ignoring the index signature")
+			return object
+		}
+		return makeMapType(String{}, index)
+	case "[":
+		return r.readTupleType()
+	case "-":
+		r.next()
+		if number := r.peek(); number.kind == dtsNumber {
+			r.next()
+			return LiteralType{Base: Number{}, Value: "-" + number.text}
+		}
+	}
+	r.next()
+	return r.opaque(start, "type expected")
+}
+
+// Is the parenthesis at the current token followed by '=>' once closed?
+func (r *declarationReader) isFunctionType() bool {
+	depth := 0
+	for i := r.i; i < len(r.tokens); i++ {
+		depth += getDepthChange(r.tokens[i])
+		if depth == 0 {
+			return i+1 < len(r.tokens) && r.tokens[i+1].text == "=>"
+		}
+	}
+	return false
+}
+
+func (r *declarationReader) readTupleType() ExpressionType {
+	start := r.i
+	r.next()
+	elements := []ExpressionType{}
+	supported := true
+	for r.peek().kind != dtsEOF && r.peek().text != "]" {
+		if r.accept("...") || r.peek().kind == dtsName && r.peekAt(1).text == ":" {
+			supported = false
+			r.skipMember()
+			continue
+		}
+		elements = append(elements, r.readType())
+		if r.accept("?") {
+			supported = false
+		}
+		if !r.accept(",") {
+			break
+		}
+	}
+	r.expect("]")
+	if !supported {
+		return r.opaque(start, "named, optional and rest elements of tuples are not supported")
+	}
+	return Tuple{elements}
+}
+
+func (r *declarationReader) readTypeName() ExpressionType {
+	start := r.i
+	t := r.next()
+	switch t.text {
+	case "number":
+		return Number{}
+	case "string":
+		return String{}
+	case "boolean":
+		return Boolean{}
+	case "void", "undefined", "null":
+		return Void{}
+	case "never":
+		return Never{}
+	case "any", "unknown":
+		return Invalid{}
+	case "true", "false":
+		return LiteralType{Base: Boolean{}, Value: t.text}
+	case "readonly":
+		return r.readPostfixType()
+	case "this":
+		if len(r.self) > 0 {
+			return r.resolveType(r.self[len(r.self)-1])
+		}
+		return r.opaque(start, "'this' is only supported in interfaces and classes")
+	case "object", "symbol", "bigint":
+		return r.opaque(start, "'%v' is not supported", t.text)
+	case "typeof", "keyof", "infer", "unique":
+		// the operand is not reported, since the whole type is
+		reported := len(r.diagnostics)
+		r.readPostfixType()
+		r.diagnostics = r.diagnostics[:reported]
+		return r.opaque(start, "'%v' types are not supported", t.text)
+	case "new", "abstract":
+		r.accept("new")
+		r.readSignature("=>")
+		return r.opaque(start, "constructor types are not supported")
+	}
+
+	name := t.text
+	qualified := false
+	for r.accept(".") {
+		name += "." + r.next().text
+		qualified = true
+	}
+	args := []ExpressionType{}
+	if r.peek().text == "<" && !r.peek().newline {
+		r.next()
+		for r.peek().kind != dtsEOF && r.peek().text != ">" {
+			args = append(args, r.readType())
+			if !r.accept(",") {
+				break
+			}
+		}
+		r.expect(">")
+	}
+	arg := func(i int) ExpressionType {
+		if i < len(args) {
+			return args[i]
+		}
+		return Invalid{}
+	}
+
+	if qualified {
+		return r.opaque(start, "qualified names like '%v' are not supported", name)
+	}
+	if r.isGeneric(name) {
+		return Generic{Name: name}
+	}
+	if _, ok := r.types[name]; ok {
+		return instantiate(r.resolveType(name), args)
+	}
+	switch name {
+	case "Array", "ReadonlyArray":
+		return List{arg(0)}
+	case "Promise", "PromiseLike":
+		return makePromise(arg(0))
+	case "Map", "ReadonlyMap", "Record":
+		return makeMapType(arg(0), arg(1))
+	case "String":
+		return String{}
+	case "Number":
+		return Number{}
+	case "Boolean":
+		return Boolean{}
+	}
+	if member, ok := DomLib().GetOwned(name); ok {
+		if t, ok := member.(Type); ok {
+			return t.Value
+		}
+	}
+	return r.opaque(start, "cannot find type '%v'", name)
+}
+
+func (r *declarationReader) isGeneric(name string) bool {
+	for _, names := range r.generics {
+		if names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Set the type arguments of a generic type
+func instantiate(alias TypeAlias, args []ExpressionType) TypeAlias {
+	if len(args) == 0 || len(alias.Params) == 0 {
+		return alias
+	}
+	params := slices.Clone(alias.Params)
+	for i := range params {
+		if i < len(args) {
+			params[i].Value = args[i]
+		}
+	}
+	alias.Params = params
+	return alias
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readDeclarations(t *testing.T, source string) (Module, []Diagnostic) {
+	module, diagnostics := ParseDeclarations(strings.NewReader(source), "lib.d.ts")
+	ExportDeclarations("lib.d.ts", module)
+	return module, diagnostics
+}
+
+func getDeclaredType(t *testing.T, module Module, name string) TypeAlias {
+	member, ok := module.GetOwned(name)
+	if !ok {
+		t.Fatalf("Expected '%v' to be declared", name)
+	}
+	typing, ok := member.(Type)
+	if !ok {
+		t.Fatalf("Expected '%v' to be a type, got %#v", name, member)
+	}
+	alias, ok := typing.Value.(TypeAlias)
+	if !ok {
+		t.Fatalf("Expected '%v' to be an alias, got %#v", name, typing.Value)
+	}
+	return alias
+}
+
+func TestDeclaredInterface(t *testing.T) {
+	source := "export interface Options {\n"
+	source += "    name: string\n"
+	source += "    size?: number;\n"
+	source += "    clear(all: boolean): void\n"
+	source += "}\n"
+	module, diagnostics := readDeclarations(t, source)
+	if len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+	alias := getDeclaredType(t, module, "Options")
+	object, ok := alias.Ref.(Object)
+	if !ok {
+		t.Fatalf("Expected an object, got %#v", alias.Ref)
+	}
+	if len(object.Members) != 1 || object.Members[0].Name != "name" {
+		t.Fatalf("Expected 'name' to be required, got %#v", object.Members)
+	}
+	if len(object.Defaults) != 1 || object.Defaults[0].Name != "size" {
+		t.Fatalf("Expected 'size' to be optional, got %#v", object.Defaults)
+	}
+	if _, ok := alias.Methods["clear"].(Function); !ok {
+		t.Fatalf("Expected a 'clear' method, got %#v", alias.Methods)
+	}
+	if !IsTypeOnly("lib.d.ts", "Options") {
+		t.Fatalf("Expected interface to have no runtime value")
+	}
+}
+
+func TestDeclaredTypeAlias(t *testing.T) {
+	module, _ := readDeclarations(t, "type Mode = \"light\" | \"dark\"\ntype Ids = number[]\n")
+	mode := getDeclaredType(t, module, "Mode")
+	if _, ok := mode.Ref.(Union); !ok {
+		t.Fatalf("Expected a union, got %#v", mode.Ref)
+	}
+	if !mode.Extends(LiteralType{String{}, "\"light\""}) {
+		t.Fatalf("Expected '\"light\"' to be assignable to Mode")
+	}
+	ids := getDeclaredType(t, module, "Ids")
+	if _, ok := ids.Ref.(List); !ok {
+		t.Fatalf("Expected a list, got %#v", ids.Ref)
+	}
+}
+
+func TestDeclaredFunction(t *testing.T) {
+	source := "export declare function find<T>(items: T[], key?: string): T | undefined;\n"
+	module, diagnostics := readDeclarations(t, source)
+	if len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+	member, ok := module.GetOwned("find")
+	if !ok {
+		t.Fatalf("Expected 'find' to be declared")
+	}
+	function, ok := member.(Function)
+	if !ok {
+		t.Fatalf("Expected a function, got %#v", member)
+	}
+	if len(function.TypeParams) != 1 {
+		t.Fatalf("Expected 1 type param, got %#v", function.TypeParams)
+	}
+	if IsTypeOnly("lib.d.ts", "find") {
+		t.Fatalf("Expected function to have a runtime value")
+	}
+}
+
+func TestDeclaredClass(t *testing.T) {
+	source := "export declare class Emitter {\n"
+	source += "    constructor(name: string, limit?: number)\n"
+	source += "    private secret;\n"
+	source += "    size: number\n"
+	source += "    on(event: string): this\n"
+	source += "}\n"
+	module, diagnostics := readDeclarations(t, source)
+	if len(diagnostics) != 0 {
+		t.Fatalf("Expected no diagnostics, got %#v", diagnostics)
+	}
+	alias := getDeclaredType(t, module, "Emitter")
+	object := alias.Ref.(Object)
+	if len(object.Members) != 1 || object.Members[0].Name != "size" {
+		t.Fatalf("Expected fields as members, got %#v", object.Members)
+	}
+	params, ok := GetDeclaredConstructor(alias)
+	if !ok || len(params.Members) != 1 || params.Members[0].Name != "name" {
+		t.Fatalf("Expected required constructor params, got %#v", params.Members)
+	}
+	if len(params.Defaults) != 1 || params.Defaults[0].Name != "limit" {
+		t.Fatalf("Expected optional constructor params, got %#v", params.Defaults)
+	}
+	if _, ok := object.GetOwned("secret"); ok {
+		t.Fatalf("Expected private members to be skipped")
+	}
+	on, ok := alias.Methods["on"].(Function)
+	if !ok {
+		t.Fatalf("Expected an 'on' method, got %#v", alias.Methods)
+	}
+	if returned, ok := on.Returned.(TypeAlias); !ok || returned.Name != "Emitter" {
+		t.Fatalf("Expected 'this' to be the class, got %#v", on.Returned)
+	}
+	if IsTypeOnly("lib.d.ts", "Emitter") {
+		t.Fatalf("Expected class to have a runtime value")
+	}
+}
+
+func TestDeclaredUnsupported(t *testing.T) {
+	source := "type Keys = keyof Options\n"
+	source += "interface Options {\n    name: string\n}\n"
+	source += "enum Color { Red }\n"
+	module, diagnostics := readDeclarations(t, source)
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected 2 diagnostics, got %#v", diagnostics)
+	}
+	opaque := Loc{Start: Position{1, 13}, End: Position{1, 26}}
+	found := false
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity != WarningSeverity {
+			t.Fatalf("Expected warnings, got %#v", diagnostic)
+		}
+		found = found || diagnostic.Location.Loc == opaque
+	}
+	if !found {
+		t.Fatalf("Expected a warning at %v, got %#v", opaque, diagnostics)
+	}
+	if _, ok := module.GetOwned("Keys"); !ok {
+		t.Fatalf("Expected opaque type to be declared")
+	}
+	if _, ok := module.GetOwned("Options"); !ok {
+		t.Fatalf("Expected following declarations to be read")
+	}
+}
+
+func TestResolveDeclarationFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lib.d.ts"), []byte("export declare const VERSION: string\n"), 0644)
+	main := filepath.Join(dir, "main")
+	path, ok := ResolvePath(main, "./lib")
+	if !ok || path != filepath.Join(dir, "lib.d.ts") {
+		t.Fatalf("Expected declaration file, got '%v'", path)
+	}
+	if !IsDeclarationFile(path) {
+		t.Fatalf("Expected '%v' to be a declaration file", path)
+	}
+}
+
+func TestDeclaredOptionalParams(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lib.d.ts")
+	source := "interface Options {\n    leading?: boolean\n}\n"
+	source += "export declare function debounce(f: () => void, wait: number, options?: Options): void\n"
+	os.WriteFile(path, []byte(source), 0644)
+	ParseDeclarationFile(path)
+
+	str := "use debounce from \"./lib\"\n"
+	str += "debounce(() => {}, 100)\n"
+	str += "debounce(() => {})"
+	_, errors := ParseProgram(strings.NewReader(str), filepath.Join(dir, "main"))
+	if len(errors) != 1 || errors[0].Kind != MissingElements {
+		t.Fatalf("Expected only the call without 'wait' to fail, got %v errors", len(errors))
+	}
+	if text := errors[0].Text(); text != "Got too few elements: expected 2, got 1" {
+		t.Fatalf("Unexpected message: %v", text)
+	}
+}
+
+func TestDeclaredClassInstance(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lib.d.ts")
+	source := "export declare class Timer {\n"
+	source += "    constructor(ms: number)\n"
+	source += "    id: number\n"
+	source += "}\n"
+	os.WriteFile(path, []byte(source), 0644)
+	ParseDeclarationFile(path)
+
+	str := "use Timer from \"./lib\"\n"
+	str += "t := Timer{ms: 5}\n"
+	str += "_id := t.id\n"
+	str += "_ms := t.ms"
+	_, errors := ParseProgram(strings.NewReader(str), filepath.Join(dir, "main"))
+	if len(errors) != 1 || errors[0].Kind != PropertyDoesNotExist {
+		t.Fatalf("Expected only 't.ms' to fail, got %v errors", len(errors))
+	}
+}
@@ -103,7 +103,13 @@ func parseEntry(p *Parser, expr Expression) Expression {
 		p.error(expr, FieldKeyExpected)
 		expr = nil
 	}
-	complement := p.parseBinaryExpression()
+	var complement Expression
+	switch p.Peek().Kind() {
+	case BreakKeyword, ContinueKeyword, ReturnKeyword, ThrowKeyword:
+		complement = p.parseExit()
+	default:
+		complement = p.parseBinaryExpression()
+	}
 	return &Entry{
 		Key:   expr,
 		Colon: colon,
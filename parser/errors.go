@@ -2,6 +2,8 @@ package parser
 
 import "fmt"
 
+// Kinds are used as diagnostic codes (see ParserError.Code),
+// so new kinds should be added at the end of the list.
 type ErrorKind = uint
 
 const (
@@ -71,9 +73,9 @@ const (
 	ResultDeclaration
 	VoidAssignment
 	OrphanMethod
-	UnneededCatch
-	UnneededAsync
-	UnusedVariable // [variable name]
+	_ // reserved, 'unneeded catch' is reported by the linter
+	_ // reserved, 'unneeded async' is reported by the linter
+	_ // reserved, 'unused variable' is reported by the linter
 	CannotFind
 
 	OutOfRange
@@ -94,12 +96,21 @@ const (
 	TypeDoesNotImplement
 	MissingKeys
 	MissingConstructor
+	NotInUnion      // [tested type, union type]
+	MissingTypeCase // [missing type]
+	IllegalExtern
+	UnavailableLib // [lib name, target]
+	TraitTypeTest  // [trait type]
+	UncheckedEventName
 )
 
 type ParserError struct {
 	Node        Node
 	Kind        ErrorKind
 	Complements [2]interface{}
+	Labels      []Label  // secondary spans
+	Notes       []string // additional explanations
+	Fixes       []Fix
 }
 
 func (p ParserError) Text() string {
@@ -167,7 +178,7 @@ func (p ParserError) Text() string {
 		return "Invalid pattern"
 	case InvalidTypeForPattern:
 		_ = p.Complements[0]
-		assignedType := p.Complements[1].(ExpressionType).Text()
+		assignedType := typeText(p.Complements[1])
 		return fmt.Sprintf("Cannot assign this value (%v) to that pattern", assignedType)
 	case TooManyElements:
 		a := p.Complements[0]
@@ -200,40 +211,40 @@ func (p ParserError) Text() string {
 	case ValueExpected:
 		return "Value expected, got type"
 	case BooleanExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("boolean expected, got %v", got)
 	case TypeOrBoolExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Type or boolean expected, got %v", got)
 	case NumberExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("number expected, got %v", got)
 	case IndexExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("number or range expected, got %v", got)
 	case ConcatenableExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Concatenable (string or list) expected, got %v", got)
 	case IterableExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Iterable (list or slice) expected, got %v", got)
 	case FunctionExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Function expected, got %v", got)
 	case PromiseExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Promise expected, got %v", got)
 	case ResultExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Result expected, got %v", got)
 	case RefExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Reference expected, got %v", got)
 	case ObjectTypeExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Object type expected, got %v", got)
 	case FunctionTypeExpected:
-		got := p.Complements[0].(ExpressionType).Text()
+		got := typeText(p.Complements[0])
 		return fmt.Sprintf("Function type expected, got %v", got)
 
 	case ResultDeclaration:
@@ -242,12 +253,6 @@ func (p ParserError) Text() string {
 		return "Cannot declare a variable as nil value; consider using the option type"
 	case OrphanMethod:
 		return "Methods have to be declared in the same file as the type they're attached to"
-	case UnneededCatch:
-		return "Unneeded catch (lhs is not a result type)"
-	case UnneededAsync:
-		return "Unneeded 'async' keyword"
-	case UnusedVariable:
-		return fmt.Sprintf("Unused variable '%v'", p.Complements[0])
 	case CannotFind:
 		return fmt.Sprintf("Cannot find name '%v'", p.Complements[0])
 
@@ -258,39 +263,31 @@ func (p ParserError) Text() string {
 	case UnexpectedTypeArgs:
 		return "No type arguments expected for this type"
 	case CannotAssignType:
-		t1, ok := p.Complements[0].(ExpressionType)
-		var s1 string
-		if ok {
-			s1 = t1.Text()
-		}
-		t2, ok := p.Complements[1].(ExpressionType)
-		var s2 string
-		if ok {
-			s2 = t2.Text()
-		}
-		return fmt.Sprintf("Cannot use value of type %v as type %v", s2, s1)
+		t1 := typeText(p.Complements[0])
+		t2 := typeText(p.Complements[1])
+		return fmt.Sprintf("Cannot use value of type %v as type %v", t2, t1)
 	case NotSubscriptable:
-		t := p.Complements[0].(ExpressionType).Text()
+		t := typeText(p.Complements[0])
 		return fmt.Sprintf("Type %v is not subscriptable", t)
 	case NotInstanceable:
-		t := p.Complements[0].(ExpressionType).Text()
+		t := typeText(p.Complements[0])
 		return fmt.Sprintf("Type %v cannot be instanciated", t)
 	case Unmatchable:
-		t := p.Complements[0].(ExpressionType).Text()
+		t := typeText(p.Complements[0])
 		return fmt.Sprintf("Cannot match against type %v", t)
 	case NotReferenceable:
 		return "Cannot reference such an expression"
 	case MismatchedTypes:
-		t1 := p.Complements[0].(ExpressionType).Text()
-		t2 := p.Complements[1].(ExpressionType).Text()
+		t1 := typeText(p.Complements[0])
+		t2 := typeText(p.Complements[1])
 		return fmt.Sprintf("Types %v and %v do not match", t1, t2)
 	case PropertyDoesNotExist:
 		name := p.Complements[0]
-		parent := p.Complements[1].(ExpressionType).Text()
+		parent := typeText(p.Complements[1])
 		return fmt.Sprintf("Property '%v' does not exist on type %v", name, parent)
 	case MultipleEmbeddedProperties:
 		name := p.Complements[0]
-		// parent := p.Complements[1].(ExpressionType).Text()
+		// parent := typeText(p.Complements[1])
 		return fmt.Sprintf("Found several embedded properties with name '%v', consider fully qualifying the property", name)
 	case NotInModule:
 		variableName := p.Complements[0]
@@ -304,14 +301,38 @@ func (p ParserError) Text() string {
 	case PublicDeclaration:
 		return "Cannot declare public variables at top-level, consider making it private and defining a getter/setter"
 	case TypeDoesNotImplement:
-		name := p.Complements[0].(ExpressionType).Text()
+		name := typeText(p.Complements[0])
 		return fmt.Sprintf("Type %v does not implement this trait", name)
 	case MissingKeys:
 		return fmt.Sprintf("Missing key(s) %v", p.Complements[0])
 	case MissingConstructor:
 		return fmt.Sprintf("Missing constructor '%v'", p.Complements[0])
+	case NotInUnion:
+		t := typeText(p.Complements[0])
+		union := typeText(p.Complements[1])
+		return fmt.Sprintf("Type %v can never be a value of type %v", t, union)
+	case MissingTypeCase:
+		t := typeText(p.Complements[0])
+		return fmt.Sprintf("Missing case for type %v", t)
+	case IllegalExtern:
+		return "Cannot declare extern values outside of the top level"
+	case UnavailableLib:
+		return fmt.Sprintf("Module '%v' is not available when targeting %v", p.Complements[0], p.Complements[1])
+	case TraitTypeTest:
+		t := typeText(p.Complements[0])
+		return fmt.Sprintf("Cannot test if a value implements %v, traits do not exist at runtime", t)
+	case UncheckedEventName:
+		return "Cannot check that this string is a known event name; use a literal for standard events"
 
 	default:
 		panic("Error type not implemented")
 	}
 }
+
+// Complements may lack a type when checking failed earlier
+func typeText(complement interface{}) string {
+	if t, ok := complement.(ExpressionType); ok && t != nil {
+		return t.Text()
+	}
+	return Invalid{}.Text()
+}
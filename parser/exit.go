@@ -1,7 +1,5 @@
 package parser
 
-import "slices"
-
 type Exit struct {
 	Operator Token
 	Value    Expression
@@ -20,6 +18,10 @@ func (e *Exit) typeCheck(p *Parser) {
 	}
 }
 
+// Exits never complete, so they can be used in place of any expression
+// (e.g. 'value := option catch { return }').
+func (e *Exit) Type() ExpressionType { return Never{} }
+
 func (e *Exit) Loc() Loc {
 	loc := e.Operator.Loc()
 	if e.Value != nil {
@@ -31,7 +33,9 @@ func (e *Exit) Loc() Loc {
 func (p *Parser) parseExit() *Exit {
 	keyword := p.Consume()
 	var value Expression
-	if p.Peek().Kind() != EOL {
+	switch p.Peek().Kind() {
+	case EOL, EOF, RightBrace:
+	default:
 		value = p.parseExpression()
 	}
 	statement := &Exit{keyword, value}
@@ -71,15 +75,8 @@ func reportIllegalExit(p *Parser, e *Exit) {
 	}
 }
 
+// Check if a node never completes (its type is never).
 func IsExiting(n Node) bool {
-	switch n := n.(type) {
-	case *Exit:
-		return true
-	case *Block:
-		return slices.IndexFunc(n.Statements, IsExiting) != -1
-	case *IfExpression:
-		return IsExiting(n.Body) && IsExiting(n.Alternate)
-	default:
-		return false
-	}
+	expr, ok := n.(Expression)
+	return ok && expr != nil && isNever(expr.Type())
 }
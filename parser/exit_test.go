@@ -165,3 +165,15 @@ func TestIsExiting(t *testing.T) {
 		})
 	}
 }
+
+func TestExitAsExpression(t *testing.T) {
+	parser := MakeParser(strings.NewReader("return 42"))
+	parser.pushScope(NewScope(FunctionScope))
+	expr := parser.parseExpression()
+	if _, ok := expr.(*Exit); !ok {
+		t.Fatalf("Expected exit, got %#v", expr)
+	}
+	if _, ok := expr.Type().(Never); !ok {
+		t.Fatalf("Expected never, got %v", expr.Type().Text())
+	}
+}
@@ -0,0 +1,102 @@
+package parser
+
+// Typed binding to a JavaScript value, like
+// 'extern fetchJson :: async (url string) -> Error!string from "./ffi.js"'.
+// Without a source, the binding refers to a global.
+type ExternDeclaration struct {
+	Name   *Identifier
+	Async  bool       // the value is a function returning a promise
+	Typing Expression // type of the value
+	Source *Literal   // nil for globals
+	start  Position
+}
+
+func (e *ExternDeclaration) Loc() Loc {
+	loc := Loc{Start: e.start, End: e.start}
+	if e.Source != nil {
+		loc.End = e.Source.Loc().End
+	} else if e.Typing != nil {
+		loc.End = e.Typing.Loc().End
+	} else if e.Name != nil {
+		loc.End = e.Name.Loc().End
+	}
+	return loc
+}
+func (e *ExternDeclaration) getChildren() []Node {
+	children := []Node{}
+	if e.Name != nil {
+		children = append(children, e.Name)
+	}
+	if e.Typing != nil {
+		children = append(children, e.Typing)
+	}
+	return children
+}
+
+// Type of the bound value
+func (e *ExternDeclaration) Type() ExpressionType {
+	if e.Typing == nil {
+		return Invalid{}
+	}
+	t, ok := e.Typing.Type().(Type)
+	if !ok {
+		return Invalid{}
+	}
+	if f, ok := t.Value.(Function); ok && e.Async {
+		f.Async = true
+		return f
+	}
+	return t.Value
+}
+
+func (e *ExternDeclaration) typeCheck(p *Parser) {
+	if p.scope.outer != &std {
+		p.error(e, IllegalExtern)
+	}
+	if e.Typing == nil || e.Name == nil {
+		return
+	}
+	e.Typing.typeCheck(p)
+	t, ok := e.Typing.Type().(Type)
+	if !ok {
+		p.error(e.Typing, TypeExpected)
+	} else if _, ok := t.Value.(Function); e.Async && !ok {
+		p.error(e.Typing, FunctionTypeExpected, t.Value)
+	}
+	p.scope.AddConstant(e.Name.Text(), e.Name.Loc(), e.Type())
+}
+
+func (p *Parser) parseExternDeclaration() *ExternDeclaration {
+	start := p.Consume().Loc().Start // "extern"
+	declaration := &ExternDeclaration{start: start}
+	expr := p.parseToken()
+	if name, ok := expr.(*Identifier); ok && !name.IsType() {
+		declaration.Name = name
+	} else if expr != nil {
+		p.error(expr, ValueIdentifierExpected)
+	}
+	if p.Peek().Kind() != Define {
+		recoverBadTokens(p, Define)
+	}
+	if p.Peek().Kind() != Define {
+		return declaration
+	}
+	p.Consume()
+	if p.Peek().Kind() == AsyncKeyword {
+		p.Consume()
+		declaration.Async = true
+	}
+	declaration.Typing = p.parseExpression()
+	if p.Peek().Kind() != FromKeyword {
+		return declaration
+	}
+	p.Consume()
+	expr = p.parseExpression()
+	source, ok := expr.(*Literal)
+	if ok && source.Kind() == StringLiteral {
+		declaration.Source = source
+	} else if expr != nil {
+		p.error(expr, StringLiteralExpected)
+	}
+	return declaration
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseExtern(t *testing.T) {
+	str := "extern find :: (string) -> ?number from \"./ffi.js\""
+	parser := MakeParser(strings.NewReader(str))
+	node := parser.parseStatement()
+	testParserErrors(t, parser, 0)
+	extern, ok := node.(*ExternDeclaration)
+	if !ok {
+		t.Fatalf("Expected extern declaration, got %#v", node)
+	}
+	if extern.Source == nil || extern.Source.Text() != "\"./ffi.js\"" {
+		t.Fatalf("Expected source, got %#v", extern.Source)
+	}
+}
+
+func TestParseExternGlobal(t *testing.T) {
+	str := "extern innerWidth :: number"
+	parser := MakeParser(strings.NewReader(str))
+	node := parser.parseStatement()
+	testParserErrors(t, parser, 0)
+	if extern := node.(*ExternDeclaration); extern.Source != nil {
+		t.Fatalf("Expected no source, got %#v", extern.Source)
+	}
+}
+
+func TestCheckExtern(t *testing.T) {
+	str := "extern fetchText :: async (string) -> (Error!string) from \"./ffi.js\""
+	program, errors := ParseProgram(strings.NewReader(str), "")
+	if len(errors) != 0 {
+		t.Fatalf("Expected no errors, got %#v", errors)
+	}
+	v, ok := program.Scope().Find("fetchText")
+	if !ok {
+		t.Fatalf("Expected 'fetchText' to be declared")
+	}
+	f, ok := v.Typing.(Function)
+	if !ok || !f.Async {
+		t.Fatalf("Expected async function, got %#v", v.Typing)
+	}
+}
+
+func TestCheckExternErrors(t *testing.T) {
+	str := "extern Width :: number\n"
+	str += "extern height :: async number\n"
+	str += "_f :: () => {\n    extern log :: (string) -> ()\n}"
+	_, errors := ParseProgram(strings.NewReader(str), "")
+	if len(errors) != 3 {
+		t.Fatalf("Expected 3 errors, got %#v", errors)
+	}
+	expected := []ErrorKind{ValueIdentifierExpected, FunctionTypeExpected, IllegalExtern}
+	for i, kind := range expected {
+		if errors[i].Kind != kind {
+			t.Fatalf("Expected error %v to be %v, got %v", i, kind, errors[i].Kind)
+		}
+	}
+}
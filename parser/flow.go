@@ -0,0 +1,91 @@
+package parser
+
+// A lightweight control-flow graph over the statements of a block.
+// Statements are run in order, so a statement can only lead to the next one,
+// through one edge per way it can complete (e.g. through either branch of an
+// 'if'). Statements that always exit have no edges.
+type flowGraph struct {
+	edges [][]flowEdge // edges[i] lead from statement i to statement i+1
+}
+
+// An edge is taken when its guard evaluated to holds. Types refined by the
+// guard are known to hold in the next statement (e.g. after
+// 'if option == None { return }', 'option' has a value).
+type flowEdge struct {
+	guard Node // nil if the edge is always taken
+	holds bool
+}
+
+// Add the next statement of the block, once type-checked
+func (g *flowGraph) add(statement Node) {
+	g.edges = append(g.edges, getFlowEdges(statement))
+}
+
+func getFlowEdges(statement Node) []flowEdge {
+	if IsExiting(statement) {
+		return nil
+	}
+	switch s := statement.(type) {
+	case *IfExpression:
+		// following statements are only reached through branches which do
+		// not exit
+		edges := []flowEdge{}
+		if !IsExiting(s.Body) {
+			edges = append(edges, flowEdge{s.Condition, true})
+		}
+		if s.Alternate == nil || !IsExiting(s.Alternate) {
+			edges = append(edges, flowEdge{s.Condition, false})
+		}
+		return edges
+	case *Assignment:
+		return []flowEdge{{getTriedIdentifier(s.Value), true}}
+	case Expression:
+		return []flowEdge{{getTriedIdentifier(s), true}}
+	default:
+		return []flowEdge{{}}
+	}
+}
+
+// Index of the first statement which cannot be reached, -1 if all can
+func (g *flowGraph) firstUnreachable() int {
+	for i := 1; i < len(g.edges); i++ {
+		if len(g.edges[i-1]) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Refine types for the statement following the last added one. When it is
+// reached through several edges, nothing is known about it.
+func (g *flowGraph) narrow(scope *Scope) {
+	edges := g.edges[len(g.edges)-1]
+	if len(edges) != 1 {
+		return
+	}
+	switch guard := edges[0].guard.(type) {
+	case nil:
+	case *Identifier:
+		narrowAfterTry(scope, guard)
+	default:
+		narrowCondition(scope, guard, edges[0].holds)
+	}
+}
+
+// 'try result' exits on errors, so 'result' holds a value afterwards.
+func getTriedIdentifier(expr Node) Node {
+	u, ok := expr.(*UnaryExpression)
+	if !ok || u.Operator.Kind() != TryKeyword {
+		return nil
+	}
+	identifier, ok := u.Operand.(*Identifier)
+	if !ok {
+		return nil
+	}
+	return identifier
+}
+func narrowAfterTry(scope *Scope, identifier *Identifier) {
+	if getErrorType(identifier.Type()) != nil {
+		scope.narrow(identifier.Text(), getHappyType(identifier.Type()))
+	}
+}
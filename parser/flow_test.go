@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func testFlowErrors(t *testing.T, source string, n int) {
+	_, errors := ParseProgram(strings.NewReader(source), "")
+	if len(errors) != n {
+		t.Fatalf("Expected %v error(s), got %v: %#v", n, len(errors), errors)
+	}
+}
+
+func TestNarrowAfterExitingIf(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option == None { return 0 }\n"
+	source += "    option + 1\n"
+	source += "}"
+	testFlowErrors(t, source, 0)
+}
+
+func TestNarrowAfterExitingElse(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option != None {} else { return 0 }\n"
+	source += "    option + 1\n"
+	source += "}"
+	testFlowErrors(t, source, 0)
+}
+
+func TestNoNarrowAfterNonExitingIf(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option == None { 0 }\n"
+	source += "    option + 1\n"
+	source += "}"
+	testFlowErrors(t, source, 1)
+}
+
+func TestNarrowInLogicalAnd(t *testing.T) {
+	source := "_f :: (option ?number) => { option != None && option > 0 }"
+	testFlowErrors(t, source, 0)
+}
+
+func TestNarrowAfterTry(t *testing.T) {
+	parser := MakeParser(strings.NewReader("{\n    try result\n    result + 1\n}"))
+	parser.scope.Add("result", Loc{}, makeResultType(Number{}, String{}))
+	block := parser.parseBlock()
+	block.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+}
+
+func TestNarrowInvalidatedByWrite(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option == None { return 0 }\n"
+	source += "    option = ?number{}\n"
+	source += "    option + 1\n"
+	source += "}"
+	// 'option' may be None again
+	testFlowErrors(t, source, 1)
+}
+
+func TestNoNarrowAfterIfWithoutExits(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option == None { 0 } else { 1 }\n"
+	source += "    option + 1\n"
+	source += "}"
+	testFlowErrors(t, source, 1)
+}
+
+func TestUnreachableAfterExitingBranches(t *testing.T) {
+	source := "_f :: (option ?number) => number {\n"
+	source += "    if option == None { return 0 } else { return 1 }\n"
+	source += "    2\n"
+	source += "}"
+	_, errors := ParseProgram(strings.NewReader(source), "")
+	if len(errors) != 1 || errors[0].Kind != UnreachableCode {
+		t.Fatalf("Expected unreachable code, got %#v", errors)
+	}
+}
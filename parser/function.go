@@ -130,19 +130,16 @@ func getFunctionType(f *FunctionExpression) Function {
 		typeParams = f.TypeParams.getGenerics()
 	}
 	params := getFunctionParamsType(f)
-	return Function{typeParams, &params, returned, f.canBeAsync}
+	return Function{typeParams, &params, returned, f.canBeAsync, 0}
 }
 
 func getFunctionParamsType(f *FunctionExpression) Tuple {
-	if len(f.Params.Expr.(*TupleExpression).Elements) == 0 {
-		return Tuple{[]ExpressionType{}}
-	}
-	t := f.Params.Type()
-	if tu, ok := t.(Tuple); ok {
-		return tu
-	} else {
-		return Tuple{[]ExpressionType{t}}
+	elements := f.Params.Expr.(*TupleExpression).Elements
+	types := make([]ExpressionType, len(elements))
+	for i := range elements {
+		types[i] = elements[i].Type()
 	}
+	return Tuple{types}
 }
 
 func getFunctionReturnedType(f *FunctionExpression) ExpressionType {
@@ -183,7 +180,7 @@ func (f *FunctionTypeExpression) Loc() Loc {
 		start = f.Expr.Loc().Start
 	}
 	if f.Expr != nil {
-		end = f.Loc().End
+		end = f.Expr.Loc().End
 	} else if f.Params != nil {
 		end = f.Params.Loc().End
 	} else {
@@ -230,6 +227,7 @@ func (f *FunctionTypeExpression) typeCheck(p *Parser) {
 	if f.Expr == nil {
 		return
 	}
+	f.Expr.typeCheck(p)
 	if _, ok := f.Expr.Type().(Type); !ok {
 		p.error(f.Expr, TypeExpected)
 	}
@@ -372,11 +370,17 @@ func typeCheckReturnsExplicit(p *Parser, explicit ExpressionType, body *Block) {
 	expected := getHappyType(t.Value)
 	returns := findReturnStatements(body)
 	bodyType := body.Type()
-	if !IsExiting(body.Last()) && !expected.Extends(bodyType) {
+	if last, ok := body.Last().(Expression); ok && !isNever(bodyType) {
+		bodyType = getContextualType(expected, last)
+	}
+	if !expected.Extends(bodyType) {
 		p.error(body.reportedNode(), CannotAssignType, expected, bodyType)
 	}
 	for _, r := range returns {
 		returnType := getExitType(r)
+		if r.Value != nil {
+			returnType = getContextualType(expected, r.Value)
+		}
 		if !expected.Extends(returnType) {
 			p.error(r.Value, CannotAssignType, expected, returnType)
 		}
@@ -547,3 +547,28 @@ func TestCheckAsyncFunctionExpression(t *testing.T) {
 		t.Fatalf("Expected function to be async, got %#v", parser.errors)
 	}
 }
+
+func TestCheckLiteralArgument(t *testing.T) {
+	source := "_f :: (mode \"light\" | \"dark\") => { mode }\n"
+	source += "_f(\"light\")\n"
+	source += "_f(\"blue\")"
+	_, errors := ParseProgram(strings.NewReader(source), "")
+	// "blue" is not assignable
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error, got %v: %#v", len(errors), errors)
+	}
+}
+
+func TestCheckNamedUnionArgument(t *testing.T) {
+	source := "Shape :: { name string }\n"
+	source += "Foo :: number | Shape\n"
+	source += "_f :: (foo Foo) => { foo }\n"
+	source += "_f(Shape{name: \"a\"})\n"
+	source += "_f(42)\n"
+	source += "_f(\"a\")"
+	_, errors := ParseProgram(strings.NewReader(source), "")
+	// a string is not a Foo
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error, got %v: %#v", len(errors), errors)
+	}
+}
@@ -34,30 +34,127 @@ func (i *IfExpression) typeCheck(p *Parser) {
 			p.error(i.Condition, BooleanExpected, expr.Type())
 		}
 	}
+	narrowCondition(p.scope, i.Condition, true)
 	i.Body.typeCheck(p)
 	p.dropScope()
 
 	if i.Alternate == nil {
 		return
 	}
+	p.pushScope(NewScope(BlockScope))
+	narrowCondition(p.scope, i.Condition, false)
 	i.Alternate.typeCheck(p)
-	if !Match(i.Body.Type(), i.Alternate.Type()) {
+	p.dropScope()
+	if _, ok := unifyBranches(i.Alternate.Type(), i.Body.Type()); !ok {
 		loc := Loc{i.Keyword.Loc().Start, i.Alternate.Loc().End}
 		p.error(&Block{loc: loc}, CannotAssignType, i.Body.Type(), i.Alternate.Type())
 	}
 }
 
+// Refine the types of identifiers tested in a condition (e.g. 'x is number').
+// If holds is false, types are refined for the case where the condition failed.
+func narrowCondition(scope *Scope, condition Node, holds bool) {
+	b, ok := condition.(*BinaryExpression)
+	if !ok {
+		return
+	}
+	switch b.Operator.Kind() {
+	case LogicalAnd:
+		if holds {
+			narrowCondition(scope, b.Left, true)
+			narrowCondition(scope, b.Right, true)
+		}
+	case LogicalOr:
+		if !holds {
+			narrowCondition(scope, b.Left, false)
+			narrowCondition(scope, b.Right, false)
+		}
+	case IsKeyword:
+		narrowTypeTest(scope, b, holds)
+	case Equal, NotEqual:
+		narrowEquality(scope, b, holds == (b.Operator.Kind() == Equal))
+	}
+}
+
+func narrowTypeTest(scope *Scope, b *BinaryExpression, holds bool) {
+	identifier, ok := b.Left.(*Identifier)
+	if !ok || b.Right == nil {
+		return
+	}
+	t, ok := b.Right.Type().(Type)
+	if !ok {
+		return
+	}
+	if holds {
+		scope.narrow(identifier.Text(), t.Value)
+		return
+	}
+	if union, ok := asUnion(identifier.Type()); ok {
+		scope.narrow(identifier.Text(), union.exclude(t.Value))
+	}
+}
+
+// Narrow variables compared to literal values (e.g. 'mode == "light"')
+// or to empty options (e.g. 'option != None').
+func narrowEquality(scope *Scope, b *BinaryExpression, holds bool) {
+	identifier, compared := getComparedOperands(b)
+	if identifier == nil || compared == nil {
+		return
+	}
+	if isNone(compared) {
+		if !holds && isOptionType(identifier.Type()) {
+			scope.narrow(identifier.Text(), getSomeTypeOf(identifier.Type()))
+		}
+		return
+	}
+	literal, ok := compared.(*Literal)
+	if !ok {
+		return
+	}
+	union, ok := asUnion(identifier.Type())
+	if !ok {
+		return
+	}
+	t, ok := literal.literalType()
+	if !ok || !union.Extends(t) {
+		return
+	}
+	if holds {
+		scope.narrow(identifier.Text(), t)
+	} else {
+		scope.narrow(identifier.Text(), union.exclude(t))
+	}
+}
+
+// Get the variable being compared, and the expression it is compared to
+func getComparedOperands(b *BinaryExpression) (*Identifier, Expression) {
+	if identifier, ok := b.Left.(*Identifier); ok && !isNone(identifier) {
+		return identifier, b.Right
+	}
+	if identifier, ok := b.Right.(*Identifier); ok && !isNone(identifier) {
+		return identifier, b.Left
+	}
+	return nil, nil
+}
+
 func (i *IfExpression) Loc() Loc {
-	return Loc{
-		Start: i.Keyword.Loc().Start,
-		End:   i.Body.Loc().End,
+	var loc Loc
+	if i.Keyword != nil {
+		loc.Start = i.Keyword.Loc().Start
+	} else if i.Condition != nil {
+		loc.Start = i.Condition.Loc().Start
+	}
+	if i.Body != nil {
+		loc.End = i.Body.Loc().End
 	}
+	return loc
 }
 func (i *IfExpression) Type() ExpressionType {
 	if i.Alternate == nil {
 		return makeOptionType(i.Body.Type())
 	}
-	return i.Alternate.Type()
+	t, _ := unifyBranches(i.Alternate.Type(), i.Body.Type())
+	return t
 }
 
 func (p *Parser) parseIfExpression() *IfExpression {
@@ -137,3 +137,92 @@ func TestCheckIfPattern(t *testing.T) {
 		t.Fatalf("Expected a number, got %#v", expr.Type())
 	}
 }
+
+func TestIfNarrowing(t *testing.T) {
+	str := "if x is number { x > 0 } else { x == \"a\" }"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("x", Loc{}, Union{[]ExpressionType{Number{}, String{}}})
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+}
+
+func TestIfNarrowingInLogicalAnd(t *testing.T) {
+	str := "if x is number && x > 0 {}"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("x", Loc{}, Union{[]ExpressionType{Number{}, String{}}})
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+}
+
+func TestIfNarrowingNotInUnion(t *testing.T) {
+	str := "if x is boolean {}"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("x", Loc{}, Union{[]ExpressionType{Number{}, String{}}})
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 1)
+}
+
+func TestIfNarrowingEquality(t *testing.T) {
+	str := "if x == \"light\" { x == \"dark\" } else { x == \"dark\" }"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("x", Loc{}, Union{[]ExpressionType{
+		LiteralType{String{}, "\"light\""},
+		LiteralType{String{}, "\"dark\""},
+	}})
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	// x can only be "light" in the body
+	testParserErrors(t, parser, 1)
+}
+
+func TestIfNarrowingInequality(t *testing.T) {
+	str := "if x != \"light\" { x == \"light\" }"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("x", Loc{}, Union{[]ExpressionType{
+		LiteralType{String{}, "\"light\""},
+		LiteralType{String{}, "\"dark\""},
+	}})
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 1)
+}
+
+func TestIfWithExitingBranch(t *testing.T) {
+	str := "if true { 42 } else { return 0 }"
+	parser := MakeParser(strings.NewReader(str))
+	parser.pushScope(NewScope(FunctionScope))
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+	if _, ok := expr.Type().(Number); !ok {
+		t.Fatalf("Expected number, got %v", expr.Type().Text())
+	}
+}
+
+func TestIfNarrowingNamedUnion(t *testing.T) {
+	str := "if x is Shape { false } else { x > 0 }"
+	parser := MakeParser(strings.NewReader(str))
+	shape := TypeAlias{Name: "Shape", Ref: newObject()}
+	parser.scope.Add("Shape", Loc{}, Type{shape})
+	parser.scope.Add("x", Loc{}, TypeAlias{Name: "Foo", Ref: Union{[]ExpressionType{Number{}, shape}}})
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+}
+
+func TestIfNarrowingTrait(t *testing.T) {
+	str := "if x is Named {}"
+	parser := MakeParser(strings.NewReader(str))
+	trait := Trait{Self: Generic{Name: "_"}, Members: map[string]ExpressionType{}}
+	parser.scope.Add("Named", Loc{}, Type{TypeAlias{Name: "Named", Ref: trait}})
+	parser.scope.Add("x", Loc{}, Number{})
+	expr := parser.parseIfExpression()
+	expr.typeCheck(parser)
+	testParserErrors(t, parser, 1)
+	if len(parser.errors) == 1 && parser.errors[0].Kind != TraitTypeTest {
+		t.Fatalf("Expected a trait type test error, got %v", parser.errors[0].Text())
+	}
+}
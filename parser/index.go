@@ -1,9 +1,9 @@
 package parser
 
 import (
+	"bytes"
 	"io"
 	"os"
-	"path/filepath"
 	"slices"
 )
 
@@ -56,18 +56,40 @@ func Walk(node Node, predicate func(n Node, skip func())) {
 }
 
 type Program struct {
-	scope *Scope
-	nodes []Node
+	path     string
+	scope    *Scope
+	scopes   []*Scope
+	nodes    []Node
+	comments []Comment
 }
 
-func (p Program) Scope() *Scope { return p.scope }
-func (p Program) Nodes() []Node { return p.nodes }
+func (p Program) Path() string        { return p.path }
+func (p Program) Scope() *Scope       { return p.scope }
+func (p Program) Scopes() []*Scope    { return p.scopes }
+func (p Program) Nodes() []Node       { return p.nodes }
+func (p Program) Comments() []Comment { return p.comments }
 
+// Public names of the program, as seen by files using it
+func (p Program) Module() Module { return p.scope.toModule() }
+
+// Parse and check a program. Statements are dropped if there are errors, so
+// that no erroneous tree reaches the emitter.
 func ParseProgram(reader io.Reader, path string) (Program, []ParserError) {
+	program, errors := ParseDocument(reader, path)
+	if len(errors) > 0 {
+		program.nodes = []Node{}
+	}
+	return program, errors
+}
+
+// Same as ParseProgram, but statements are kept even if there are errors, so
+// that editors can still query them (e.g. for completion or hover)
+func ParseDocument(reader io.Reader, path string) (Program, []ParserError) {
 	p := MakeParser(reader)
 	p.filePath = path
 	statements := []Node{}
 
+	p.DiscardLineBreaks()
 	for p.Peek().Kind() != EOF {
 		statements = append(statements, p.parseStatement())
 		next := p.Peek().Kind()
@@ -81,34 +103,31 @@ func ParseProgram(reader io.Reader, path string) (Program, []ParserError) {
 	for i := range statements {
 		statements[i].typeCheck(p)
 	}
-	checkUnusedPrivateVariables(p)
 
-	if len(p.errors) > 0 {
-		statements = []Node{}
-	}
-	return Program{p.scope, statements}, p.errors
-}
-func checkUnusedPrivateVariables(p *Parser) {
-	for name, info := range p.scope.variables {
-		if name[0] != '_' && len(info.reads) == 0 {
-			p.error(&Identifier{Token: literal{kind: Name, value: name, loc: info.declaredAt}}, UnusedVariable, name)
-		}
-	}
+	return Program{path, p.scope, p.scopes, statements, p.comments}, p.errors
 }
 
 var filesExports = map[string]Module{}
 
-func ParseFile(path string) (Program, []ParserError) {
-	file, err := os.Open(path)
+func ParseFile(path string) (Program, []ParserError, error) {
+	// read first, so that I/O failures are not parsed as the end of file
+	content, err := os.ReadFile(path)
 	if err != nil {
-		panic(err)
+		return Program{}, nil, err
 	}
-	defer file.Close()
 
-	program, errors := ParseProgram(file, path)
+	program, errors := ParseProgram(bytes.NewReader(content), path)
+	ExportProgram(path, program)
+	return program, errors, nil
+}
+
+// Make the public declarations of a program available to files using it.
+// Returns the resulting module.
+func ExportProgram(path string, program Program) Module {
 	o := program.scope.toModule()
+	o.path = path
 	filesExports[path] = o
-	return program, errors
+	return o
 }
 
 type File struct {
@@ -129,11 +148,15 @@ func (f *File) hasAncestor(a *File) bool {
 type DependencyBuilder struct {
 	files   []*File
 	inCycle []*File
+	open    func(path string) (io.ReadCloser, error)
 }
 
 func (d *DependencyBuilder) make() *DependencyBuilder {
 	d.files = []*File{}
 	d.inCycle = []*File{}
+	if d.open == nil {
+		d.open = func(path string) (io.ReadCloser, error) { return os.Open(path) }
+	}
 	return d
 }
 
@@ -156,19 +179,24 @@ func (d *DependencyBuilder) findFile(path string) *File {
 }
 func (d *DependencyBuilder) buildDependencyTree(filePath string) *File {
 	f := d.makeFile(filePath)
-	file, err := os.Open(filePath)
+	if IsDeclarationFile(filePath) {
+		// declaration files don't use other files
+		return f
+	}
+	file, err := d.open(filePath)
 	if err != nil {
 		return nil
 	}
 	defer file.Close()
 
-	dir := filepath.Dir(filePath)
-
 	names := parseDependencies(file)
 	files := make([]*File, len(names))
 	i := 0
 	for _, name := range names {
-		name = filepath.Join(dir, name)
+		name, ok := ResolvePath(filePath, name)
+		if !ok {
+			continue
+		}
 		found := d.findFile(name)
 		if found != nil {
 			files[i] = found
@@ -199,6 +227,7 @@ func parseDependencies(reader io.Reader) []string {
 	p := MakeParser(reader)
 	files := []string{}
 
+	p.DiscardLineBreaks()
 	for p.Peek().Kind() != EOF {
 		statement := p.parseStatement()
 		u, ok := statement.(*UseDirective)
@@ -210,11 +239,9 @@ func parseDependencies(reader io.Reader) []string {
 		}
 		source := u.Source.Text()
 		source = source[1 : len(source)-1] // remove quotation marks
-		// sources like "io" are std.
+		// sources like "io" are std, others are resolved by the caller.
 		// if referring to a file in same dir, use "./io" instead.
-		if IsLocalPath(source) {
-			files = append(files, source)
-		}
+		files = append(files, source)
 		next := p.Peek().Kind()
 		if next == EOL {
 			p.DiscardLineBreaks()
@@ -228,21 +255,36 @@ func parseDependencies(reader io.Reader) []string {
 
 // returns pathsInOrder, pathsInCircularDependencies
 func GetCompileOrder(rootPath string) ([]*File, []*File) {
-	d := (&DependencyBuilder{}).make()
+	return GetCompileOrderWith(rootPath, nil)
+}
+
+// Same as GetCompileOrder, but files are read with the given function
+// (e.g. to use unsaved editor buffers). Defaults to os.Open if nil.
+func GetCompileOrderWith(rootPath string, open func(path string) (io.ReadCloser, error)) ([]*File, []*File) {
+	d := (&DependencyBuilder{open: open}).make()
 	d.buildDependencyTree(rootPath)
 	d.validateDependencyTree()
 	slices.Reverse(d.files)
 	return d.files, d.inCycle
 }
 
-func Parse(rootPath string) ([]Program, []ParserError) {
+func Parse(rootPath string) ([]Program, []ParserError, error) {
 	files, _ := GetCompileOrder(rootPath)
 	chunks := []Program{}
 	errors := []ParserError{}
 	for _, file := range files {
-		program, errs := ParseFile(file.Path)
+		if IsDeclarationFile(file.Path) {
+			if _, _, err := ParseDeclarationFile(file.Path); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		program, errs, err := ParseFile(file.Path)
+		if err != nil {
+			return nil, nil, err
+		}
 		chunks = append(chunks, program)
 		errors = append(errors, errs...)
 	}
-	return chunks, errors
+	return chunks, errors, nil
 }
@@ -106,6 +106,10 @@ func checkObjectInstanciation(p *Parser, i *InstanceExpression) {
 		typeCheckMapInstanciation(p, i, alias)
 		return
 	}
+	if newtype, ok := alias.Ref.(Newtype); ok {
+		checkNewtypeInstanciation(p, i, alias, newtype)
+		return
+	}
 	p.pushScope(NewScope(ProgramScope))
 	defer p.dropScope()
 	typeCheckTypeArgs(p, nil, alias.Params)
@@ -116,6 +120,10 @@ func checkObjectInstanciation(p *Parser, i *InstanceExpression) {
 		i.typing = Invalid{}
 		return
 	}
+	// classes of declaration files are built with their constructor's params
+	if params, ok := GetDeclaredConstructor(alias); ok {
+		object = params
+	}
 
 	args := i.Args.Expr.(*TupleExpression).Elements
 	formatStructEntries(p, args)
@@ -126,12 +134,15 @@ func checkObjectInstanciation(p *Parser, i *InstanceExpression) {
 			name = entry.Key.(*Identifier).Text()
 		}
 		expected, ok := object.GetOwned(name)
-		if ok && entry.Value != nil && !expected.Extends(entry.Value.Type()) {
-			p.error(arg, CannotAssignType, expected, entry.Value.Type())
+		if !ok || entry.Value == nil {
+			continue
+		}
+		if received := getContextualType(expected, entry.Value); !expected.Extends(received) {
+			p.error(arg, CannotAssignType, expected, received)
 		}
 	}
 
-	reportExcessMembers(p, alias, args)
+	reportExcessMembers(p, alias, object, args)
 	reportMissingMembers(p, object, i.Args)
 
 	i.typing = alias
@@ -160,8 +171,7 @@ func getFormattedStructEntry(p *Parser, received Expression) *Entry {
 	}
 	return entry
 }
-func reportExcessMembers(p *Parser, alias TypeAlias, received []Expression) {
-	expected := alias.Ref.(Object)
+func reportExcessMembers(p *Parser, alias TypeAlias, expected Object, received []Expression) {
 	for _, arg := range received {
 		namedArg, ok := arg.(*Entry)
 		if !ok || namedArg.Key == nil {
@@ -172,6 +182,7 @@ func reportExcessMembers(p *Parser, alias TypeAlias, received []Expression) {
 			continue
 		}
 		p.error(arg, PropertyDoesNotExist, name, alias)
+		p.suggestName(namedArg.Key.(*Identifier), getMemberNames(expected))
 	}
 }
 func reportMissingMembers(p *Parser, expected Object, received *BracedExpression) {
@@ -216,6 +227,22 @@ func checkOptionInstanciation(p *Parser, i *InstanceExpression, t TypeAlias) {
 		p.error(args.Elements[0], CannotAssignType, expected, received)
 	}
 }
+
+// Wrap a value into a newtype, like 'UserId{42}'
+func checkNewtypeInstanciation(p *Parser, i *InstanceExpression, alias TypeAlias, t Newtype) {
+	i.typing = alias
+	args := i.Args.Expr.(*TupleExpression)
+	if len(args.Elements) == 0 {
+		p.error(i.Args, MissingElements, 1, 0)
+		return
+	}
+	checkOptionArgs(p, args)
+	expected := t.Underlying
+	received := getContextualType(expected, args.Elements[0])
+	if !expected.Extends(received) {
+		p.error(args.Elements[0], CannotAssignType, expected, received)
+	}
+}
 func checkInferredOptionInstance(p *Parser, i *InstanceExpression) {
 	args := i.Args.Expr.(*TupleExpression)
 	if len(args.Elements) == 0 {
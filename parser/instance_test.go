@@ -437,3 +437,51 @@ func TestCheckAnonymousList(t *testing.T) {
 		t.Fatalf("List expected")
 	}
 }
+
+func TestCheckNewtypeInstance(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		errors int
+	}{
+		{
+			name:   "wrap",
+			source: "_UserId :: new number\n_id := _UserId{42}",
+			errors: 0,
+		},
+		{
+			name:   "wrong underlying type",
+			source: "_UserId :: new number\n_id := _UserId{\"42\"}",
+			errors: 1,
+		},
+		{
+			name:   "missing value",
+			source: "_UserId :: new number\n_id := _UserId{}",
+			errors: 1,
+		},
+		{
+			name:   "underlying value as newtype",
+			source: "_UserId :: new number\n_f :: (id _UserId) => { id }\n_f(42)",
+			errors: 1,
+		},
+		{
+			name:   "newtype as underlying value",
+			source: "_UserId :: new number\n_f :: (n number) => { n }\n_f(_UserId{42})",
+			errors: 1,
+		},
+		{
+			name:   "other newtype",
+			source: "_UserId :: new number\n_OrderId :: new number\n_f :: (id _UserId) => { id }\n_f(_OrderId{42})",
+			errors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errors := ParseProgram(strings.NewReader(tt.source), "")
+			if len(errors) != tt.errors {
+				t.Fatalf("Expected %v error(s), got %#v", tt.errors, errors)
+			}
+		})
+	}
+}
@@ -39,10 +39,13 @@ func (m MatchCase) Loc() Loc {
 	} else {
 		start = m.Colon.Loc().Start
 	}
-	if m.Consequent != nil {
+	switch {
+	case m.Consequent != nil:
 		end = m.Consequent.Loc().End
-	} else {
+	case m.Colon != nil:
 		end = m.Colon.Loc().End
+	default:
+		end = m.Pattern.Loc().End
 	}
 	return Loc{start, end}
 }
@@ -111,7 +114,12 @@ func (m *MatchExpression) Type() ExpressionType {
 	if len(m.Cases) == 0 {
 		return Void{}
 	}
-	return m.Cases[0].Type()
+	types := make([]ExpressionType, len(m.Cases))
+	for i := range m.Cases {
+		types[i] = m.Cases[i].Type()
+	}
+	t, _ := unifyBranches(types...)
+	return t
 }
 
 func (m *MatchExpression) typeCheck(p *Parser) {
@@ -124,7 +132,7 @@ func (m *MatchExpression) typeCheck(p *Parser) {
 		t = alias.Ref
 	}
 	switch t.(type) {
-	case Sum, Trait:
+	case Sum, Trait, Union:
 	default:
 		p.error(m.Value, Unmatchable, t)
 	}
@@ -213,6 +221,11 @@ func reportDuplicatedCases(p *Parser, cases []MatchCase) {
 		}
 		for _, loc := range locs {
 			p.error(&Block{loc: loc}, DuplicateIdentifier, name)
+			for _, other := range locs {
+				if other != loc {
+					p.label(other, "other declaration of '"+name+"'")
+				}
+			}
 		}
 	}
 }
@@ -236,6 +249,10 @@ func reportMissingCases(p *Parser, cases []MatchCase, matched ExpressionType) {
 		}
 		names[identifier.Text()] = true
 	}
+	if union, ok := matched.(Union); ok {
+		reportMissingTypeCases(p, &Block{loc: loc}, cases, union)
+		return
+	}
 	sum, ok := matched.(Sum)
 	if !ok {
 		p.error(&Block{loc: loc}, NotExhaustive)
@@ -249,6 +266,39 @@ func reportMissingCases(p *Parser, cases []MatchCase, matched ExpressionType) {
 	}
 }
 
+// Every member of a matched union should be handled by a case
+func reportMissingTypeCases(p *Parser, reported Node, cases []MatchCase, union Union) {
+	handled := []ExpressionType{}
+	for _, c := range cases {
+		if t, ok := getCaseType(c); ok {
+			handled = append(handled, t)
+		}
+	}
+	for _, member := range union.Members {
+		if !containsType(handled, member) {
+			p.error(reported, MissingTypeCase, member)
+		}
+	}
+}
+
+// Get the type handled by a case in a union match
+// (e.g. 'number' for '(n number)', or '"light"' for '"light"')
+func getCaseType(c MatchCase) (ExpressionType, bool) {
+	switch pattern := c.Pattern.(type) {
+	case *Param:
+		if pattern.Complement == nil {
+			return nil, false
+		}
+		t, ok := pattern.Complement.Type().(Type)
+		return t.Value, ok
+	case *Literal:
+		t, ok := pattern.literalType()
+		return t, ok
+	default:
+		return nil, false
+	}
+}
+
 func getCaseIdentifier(c MatchCase) *Identifier {
 	switch pattern := c.Pattern.(type) {
 	case *Identifier:
@@ -79,7 +79,7 @@ func TestCheckMatchCase(t *testing.T) {
 			consequent: &Block{Statements: []Node{
 				&Exit{Operator: token{kind: ReturnKeyword}},
 			}},
-			expectedType: Void{},
+			expectedType: Never{},
 		},
 	}
 
@@ -106,3 +106,77 @@ func TestMatch(t *testing.T) {
 		t.Fatalf("Expected 1 case, got %#v", statement.Cases)
 	}
 }
+
+func TestMatchUnion(t *testing.T) {
+	str := "match value {\n"
+	str += "n number: n\n"
+	str += "s string: 0\n"
+	str += "}"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("value", Loc{}, Union{[]ExpressionType{Number{}, String{}}})
+	node := parser.parseMatchExpression()
+	node.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+	if _, ok := node.Type().(Number); !ok {
+		t.Fatalf("Expected number, got %v", node.Type().Text())
+	}
+}
+
+func TestMatchUnionNotExhaustive(t *testing.T) {
+	str := "match value {\n"
+	str += "n number: n\n"
+	str += "b boolean: 0\n"
+	str += "}"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("value", Loc{}, Union{[]ExpressionType{Number{}, String{}}})
+	node := parser.parseMatchExpression()
+	node.typeCheck(parser)
+	// boolean is not in union, string is missing
+	testParserErrors(t, parser, 2)
+}
+
+func TestMatchLiteralUnion(t *testing.T) {
+	str := "match value {\n"
+	str += "\"light\": 1\n"
+	str += "\"dark\": 2\n"
+	str += "}"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("value", Loc{}, Union{[]ExpressionType{
+		LiteralType{String{}, "\"light\""},
+		LiteralType{String{}, "\"dark\""},
+	}})
+	node := parser.parseMatchExpression()
+	node.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+}
+
+func TestMatchLiteralUnionNotExhaustive(t *testing.T) {
+	str := "match value {\n"
+	str += "\"light\": 1\n"
+	str += "\"blue\": 2\n"
+	str += "}"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("value", Loc{}, Union{[]ExpressionType{
+		LiteralType{String{}, "\"light\""},
+		LiteralType{String{}, "\"dark\""},
+	}})
+	node := parser.parseMatchExpression()
+	node.typeCheck(parser)
+	// "blue" is not in union, "dark" is missing
+	testParserErrors(t, parser, 2)
+}
+
+func TestMatchWithExitingCase(t *testing.T) {
+	str := "match value {\n"
+	str += "n number: n\n"
+	str += "_: todo()\n"
+	str += "}"
+	parser := MakeParser(strings.NewReader(str))
+	parser.scope.Add("value", Loc{}, Union{[]ExpressionType{Number{}, String{}}})
+	node := parser.parseMatchExpression()
+	node.typeCheck(parser)
+	testParserErrors(t, parser, 0)
+	if _, ok := node.Type().(Number); !ok {
+		t.Fatalf("Expected number, got %v", node.Type().Text())
+	}
+}
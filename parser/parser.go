@@ -6,6 +6,7 @@ type Parser struct {
 	tokenizer
 	errors            []ParserError
 	scope             *Scope
+	scopes            []*Scope // every scope created, for semantic queries
 	filePath          string
 	writing           Node
 	multiline         bool
@@ -40,6 +41,19 @@ func (p *Parser) error(node Node, kind ErrorKind, comp ...interface{}) {
 	p.errors = append(p.errors, err)
 }
 
+// Attach a secondary span to the last reported error
+func (p *Parser) label(loc Loc, message string) {
+	err := &p.errors[len(p.errors)-1]
+	err.Labels = append(err.Labels, Label{Location{p.filePath, loc}, message})
+}
+
+// Attach a suggested edit to the last reported error
+func (p *Parser) fix(message string, loc Loc, newText string) {
+	err := &p.errors[len(p.errors)-1]
+	edit := TextEdit{p.filePath, loc, newText}
+	err.Fixes = append(err.Fixes, Fix{message, []TextEdit{edit}})
+}
+
 func MakeParser(reader io.Reader) *Parser {
 	tokenizer := NewTokenizer(reader)
 	scope := NewScope(ProgramScope)
@@ -47,6 +61,7 @@ func MakeParser(reader io.Reader) *Parser {
 	return &Parser{
 		tokenizer:         *tokenizer,
 		scope:             scope,
+		scopes:            []*Scope{scope},
 		allowBraceParsing: true,
 		allowCallExpr:     true,
 	}
@@ -55,14 +70,10 @@ func MakeParser(reader io.Reader) *Parser {
 func (p *Parser) pushScope(scope *Scope) {
 	scope.outer = p.scope
 	p.scope = scope
+	p.scopes = append(p.scopes, scope)
 }
 
 func (p *Parser) dropScope() {
-	for _, info := range p.scope.variables {
-		if len(info.reads) == 0 {
-			p.error(&Block{loc: info.declaredAt}, UnusedVariable)
-		}
-	}
 	p.scope = p.scope.outer
 }
 
@@ -72,6 +83,8 @@ func (p *Parser) parseStatement() Node {
 		return p.parseExit()
 	case UseKeyword:
 		return p.parseUseDirective()
+	case ExternKeyword:
+		return p.parseExternDeclaration()
 	default:
 		return p.parseAssignment()
 	}
@@ -85,6 +98,8 @@ func (p *Parser) parseExpression() Expression {
 		return p.parseIfExpression()
 	case MatchKeyword:
 		return p.parseMatchExpression()
+	case BreakKeyword, ContinueKeyword, ReturnKeyword, ThrowKeyword:
+		return p.parseExit()
 	default:
 		return p.parseTupleExpression()
 	}
@@ -2,10 +2,14 @@ package parser
 
 func (p *Parser) typeCheckPattern(pattern Expression, matched ExpressionType) {
 	switch matched := matched.(type) {
+	case TypeAlias:
+		p.typeCheckPattern(pattern, matched.Ref)
 	case Sum:
 		validateSumPattern(p, pattern, matched)
 	case Trait:
 		validateTraitPattern(p, pattern, matched)
+	case Union:
+		validateUnionPattern(p, pattern, matched)
 	}
 }
 
@@ -42,6 +46,7 @@ func validateTraitPattern(p *Parser, pattern Expression, trait Trait) {
 	v, ok := p.scope.Find(typing.Text())
 	if !ok {
 		p.error(typing, CannotFind, typing.Text())
+		p.suggestName(typing, p.scope.visibleNames())
 		return
 	}
 	alias, ok := v.Typing.(TypeAlias)
@@ -52,3 +57,41 @@ func validateTraitPattern(p *Parser, pattern Expression, trait Trait) {
 
 	p.scope.Add(param.Identifier.Text(), param.Identifier.Loc(), alias)
 }
+
+func validateUnionPattern(p *Parser, pattern Expression, union Union) {
+	if identifier, ok := pattern.(*Identifier); ok && identifier.Text() == "_" {
+		return
+	}
+	if literal, ok := pattern.(*Literal); ok {
+		validateLiteralPattern(p, literal, union)
+		return
+	}
+	param, ok := pattern.(*Param)
+	if !ok || param.Complement == nil {
+		p.error(pattern, InvalidPattern)
+		return
+	}
+	param.Complement.typeCheck(p)
+	t, ok := param.Complement.Type().(Type)
+	if !ok {
+		p.error(param.Complement, TypeExpected)
+		return
+	}
+	if isTraitType(t.Value) {
+		p.error(param.Complement, TraitTypeTest, t.Value)
+	} else if !union.Extends(t.Value) {
+		p.error(param.Complement, NotInUnion, t.Value, union)
+	}
+	p.scope.Add(param.Identifier.Text(), param.Identifier.Loc(), t.Value)
+}
+
+func validateLiteralPattern(p *Parser, literal *Literal, union Union) {
+	t, ok := literal.literalType()
+	if !ok {
+		p.error(literal, InvalidPattern)
+		return
+	}
+	if !union.Extends(t) {
+		p.error(literal, NotInUnion, t, union)
+	}
+}
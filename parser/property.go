@@ -121,6 +121,7 @@ func typeCheckSumConstructorAccess(p *Parser, expr *PropertyAccessExpression) {
 	expr.typing = getSumTypeConstructor(expr.Expr.Type().(Type), name)
 	if expr.typing == (Invalid{}) {
 		p.error(expr.Property, PropertyDoesNotExist, name, expr.Expr.Type())
+		p.suggestName(property, getMemberNames(expr.Expr.Type()))
 	}
 }
 
@@ -172,10 +173,13 @@ func typeCheckPropertyAccess(p *Parser, expr *PropertyAccessExpression) {
 				expr.typing = Invalid{}
 			} else {
 				p.error(expr.Property, PropertyDoesNotExist, name, expr.Expr.Type())
+				p.suggestName(property, getMemberNames(expr.Expr.Type()))
 				expr.typing = Invalid{}
 			}
 		case Sum:
 			expr.typing = t.Methods[name]
+		case Newtype:
+			expr.typing = getNewtypeProperty(t, name)
 		}
 	case Module:
 		expr.typing, _ = t.GetOwned(name)
@@ -184,9 +188,18 @@ func typeCheckPropertyAccess(p *Parser, expr *PropertyAccessExpression) {
 	}
 	if expr.typing == nil {
 		p.error(expr.Property, PropertyDoesNotExist, name, expr.Expr.Type())
+		p.suggestName(property, getMemberNames(expr.Expr.Type()))
 		expr.typing = Invalid{}
 	}
 }
+
+// Newtypes are unwrapped with '.value'
+func getNewtypeProperty(alias TypeAlias, name string) ExpressionType {
+	if name == "value" {
+		return alias.Ref.(Newtype).Underlying
+	}
+	return alias.Methods[name]
+}
 func reportPrivateFromOtherModule(p *Parser, expr *PropertyAccessExpression) bool {
 	i, ok := expr.Property.(*Identifier)
 	if !ok {
@@ -139,3 +139,29 @@ func TestListMethodAccess(t *testing.T) {
 	expr.typeCheck(parser)
 	testParserErrors(t, parser, 0)
 }
+
+func TestNewtypePropertyAccess(t *testing.T) {
+	source := "_UserId :: new number\n"
+	source += "(id _UserId).double :: () => { _UserId{id.value * 2} }\n"
+	source += "_x := _UserId{21}\n"
+	source += "_id := _x.double()\n"
+	source += "_n := _id.value + 1"
+	program, errors := ParseProgram(strings.NewReader(source), "")
+	if len(errors) != 0 {
+		t.Fatalf("Expected no errors, got %#v", errors)
+	}
+	n, _ := program.Scope().Find("_n")
+	if _, ok := n.Typing.(Number); !ok {
+		t.Fatalf("Expected number, got %v", n.Typing.Text())
+	}
+}
+
+func TestNewtypeMissingMethod(t *testing.T) {
+	source := "_UserId :: new number\n"
+	source += "_id := _UserId{21}\n"
+	source += "_n := _id.double()"
+	_, errors := ParseProgram(strings.NewReader(source), "")
+	if len(errors) != 1 || errors[0].Kind != PropertyDoesNotExist {
+		t.Fatalf("Expected PropertyDoesNotExist, got %#v", errors)
+	}
+}
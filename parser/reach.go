@@ -0,0 +1,422 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// Which top-level statements of a project are needed at runtime.
+//
+// Statements with side effects are always needed. From them, references to
+// top-level names are followed across modules ('use' directives), and
+// methods are kept when their type is needed and their name is accessed
+// somewhere in the needed code.
+type Reachability struct {
+	programs   map[string]Program
+	entries    []string
+	imports    map[string]map[string]reference // names brought by 'use', by module
+	stars      map[string]map[string]string    // modules brought by 'use * as', by module
+	decls      map[string]map[string][]Node    // top-level declarations, by module
+	methods    map[string]map[string][]Node    // method declarations by type name, by module
+	nodes      map[Node]bool                   // reached statements
+	names      map[reference]bool              // reached top-level names
+	properties map[string]bool                 // property names accessed by reached code
+	used       map[reference]bool              // names used by other modules
+}
+
+type reference struct {
+	path string
+	name string
+}
+
+// Analyze the programs of a project, which are used by the given entries
+func AnalyzeReachability(programs []Program, entries []string) *Reachability {
+	r := &Reachability{
+		programs:   map[string]Program{},
+		imports:    map[string]map[string]reference{},
+		stars:      map[string]map[string]string{},
+		decls:      map[string]map[string][]Node{},
+		methods:    map[string]map[string][]Node{},
+		nodes:      map[Node]bool{},
+		names:      map[reference]bool{},
+		properties: map[string]bool{},
+		used:       map[reference]bool{},
+	}
+	for _, entry := range entries {
+		r.entries = append(r.entries, filepath.Clean(entry))
+	}
+	for _, program := range programs {
+		r.index(program)
+	}
+	for _, program := range programs {
+		r.findUses(program)
+	}
+	for _, program := range programs {
+		for _, node := range program.nodes {
+			if r.isRoot(node) {
+				r.reachNode(program.path, node)
+			}
+		}
+	}
+	// entries' exports are the API of the project, used by JavaScript
+	for _, entry := range r.entries {
+		for _, name := range r.getExports(entry) {
+			r.reachName(reference{entry, name})
+		}
+	}
+	r.reachMethods()
+	return r
+}
+
+// Is the top-level statement needed?
+func (r *Reachability) IsReachable(node Node) bool {
+	if _, ok := node.(*UseDirective); ok {
+		return true
+	}
+	return r.nodes[node]
+}
+
+// Is the top-level name of the module at path needed?
+func (r *Reachability) IsUsed(path string, name string) bool {
+	return r.names[reference{filepath.Clean(path), name}]
+}
+
+// Public names that no other module uses (entries excluded)
+func (r *Reachability) UnusedExports() []Diagnostic {
+	paths := make([]string, 0, len(r.programs))
+	for path := range r.programs {
+		if !slices.Contains(r.entries, path) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	diagnostics := []Diagnostic{}
+	for _, path := range paths {
+		scope := r.programs[path].scope
+		for _, name := range r.getExports(path) {
+			if r.used[reference{path, name}] {
+				continue
+			}
+			start := scope.FindLocal(name).declaredAt.Start
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: InfoSeverity,
+				Code:     "unused-export",
+				Message:  fmt.Sprintf("'%v' is exported but never used", name),
+				Location: Location{
+					Path: path,
+					Loc:  Loc{Start: start, End: Position{Line: start.Line, Col: start.Col + len(name)}},
+				},
+				Notes: []string{"prefix it with '_' to make it private"},
+			})
+		}
+	}
+	return diagnostics
+}
+
+// Public names declared by the module (not brought by 'use' or 'extern')
+func (r *Reachability) getExports(path string) []string {
+	exports := []string{}
+	program, ok := r.programs[path]
+	if !ok {
+		// declaration files are not analyzed
+		return exports
+	}
+	for _, name := range program.scope.Names() {
+		if name[0] == '_' {
+			continue
+		}
+		if _, ok := r.imports[path][name]; ok {
+			continue
+		}
+		if _, ok := r.stars[path][name]; ok {
+			continue
+		}
+		if isExtern(r.decls[path][name]) {
+			continue
+		}
+		exports = append(exports, name)
+	}
+	return exports
+}
+
+func isExtern(declarations []Node) bool {
+	if len(declarations) != 1 {
+		return false
+	}
+	_, ok := declarations[0].(*ExternDeclaration)
+	return ok
+}
+
+func (r *Reachability) index(program Program) {
+	path := filepath.Clean(program.path)
+	program.path = path
+	r.programs[path] = program
+	r.imports[path] = map[string]reference{}
+	r.stars[path] = map[string]string{}
+	r.decls[path] = map[string][]Node{}
+	r.methods[path] = map[string][]Node{}
+	for _, node := range program.nodes {
+		switch node := node.(type) {
+		case *UseDirective:
+			r.indexUse(path, node)
+		case *ExternDeclaration:
+			if node.Name != nil {
+				r.decls[path][node.Name.Text()] = []Node{node}
+			}
+		case *Assignment:
+			kind := node.Operator.Kind()
+			if kind != Declare && kind != Define {
+				continue
+			}
+			if t, ok := getReceiverTypeName(node.Pattern); ok {
+				r.methods[path][t] = append(r.methods[path][t], node)
+				continue
+			}
+			for _, name := range getDeclaredNames(node.Pattern) {
+				r.decls[path][name] = append(r.decls[path][name], node)
+			}
+		}
+	}
+}
+
+func (r *Reachability) indexUse(path string, u *UseDirective) {
+	if u.Source == nil || u.Names == nil {
+		return
+	}
+	source := u.Source.Text()
+	source = source[1 : len(source)-1]
+	resolved, ok := ResolvePath(path, source)
+	if ok {
+		resolved = filepath.Clean(resolved)
+	} else {
+		// std libs (and missing modules) have no program, but their names
+		// are still brought by 'use'
+		resolved = source
+	}
+	for _, name := range getDeclaredNames(u.Names) {
+		if u.Star {
+			r.stars[path][name] = resolved
+		} else {
+			r.imports[path][name] = reference{resolved, name}
+		}
+	}
+}
+
+// Record which names of other modules the program uses, reached or not
+func (r *Reachability) findUses(program Program) {
+	path := filepath.Clean(program.path)
+	for _, ref := range r.imports[path] {
+		r.used[ref] = true
+	}
+	for _, node := range program.nodes {
+		if _, ok := node.(*UseDirective); ok {
+			continue
+		}
+		r.visitReferences(path, node, func(ref reference) {
+			if ref.path != path {
+				r.used[ref] = true
+			}
+		}, func(string) {})
+	}
+}
+
+// Statements that must run even if nothing reads what they declare.
+// Declarations that cannot be attributed to a name are kept as well.
+func (r *Reachability) isRoot(node Node) bool {
+	switch node := node.(type) {
+	case *UseDirective, *ExternDeclaration:
+		return false
+	case *Assignment:
+		kind := node.Operator.Kind()
+		if kind != Declare && kind != Define {
+			return true
+		}
+		if _, ok := getReceiverTypeName(node.Pattern); ok {
+			return false
+		}
+		if len(getDeclaredNames(node.Pattern)) == 0 {
+			return true
+		}
+		return hasSideEffects(node.Value)
+	default:
+		return true
+	}
+}
+
+func (r *Reachability) reachNode(path string, node Node) {
+	if r.nodes[node] {
+		return
+	}
+	r.nodes[node] = true
+	r.visitReferences(path, node, r.reachName, func(name string) {
+		r.properties[name] = true
+	})
+}
+
+func (r *Reachability) reachName(ref reference) {
+	if r.names[ref] {
+		return
+	}
+	r.names[ref] = true
+	if imported, ok := r.imports[ref.path][ref.name]; ok {
+		r.reachName(imported)
+	}
+	for _, node := range r.decls[ref.path][ref.name] {
+		r.reachNode(ref.path, node)
+	}
+}
+
+// Reach the methods of reached types whose name is accessed, until
+// no new method is found (reached methods can access other properties).
+func (r *Reachability) reachMethods() {
+	for changed := true; changed; {
+		changed = false
+		for path, types := range r.methods {
+			for t, methods := range types {
+				if !r.names[reference{path, t}] {
+					continue
+				}
+				for _, node := range methods {
+					pattern := node.(*Assignment).Pattern.(*PropertyAccessExpression)
+					name, ok := pattern.Property.(*Identifier)
+					if r.nodes[node] || ok && !r.properties[name.Text()] {
+						continue
+					}
+					r.reachNode(path, node)
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// Call found() for each top-level name referenced in the node, and
+// accessed() for each accessed property name.
+// Names of modules brought by 'use * as x' are followed to the module's
+// exported names.
+func (r *Reachability) visitReferences(path string, node Node, found func(reference), accessed func(string)) {
+	scope := r.programs[path].scope
+	Walk(node, func(n Node, skip func()) {
+		switch n := n.(type) {
+		case *PropertyAccessExpression:
+			property, ok := n.Property.(*Identifier)
+			if !ok {
+				return
+			}
+			accessed(property.Text())
+			identifier, ok := n.Expr.(*Identifier)
+			if !ok || !isTopLevelReference(scope, identifier) {
+				return
+			}
+			if module, ok := r.stars[path][identifier.Text()]; ok {
+				found(reference{module, property.Text()})
+				skip()
+			}
+		case *Identifier:
+			if !isTopLevelReference(scope, n) {
+				return
+			}
+			module, ok := r.stars[path][n.Text()]
+			if !ok {
+				found(reference{path, n.Text()})
+				return
+			}
+			// the module is used as a whole
+			for _, name := range r.getExports(module) {
+				found(reference{module, name})
+			}
+		}
+	})
+}
+
+// Identifiers are resolved by the checker, except in some declarations:
+// unresolved identifiers are assumed to refer to the top-level name.
+func isTopLevelReference(scope *Scope, identifier *Identifier) bool {
+	if identifier.scope != nil && identifier.scope != scope {
+		return false
+	}
+	v := scope.FindLocal(identifier.Text())
+	return v != nil && v.declaredAt != identifier.Loc()
+}
+
+// Names declared by a pattern like 'a', 'a, b' or 'Type[T]'
+func getDeclaredNames(pattern Expression) []string {
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		return []string{pattern.Text()}
+	case *Param:
+		if pattern.Identifier == nil {
+			return nil
+		}
+		return []string{pattern.Identifier.Text()}
+	case *ComputedAccessExpression:
+		return getDeclaredNames(pattern.Expr)
+	case *TupleExpression:
+		names := []string{}
+		for _, element := range pattern.Elements {
+			names = append(names, getDeclaredNames(element)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+// Get the name of the receiver's type in a method pattern like
+// '(p Type).method'
+func getReceiverTypeName(pattern Expression) (string, bool) {
+	p, ok := pattern.(*PropertyAccessExpression)
+	if !ok {
+		return "", false
+	}
+	paren, ok := p.Expr.(*ParenthesizedExpression)
+	if !ok {
+		return "", false
+	}
+	param, ok := paren.Expr.(*Param)
+	if !ok {
+		return "", false
+	}
+	expr := param.Complement
+	for {
+		switch e := expr.(type) {
+		case *UnaryExpression:
+			expr = e.Operand
+		case *ComputedAccessExpression:
+			expr = e.Expr
+		case *Identifier:
+			return e.Text(), true
+		default:
+			return "", false
+		}
+	}
+}
+
+// Could evaluating the expression do anything else than producing a value?
+// Function bodies are not evaluated.
+func hasSideEffects(expr Expression) bool {
+	if expr == nil {
+		return false
+	}
+	found := false
+	Walk(expr, func(n Node, skip func()) {
+		switch n := n.(type) {
+		case *FunctionExpression:
+			skip()
+		case *CallExpression:
+			found = true
+			skip()
+		case *Assignment:
+			found = true
+			skip()
+		case *UnaryExpression:
+			if n.Operator.Kind() == AwaitKeyword {
+				found = true
+				skip()
+			}
+		}
+	})
+	return found
+}
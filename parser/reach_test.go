@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseModule(t *testing.T, dir string, name string, source string) Program {
+	path := filepath.Join(dir, name)
+	os.WriteFile(path, []byte(source), 0644)
+	program, errors := ParseProgram(strings.NewReader(source), path)
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors in %v: %#v", name, errors)
+	}
+	ExportProgram(path, program)
+	return program
+}
+
+func TestReachability(t *testing.T) {
+	dir := t.TempDir()
+	lib := parseModule(t, dir, "lib", strings.Join([]string{
+		"Point :: { x number }",
+		"(p Point).get :: () => number { p.x }",
+		"(p Point).unused :: () => number { p.x }",
+		"Unused :: { x number }",
+		"double :: (a number) => { a * 2 }",
+		"triple :: (a number) => { a * 3 }",
+		"side :: () => number { 1 }",
+		"value := side()",
+	}, "\n"))
+	main := parseModule(t, dir, "main", strings.Join([]string{
+		"use Point, triple from \"./lib\"",
+		"use * as lib from \"./lib\"",
+		"p := Point{x: 1}",
+		"_n :: lib.double(p.get())",
+	}, "\n"))
+
+	r := AnalyzeReachability([]Program{lib, main}, []string{main.path})
+	expected := []bool{true, true, false, false, true, false, true, true}
+	for i, node := range lib.Nodes() {
+		if r.IsReachable(node) != expected[i] {
+			t.Errorf("statement %v: expected reachable to be %v", i+1, expected[i])
+		}
+	}
+	if !r.IsUsed(main.path, "Point") || r.IsUsed(main.path, "triple") {
+		t.Errorf("expected only used imports to be reached")
+	}
+
+	unused := []string{}
+	for _, d := range r.UnusedExports() {
+		unused = append(unused, d.Message)
+	}
+	expectedUnused := []string{
+		"'Unused' is exported but never used",
+		"'side' is exported but never used",
+		"'value' is exported but never used",
+	}
+	if strings.Join(unused, "\n") != strings.Join(expectedUnused, "\n") {
+		t.Fatalf("expected unused exports %v, got %v", expectedUnused, unused)
+	}
+}
+
+func TestReachabilityOfEntries(t *testing.T) {
+	dir := t.TempDir()
+	lib := parseModule(t, dir, "lib", strings.Join([]string{
+		"use log from \"io\"",
+		"square :: (n number) => { n * n }",
+		"_cube :: (n number) => { n * n * n }",
+		"log(2)",
+	}, "\n"))
+
+	r := AnalyzeReachability([]Program{lib}, []string{lib.path})
+	expected := []bool{true, true, false, true}
+	for i, node := range lib.Nodes() {
+		if r.IsReachable(node) != expected[i] {
+			t.Errorf("statement %v: expected reachable to be %v", i+1, expected[i])
+		}
+	}
+
+	other := AnalyzeReachability([]Program{lib}, nil)
+	for _, d := range other.UnusedExports() {
+		if strings.Contains(d.Message, "'log'") {
+			t.Fatalf("names brought by 'use' should not be reported as exports")
+		}
+	}
+}
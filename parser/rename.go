@@ -0,0 +1,385 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+)
+
+// A replacement of the text at Loc in the file at Path
+type TextEdit struct {
+	Path    string `json:"path"`
+	Loc     Loc    `json:"loc"`
+	NewText string `json:"newText"`
+}
+
+// A member (field or method) of a type alias
+type memberSymbol struct {
+	alias TypeAlias
+	name  string
+}
+
+var validIdentifier = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+
+// Compute the edits needed to rename the symbol at the given position,
+// across all given programs (typically every file of the compile graph).
+// Returns an error if the renaming would break the program.
+func Rename(programs []Program, path string, pos Position, newName string) ([]TextEdit, *ParserError) {
+	i := slices.IndexFunc(programs, func(p Program) bool { return p.path == path })
+	if i == -1 {
+		return nil, nil
+	}
+	program := programs[i]
+	node, ancestors := program.NodeAt(pos)
+	identifier, ok := node.(*Identifier)
+	if !ok {
+		return nil, nil
+	}
+	if err := validateNewName(identifier, newName); err != nil {
+		return nil, err
+	}
+
+	if member, ok := program.findMemberSymbol(identifier, ancestors); ok {
+		return renameMember(programs, identifier, member, newName)
+	}
+	if name, declaration, ok := program.resolveMember(identifier, ancestors); ok {
+		return renameVariable(programs, identifier, name, *declaration, newName)
+	}
+	v, name := program.findVariable(pos)
+	if v == nil || v.scope == &std {
+		return nil, nil
+	}
+	return renameVariable(programs, identifier, name, program.resolveDeclaration(v, name), newName)
+}
+
+func validateNewName(identifier *Identifier, newName string) *ParserError {
+	if !validIdentifier.MatchString(newName) || newName == "_" {
+		return &ParserError{Node: identifier, Kind: IdentifierExpected}
+	}
+	if makeToken(newName, Loc{}).Kind() != Name {
+		return &ParserError{Node: identifier, Kind: ReservedName, Complements: [2]interface{}{newName}}
+	}
+	renamed := &Identifier{Token: literal{kind: Name, value: newName}}
+	if identifier.IsType() && !renamed.IsType() {
+		return &ParserError{Node: identifier, Kind: TypeIdentifierExpected}
+	}
+	if !identifier.IsType() && renamed.IsType() {
+		return &ParserError{Node: identifier, Kind: ValueIdentifierExpected}
+	}
+	return nil
+}
+
+// Find the field or method referenced by the identifier, if any
+func (p Program) findMemberSymbol(identifier *Identifier, ancestors []Node) (memberSymbol, bool) {
+	if len(ancestors) == 0 {
+		return memberSymbol{}, false
+	}
+	name := identifier.Text()
+	switch parent := ancestors[len(ancestors)-1].(type) {
+	case *PropertyAccessExpression:
+		if parent.Property != identifier || parent.Expr == nil {
+			return memberSymbol{}, false
+		}
+		alias, ok := deref(parent.Expr.Type()).(TypeAlias)
+		return memberSymbol{alias, name}, ok
+	case *Param:
+		if parent.Identifier != identifier {
+			return memberSymbol{}, false
+		}
+		alias, ok := p.findDefinedAlias(ancestors)
+		return memberSymbol{alias, name}, ok
+	case *Entry:
+		if parent.Key != identifier {
+			return memberSymbol{}, false
+		}
+		if alias, ok := p.findDefinedAlias(ancestors); ok {
+			return memberSymbol{alias, name}, true
+		}
+		if len(ancestors) < 4 {
+			return memberSymbol{}, false
+		}
+		instance, ok := ancestors[len(ancestors)-4].(*InstanceExpression)
+		if !ok {
+			return memberSymbol{}, false
+		}
+		alias, ok := instance.Type().(TypeAlias)
+		return memberSymbol{alias, name}, ok
+	}
+	return memberSymbol{}, false
+}
+
+// Get the alias defined by a struct definition like 'Type :: { field type }',
+// with ancestors ending with: Assignment, BracedExpression, TupleExpression, *Param or *Entry
+func (p Program) findDefinedAlias(ancestors []Node) (TypeAlias, bool) {
+	if len(ancestors) < 4 {
+		return TypeAlias{}, false
+	}
+	assignment, ok := ancestors[len(ancestors)-4].(*Assignment)
+	if !ok || assignment.Operator.Kind() != Define {
+		return TypeAlias{}, false
+	}
+	if _, ok := assignment.Value.(*BracedExpression); !ok {
+		return TypeAlias{}, false
+	}
+	pattern, ok := assignment.Pattern.(*Identifier)
+	if !ok {
+		return TypeAlias{}, false
+	}
+	v := p.scope.FindLocal(pattern.Text())
+	if v == nil {
+		return TypeAlias{}, false
+	}
+	t, ok := v.Typing.(Type)
+	if !ok {
+		return TypeAlias{}, false
+	}
+	alias, ok := t.Value.(TypeAlias)
+	return alias, ok
+}
+
+func isSameAlias(a TypeAlias, b TypeAlias) bool {
+	return a.Name == b.Name && a.From == b.From
+}
+
+func renameMember(programs []Program, identifier *Identifier, member memberSymbol, newName string) ([]TextEdit, *ParserError) {
+	if member.alias.From == "" || member.alias.Name == "" {
+		// built-in types cannot be modified
+		return nil, nil
+	}
+	if identifier.IsPrivate() != (newName[0] == '_') {
+		return nil, &ParserError{
+			Node:        identifier,
+			Kind:        PrivateProperty,
+			Complements: [2]interface{}{newName, member.alias.From},
+		}
+	}
+	if hasMember(member.alias, newName) {
+		return nil, &ParserError{
+			Node:        identifier,
+			Kind:        DuplicateIdentifier,
+			Complements: [2]interface{}{newName},
+		}
+	}
+	edits := []TextEdit{}
+	for _, program := range programs {
+		for _, loc := range program.findMemberLocations(member) {
+			edits = append(edits, TextEdit{program.path, loc, newName})
+		}
+	}
+	return edits, nil
+}
+
+func hasMember(alias TypeAlias, name string) bool {
+	if _, ok := alias.Methods[name]; ok {
+		return true
+	}
+	object, ok := alias.Ref.(Object)
+	if !ok {
+		return false
+	}
+	_, ok = object.GetOwned(name)
+	return ok
+}
+
+func (p Program) findMemberLocations(member memberSymbol) []Loc {
+	locations := []Loc{}
+	for _, node := range p.nodes {
+		Walk(node, func(n Node, skip func()) {
+			switch n := n.(type) {
+			case *PropertyAccessExpression:
+				property, ok := n.Property.(*Identifier)
+				if !ok || n.Expr == nil || property.Text() != member.name {
+					return
+				}
+				alias, ok := deref(n.Expr.Type()).(TypeAlias)
+				if ok && isSameAlias(alias, member.alias) {
+					locations = append(locations, property.Loc())
+				}
+			case *InstanceExpression:
+				alias, ok := n.Type().(TypeAlias)
+				if ok && isSameAlias(alias, member.alias) {
+					locations = append(locations, findKeyLocations(n.Args, member.name)...)
+				}
+			case *Assignment:
+				if p.path != member.alias.From || n.Operator.Kind() != Define {
+					return
+				}
+				pattern, ok := n.Pattern.(*Identifier)
+				if !ok || pattern.Text() != member.alias.Name {
+					return
+				}
+				if def, ok := n.Value.(*BracedExpression); ok {
+					locations = append(locations, findKeyLocations(def, member.name)...)
+				}
+			}
+		})
+	}
+	return locations
+}
+
+// Find fields named 'name' in braces like '{ name type }' or '{ name: value }'
+func findKeyLocations(b *BracedExpression, name string) []Loc {
+	locations := []Loc{}
+	if b == nil {
+		return locations
+	}
+	tuple, ok := b.Expr.(*TupleExpression)
+	if !ok {
+		return locations
+	}
+	for _, element := range tuple.Elements {
+		var key Expression
+		switch element := element.(type) {
+		case *Param:
+			key = element.Identifier
+		case *Entry:
+			key = element.Key
+		}
+		identifier, ok := key.(*Identifier)
+		if ok && identifier.Text() == name {
+			locations = append(locations, identifier.Loc())
+		}
+	}
+	return locations
+}
+
+func renameVariable(programs []Program, identifier *Identifier, name string, declaration Location, newName string) ([]TextEdit, *ParserError) {
+	edits := []TextEdit{}
+	usedElsewhere := false
+	for _, program := range programs {
+		if err := program.checkVariableCollisions(declaration, name, newName); err != nil {
+			return nil, err
+		}
+		for _, loc := range program.findVariableLocations(declaration, name) {
+			edits = append(edits, TextEdit{program.path, loc, newName})
+			usedElsewhere = usedElsewhere || program.path != declaration.Path
+		}
+	}
+
+	if identifier.IsPrivate() == (newName[0] == '_') {
+		return edits, nil
+	}
+	i := slices.IndexFunc(programs, func(p Program) bool { return p.path == declaration.Path })
+	if i == -1 {
+		return edits, nil
+	}
+	v := programs[i].scope.FindLocal(name)
+	if v == nil || v.declaredAt != declaration.Loc {
+		// not a top-level declaration, privacy does not apply
+		return edits, nil
+	}
+	if usedElsewhere {
+		return nil, &ParserError{
+			Node:        identifier,
+			Kind:        PrivateProperty,
+			Complements: [2]interface{}{newName, declaration.Path},
+		}
+	}
+	if newName[0] != '_' && !v.constant {
+		return nil, &ParserError{Node: identifier, Kind: PublicDeclaration}
+	}
+	return edits, nil
+}
+
+// Report a DuplicateIdentifier error if the new name would change which
+// variable an identifier refers to: if a scope declaring the variable already
+// has a variable with the new name, if a reference would be shadowed by a
+// variable with the new name, or if a variable with the new name would be
+// captured by the renamed one.
+func (p Program) checkVariableCollisions(declaration Location, name string, newName string) *ParserError {
+	declaring := map[*Scope]bool{}
+	for _, scope := range p.scopes {
+		for name, v := range scope.variables {
+			if p.resolveDeclaration(v, name) != declaration {
+				continue
+			}
+			if scope.FindLocal(newName) != nil {
+				return &ParserError{
+					Node:        &Identifier{Token: literal{kind: Name, value: name, loc: v.declaredAt}},
+					Kind:        DuplicateIdentifier,
+					Complements: [2]interface{}{newName},
+				}
+			}
+			declaring[scope] = true
+		}
+	}
+	var err *ParserError
+	for _, node := range p.nodes {
+		Walk(node, func(n Node, skip func()) {
+			identifier, ok := n.(*Identifier)
+			if !ok || err != nil || identifier.scope == nil || identifier.usedIn == nil {
+				return
+			}
+			switch identifier.Text() {
+			case name:
+				v := identifier.scope.FindLocal(name)
+				if v == nil || p.resolveDeclaration(v, name) != declaration {
+					return
+				}
+				for _, scope := range identifier.getScopesBetween() {
+					if scope.FindLocal(newName) != nil {
+						err = &ParserError{Node: identifier, Kind: DuplicateIdentifier, Complements: [2]interface{}{newName}}
+					}
+				}
+			case newName:
+				for _, scope := range identifier.getScopesBetween() {
+					if declaring[scope] {
+						err = &ParserError{Node: identifier, Kind: DuplicateIdentifier, Complements: [2]interface{}{newName}}
+					}
+				}
+			}
+		})
+	}
+	return err
+}
+
+// Scopes between the one where the identifier is used (included) and the
+// one declaring its variable (excluded)
+func (i *Identifier) getScopesBetween() []*Scope {
+	scopes := []*Scope{}
+	for scope := i.usedIn; scope != nil && scope != i.scope; scope = scope.outer {
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// Find all identifiers (declarations, reads and writes) referring to the declaration
+func (p Program) findVariableLocations(declaration Location, name string) []Loc {
+	locations := []Loc{}
+	add := func(loc Loc) {
+		if !slices.Contains(locations, loc) {
+			locations = append(locations, loc)
+		}
+	}
+	for _, scope := range p.scopes {
+		v := scope.FindLocal(name)
+		if v == nil || p.resolveDeclaration(v, name) != declaration {
+			continue
+		}
+		// declaredAt may span a whole param, like 'name type'
+		start := v.declaredAt.Start
+		add(Loc{start, Position{start.Line, start.Col + len(name)}})
+	}
+	for _, node := range p.nodes {
+		Walk(node, func(n Node, skip func()) {
+			switch n := n.(type) {
+			case *Identifier:
+				if n.scope == nil || n.Text() != name {
+					return
+				}
+				v := n.scope.FindLocal(name)
+				if v != nil && p.resolveDeclaration(v, name) == declaration {
+					add(n.Loc())
+				}
+			case *PropertyAccessExpression:
+				if n.Property == nil {
+					return
+				}
+				_, d, ok := p.resolveMember(n.Property, []Node{n})
+				if ok && *d == declaration {
+					add(n.Property.Loc())
+				}
+			}
+		})
+	}
+	return locations
+}
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenameLocalVariable(t *testing.T) {
+	program, _ := ParseProgram(strings.NewReader("_a := 1\n_b := _a + 2"), "main")
+	edits, err := Rename([]Program{program}, "main", Position{2, 7}, "_c")
+	if err != nil {
+		t.Fatalf("Expected no errors, got %v", err.Text())
+	}
+	expected := []TextEdit{
+		{"main", Loc{Position{1, 1}, Position{1, 3}}, "_c"},
+		{"main", Loc{Position{2, 7}, Position{2, 9}}, "_c"},
+	}
+	if len(edits) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, edits)
+	}
+	for _, edit := range expected {
+		if !slices.Contains(edits, edit) {
+			t.Fatalf("Expected edit %v, got %v", edit, edits)
+		}
+	}
+}
+
+func TestRenameImported(t *testing.T) {
+	lib, _ := ParseProgram(strings.NewReader("answer :: 42\n_b := answer"), "/project/lib")
+	ExportProgram("/project/lib", lib)
+
+	source := "use answer from \"./lib\"\n"
+	source += "use * as lib from \"./lib\"\n"
+	source += "_a := answer + lib.answer"
+	program, _ := ParseProgram(strings.NewReader(source), "/project/main")
+
+	edits, err := Rename([]Program{lib, program}, "/project/lib", Position{1, 1}, "response")
+	if err != nil {
+		t.Fatalf("Expected no errors, got %v", err.Text())
+	}
+	if len(edits) != 5 {
+		t.Fatalf("Expected 5 edits (declaration, read, use, read, member), got %v", edits)
+	}
+	edit := TextEdit{"/project/main", Loc{Position{3, 20}, Position{3, 26}}, "response"}
+	if !slices.Contains(edits, edit) {
+		t.Fatalf("Expected edit %v, got %v", edit, edits)
+	}
+}
+
+func TestRenameField(t *testing.T) {
+	source := "Point :: { x number, y number }\n"
+	source += "_p := Point{ x: 1, y: 2 }\n"
+	source += "_x := _p.x"
+	program, _ := ParseProgram(strings.NewReader(source), "main")
+
+	edits, err := Rename([]Program{program}, "main", Position{3, 10}, "z")
+	if err != nil {
+		t.Fatalf("Expected no errors, got %v", err.Text())
+	}
+	expected := []TextEdit{
+		{"main", Loc{Position{1, 12}, Position{1, 13}}, "z"},
+		{"main", Loc{Position{2, 14}, Position{2, 15}}, "z"},
+		{"main", Loc{Position{3, 10}, Position{3, 11}}, "z"},
+	}
+	if len(edits) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, edits)
+	}
+	for _, edit := range expected {
+		if !slices.Contains(edits, edit) {
+			t.Fatalf("Expected edit %v, got %v", edit, edits)
+		}
+	}
+}
+
+func TestRenameMethod(t *testing.T) {
+	source := "Point :: { x number }\n"
+	source += "(p Point).getX :: () => { p.x }\n"
+	source += "_p := Point{ x: 1 }\n"
+	source += "_x := _p.getX()"
+	program, _ := ParseProgram(strings.NewReader(source), "main")
+
+	edits, err := Rename([]Program{program}, "main", Position{4, 10}, "getAbscissa")
+	if err != nil {
+		t.Fatalf("Expected no errors, got %v", err.Text())
+	}
+	expected := []TextEdit{
+		{"main", Loc{Position{2, 11}, Position{2, 15}}, "getAbscissa"},
+		{"main", Loc{Position{4, 10}, Position{4, 14}}, "getAbscissa"},
+	}
+	if len(edits) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, edits)
+	}
+	for _, edit := range expected {
+		if !slices.Contains(edits, edit) {
+			t.Fatalf("Expected edit %v, got %v", edit, edits)
+		}
+	}
+}
+
+func TestRenameCollision(t *testing.T) {
+	program, _ := ParseProgram(strings.NewReader("_a := 1\n_b := _a + 2"), "main")
+	_, err := Rename([]Program{program}, "main", Position{2, 7}, "_b")
+	if err == nil || err.Kind != DuplicateIdentifier {
+		t.Fatalf("Expected DuplicateIdentifier error, got %#v", err)
+	}
+
+	source := "Point :: { x number, y number }\n_p := Point{ x: 1, y: 2 }"
+	program, _ = ParseProgram(strings.NewReader(source), "main")
+	_, err = Rename([]Program{program}, "main", Position{1, 12}, "y")
+	if err == nil || err.Kind != DuplicateIdentifier {
+		t.Fatalf("Expected DuplicateIdentifier error, got %#v", err)
+	}
+}
+
+func TestRenameShadowed(t *testing.T) {
+	source := "_f :: (x number) => {\n"
+	source += "    if x > 0 {\n"
+	source += "        y := 1\n"
+	source += "        x + y\n"
+	source += "    } else { 0 }\n"
+	source += "}"
+	program, _ := ParseProgram(strings.NewReader(source), "main")
+	_, err := Rename([]Program{program}, "main", Position{1, 8}, "y")
+	if err == nil || err.Kind != DuplicateIdentifier {
+		t.Fatalf("Expected DuplicateIdentifier error, got %#v", err)
+	}
+}
+
+func TestRenameCapture(t *testing.T) {
+	source := "_y := 1\n"
+	source += "_f :: (x number) => { x + _y }"
+	program, _ := ParseProgram(strings.NewReader(source), "main")
+	_, err := Rename([]Program{program}, "main", Position{2, 8}, "_y")
+	if err == nil || err.Kind != DuplicateIdentifier {
+		t.Fatalf("Expected DuplicateIdentifier error, got %#v", err)
+	}
+	_, err = Rename([]Program{program}, "main", Position{2, 8}, "_z")
+	if err != nil {
+		t.Fatalf("Expected no errors, got %v", err.Text())
+	}
+}
+
+func TestRenamePrivacy(t *testing.T) {
+	lib, _ := ParseProgram(strings.NewReader("answer :: 42\n_b := answer"), "/project/lib")
+	ExportProgram("/project/lib", lib)
+	program, _ := ParseProgram(strings.NewReader("use answer from \"./lib\"\n_a := answer"), "/project/main")
+
+	_, err := Rename([]Program{lib, program}, "/project/lib", Position{1, 1}, "_answer")
+	if err == nil || err.Kind != PrivateProperty {
+		t.Fatalf("Expected PrivateProperty error, got %#v", err)
+	}
+}
+
+func TestRenameInvalidName(t *testing.T) {
+	program, _ := ParseProgram(strings.NewReader("_a := 1\n_b := _a + 2"), "main")
+	_, err := Rename([]Program{program}, "main", Position{1, 1}, "_if")
+	if err != nil {
+		t.Fatalf("Expected '_if' to be valid, got %v", err.Text())
+	}
+	_, err = Rename([]Program{program}, "main", Position{1, 1}, "_my_name")
+	if err != nil {
+		t.Fatalf("Expected '_my_name' to be valid, got %v", err.Text())
+	}
+	_, err = Rename([]Program{program}, "main", Position{1, 1}, "if")
+	if err == nil || err.Kind != ReservedName {
+		t.Fatalf("Expected ReservedName error, got %#v", err)
+	}
+	_, err = Rename([]Program{program}, "main", Position{1, 1}, "Type")
+	if err == nil || err.Kind != ValueIdentifierExpected {
+		t.Fatalf("Expected ValueIdentifierExpected error, got %#v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package parser
 
+import "slices"
+
 type Variable struct {
 	declaredAt   Loc
 	Typing       ExpressionType
@@ -13,6 +15,10 @@ type Variable struct {
 func (v *Variable) readAt(l Loc)   { v.reads = append(v.reads, l) }
 func (v *Variable) writeAt(n Node) { v.writes = append(v.writes, n) }
 
+func (v *Variable) DeclaredAt() Loc { return v.declaredAt }
+func (v *Variable) Reads() []Loc    { return v.reads }
+func (v *Variable) Scope() *Scope   { return v.scope }
+
 func (v *Variable) Writes() []Node     { return v.writes }
 func (v *Variable) HasDirectRef() bool { return v.hasDirectRef }
 
@@ -28,6 +34,7 @@ const (
 type Scope struct {
 	id        int
 	variables map[string]*Variable
+	narrowed  map[string]narrowing // types refined by conditions, e.g. 'if x is number {}'
 	kind      ScopeKind
 	outer     *Scope
 }
@@ -60,6 +67,29 @@ func (s Scope) FindLocal(name string) *Variable {
 	return s.variables[name]
 }
 
+func (s Scope) Outer() *Scope { return s.outer }
+
+// Names of the variables declared in this scope, sorted
+func (s Scope) Names() []string {
+	names := make([]string, 0, len(s.variables))
+	for name := range s.variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Find a variable with the same name in an outer scope (excluding the
+// standard library), which the local variable would hide.
+func (s Scope) Shadowed(name string) (*Variable, bool) {
+	for scope := s.outer; scope != nil && scope != &std; scope = scope.outer {
+		if v := scope.FindLocal(name); v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
 func (s Scope) Has(name string) bool {
 	_, ok := s.variables[name]
 	if ok {
@@ -93,6 +123,44 @@ func (s *Scope) AddConstant(name string, declaredAt Loc, typing ExpressionType)
 	}
 }
 
+// A refined type is only valid as long as the variable is not written to.
+type narrowing struct {
+	typing ExpressionType
+	writes int // number of writes to the variable when the type was refined
+}
+
+// Refine the type of an outer variable inside this scope
+func (s *Scope) narrow(name string, typing ExpressionType) {
+	variable, ok := s.Find(name)
+	if !ok {
+		return
+	}
+	if s.narrowed == nil {
+		s.narrowed = map[string]narrowing{}
+	}
+	s.narrowed[name] = narrowing{typing, len(variable.writes)}
+}
+
+// Find the refined type of a variable, if any.
+// The lookup stops at the scope declaring the variable.
+// Any write since the type was refined invalidates it.
+func (s Scope) findNarrowed(name string) (ExpressionType, bool) {
+	if n, ok := s.narrowed[name]; ok {
+		variable, _ := s.Find(name)
+		if len(variable.writes) != n.writes {
+			return nil, false
+		}
+		return n.typing, true
+	}
+	if _, ok := s.variables[name]; ok {
+		return nil, false
+	}
+	if s.outer == nil {
+		return nil, false
+	}
+	return s.outer.findNarrowed(name)
+}
+
 func (s *Scope) AddMethod(name string, self TypeAlias, signature Function) {
 	t, ok := s.Find(self.Name)
 	if !ok {
@@ -121,14 +189,14 @@ func (s Scope) in(kind ScopeKind) bool {
 	return s.outer.in(kind)
 }
 
-func (s Scope) toModule() Module {
+func (s *Scope) toModule() Module {
 	o := newObject()
-	for name, v := range s.variables {
+	for _, name := range s.Names() {
 		if name[0] != '_' {
-			o.addMember(name, v.Typing)
+			o.addMember(name, s.variables[name].Typing)
 		}
 	}
-	return Module{o}
+	return Module{Object: o, scope: s}
 }
 
 // utility to create option types with different Some types
@@ -229,6 +297,20 @@ var std = Scope{
 		"?": {
 			Typing: Type{optionType},
 		},
+		"None": {
+			Typing:   optionType,
+			constant: true,
+		},
+		// Placeholder for code that is not written yet, throws when called
+		"todo": {
+			Typing:   Function{Params: &Tuple{[]ExpressionType{}}, Returned: Never{}},
+			constant: true,
+		},
+		// Marks code that should never be reached, throws when called
+		"unreachable": {
+			Typing:   Function{Params: &Tuple{[]ExpressionType{}}, Returned: Never{}},
+			constant: true,
+		},
 		"!": {
 			Typing: Type{makeResultType(nil, nil)},
 		},
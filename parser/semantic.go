@@ -0,0 +1,219 @@
+package parser
+
+// A location in a given file
+type Location struct {
+	Path string `json:"path"`
+	Loc  Loc    `json:"loc"`
+}
+
+// What the type checker knows about a position in a file
+type Symbol struct {
+	Node        Node           // innermost node at the position
+	Name        string         // name of the referenced variable, if any
+	Type        ExpressionType // nil if unknown
+	Declaration *Location      // nil if not a reference to a variable
+}
+
+func (l Loc) contains(pos Position) bool {
+	return !pos.isBefore(l.Start) && pos.isBefore(l.End)
+}
+
+// Both locations are expected to overlap
+func (l Loc) isNarrowerThan(other Loc) bool {
+	if l.Start != other.Start {
+		return other.Start.isBefore(l.Start)
+	}
+	return l.End.isBefore(other.End)
+}
+func (p Position) isBefore(other Position) bool {
+	return p.Line < other.Line || p.Line == other.Line && p.Col < other.Col
+}
+
+// Get the innermost node at the given position, along with its ancestors
+// (starting with the outermost).
+func (p Program) NodeAt(pos Position) (Node, []Node) {
+	var found Node
+	ancestors := []Node{}
+	for _, node := range p.nodes {
+		Walk(node, func(n Node, skip func()) {
+			if n == nil || !n.Loc().contains(pos) {
+				skip()
+				return
+			}
+			if found != nil {
+				ancestors = append(ancestors, found)
+			}
+			found = n
+		})
+	}
+	return found, ancestors
+}
+
+// Query what the checker knows about a given position
+func (p Program) Query(pos Position) (Symbol, bool) {
+	node, ancestors := p.NodeAt(pos)
+	if node == nil {
+		return Symbol{}, false
+	}
+	symbol := Symbol{Node: node}
+	if expr, ok := node.(Expression); ok {
+		symbol.Type = expr.Type()
+	}
+	if name, declaration, ok := p.resolveMember(node, ancestors); ok {
+		symbol.Name = name
+		symbol.Declaration = declaration
+		return symbol, true
+	}
+	v, name := p.findVariable(pos)
+	if v == nil {
+		return symbol, true
+	}
+	symbol.Name = name
+	if symbol.Type == nil {
+		symbol.Type = v.Typing
+	}
+	declaration := p.resolveDeclaration(v, name)
+	symbol.Declaration = &declaration
+	return symbol, true
+}
+
+// Find the variable declared or read at the given position.
+// If several match, the narrowest one is returned.
+func (p Program) findVariable(pos Position) (*Variable, string) {
+	var found *Variable
+	var foundName string
+	var foundLoc Loc
+	match := func(v *Variable, name string, loc Loc) {
+		if !loc.contains(pos) {
+			return
+		}
+		if found == nil || loc.isNarrowerThan(foundLoc) {
+			found, foundName, foundLoc = v, name, loc
+		}
+	}
+	for _, scope := range p.scopes {
+		for name, v := range scope.variables {
+			match(v, name, v.declaredAt)
+			for _, read := range v.reads {
+				match(v, name, read)
+			}
+		}
+	}
+	return found, foundName
+}
+
+// Get where a variable was originally declared.
+// Names imported with 'use' are resolved to their declaration in the imported file.
+func (p Program) resolveDeclaration(v *Variable, name string) Location {
+	location := Location{Path: p.path, Loc: v.declaredAt}
+	use := p.findUseDirective(v.declaredAt)
+	if use == nil || use.Star {
+		return location
+	}
+	module, ok := p.getImportedModule(use)
+	if !ok || module.scope == nil {
+		return location
+	}
+	declared := module.scope.FindLocal(name)
+	if declared == nil {
+		return location
+	}
+	return Location{Path: module.path, Loc: declared.declaredAt}
+}
+
+func (p Program) findUseDirective(loc Loc) *UseDirective {
+	for _, node := range p.nodes {
+		use, ok := node.(*UseDirective)
+		if ok && use.Source != nil && use.Loc().contains(loc.Start) {
+			return use
+		}
+	}
+	return nil
+}
+
+func (p Program) getImportedModule(use *UseDirective) (Module, bool) {
+	if use.Source == nil {
+		return Module{}, false
+	}
+	path := use.Source.Text()
+	path = path[1 : len(path)-1]
+	resolved, ok := ResolvePath(p.path, path)
+	if !ok {
+		return Module{}, false
+	}
+	module, ok := filesExports[resolved]
+	return module, ok
+}
+
+// Resolve 'module.member' when the member is hovered
+func (p Program) resolveMember(node Node, ancestors []Node) (string, *Location, bool) {
+	if len(ancestors) == 0 {
+		return "", nil, false
+	}
+	access, ok := ancestors[len(ancestors)-1].(*PropertyAccessExpression)
+	if !ok || access.Property != node {
+		return "", nil, false
+	}
+	module, ok := access.Expr.Type().(Module)
+	if !ok || module.scope == nil {
+		return "", nil, false
+	}
+	name := access.Property.(*Identifier).Text()
+	declared := module.scope.FindLocal(name)
+	if declared == nil {
+		return "", nil, false
+	}
+	return name, &Location{Path: module.path, Loc: declared.declaredAt}, true
+}
+
+// Locations of the identifiers of the variable assigned by the write
+// (e.g. 'x' in 'x.y = 2')
+func getWrittenLocs(write Node, name string, scope *Scope) []Loc {
+	a, ok := write.(*Assignment)
+	if !ok {
+		return nil
+	}
+	locs := []Loc{}
+	Walk(a.Pattern, func(n Node, skip func()) {
+		identifier, ok := n.(*Identifier)
+		if ok && identifier.Text() == name && identifier.scope == scope {
+			locs = append(locs, identifier.Loc())
+		}
+	})
+	return locs
+}
+
+// Find all references in this program to the given declaration,
+// including the declaration itself if it is in this file.
+func (p Program) References(declaration Location) []Location {
+	locations := []Location{}
+	for _, scope := range p.scopes {
+		for name, v := range scope.variables {
+			if p.resolveDeclaration(v, name) != declaration {
+				continue
+			}
+			locations = append(locations, Location{p.path, v.declaredAt})
+			for _, read := range v.reads {
+				locations = append(locations, Location{p.path, read})
+			}
+			for _, write := range v.writes {
+				for _, loc := range getWrittenLocs(write, name, scope) {
+					locations = append(locations, Location{p.path, loc})
+				}
+			}
+		}
+	}
+	for _, node := range p.nodes {
+		Walk(node, func(n Node, skip func()) {
+			access, ok := n.(*PropertyAccessExpression)
+			if !ok || access.Property == nil {
+				return
+			}
+			_, d, ok := p.resolveMember(access.Property, []Node{access})
+			if ok && *d == declaration {
+				locations = append(locations, Location{p.path, access.Property.Loc()})
+			}
+		})
+	}
+	return locations
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNodeAt(t *testing.T) {
+	program, _ := ParseProgram(strings.NewReader("_a := 1\n_b := _a + 2"), "main")
+	node, ancestors := program.NodeAt(Position{2, 8})
+	identifier, ok := node.(*Identifier)
+	if !ok || identifier.Text() != "_a" {
+		t.Fatalf("Expected identifier '_a', got %#v", node)
+	}
+	if len(ancestors) != 2 {
+		t.Fatalf("Expected assignment and binary expression as ancestors, got %#v", ancestors)
+	}
+}
+
+func TestQueryReference(t *testing.T) {
+	program, _ := ParseProgram(strings.NewReader("_a := 1\n_b := _a + 2"), "main")
+	symbol, ok := program.Query(Position{2, 8})
+	if !ok {
+		t.Fatalf("Expected symbol")
+	}
+	if symbol.Name != "_a" {
+		t.Fatalf("Expected '_a', got %v", symbol.Name)
+	}
+	if _, ok := symbol.Type.(Number); !ok {
+		t.Fatalf("Expected number, got %#v", symbol.Type)
+	}
+	expected := Location{"main", Loc{Position{1, 1}, Position{1, 3}}}
+	if symbol.Declaration == nil || *symbol.Declaration != expected {
+		t.Fatalf("Expected declaration %v, got %v", expected, symbol.Declaration)
+	}
+}
+
+func TestQueryDeclaration(t *testing.T) {
+	program, _ := ParseProgram(strings.NewReader("_a := 1\n_b := _a + 2"), "main")
+	symbol, _ := program.Query(Position{1, 2})
+	if _, ok := symbol.Type.(Number); !ok || symbol.Name != "_a" {
+		t.Fatalf("Expected '_a' of type number, got %#v", symbol)
+	}
+}
+
+func TestQueryImported(t *testing.T) {
+	lib, _ := ParseProgram(strings.NewReader("answer :: 42\n_b := answer"), "/project/lib")
+	ExportProgram("/project/lib", lib)
+
+	source := "use answer from \"./lib\"\n"
+	source += "use * as lib from \"./lib\"\n"
+	source += "_a := answer + lib.answer"
+	program, _ := ParseProgram(strings.NewReader(source), "/project/main")
+
+	expected := Location{"/project/lib", Loc{Position{1, 1}, Position{1, 7}}}
+	symbol, _ := program.Query(Position{3, 8})
+	if symbol.Declaration == nil || *symbol.Declaration != expected {
+		t.Fatalf("Expected declaration %v, got %v", expected, symbol.Declaration)
+	}
+	symbol, _ = program.Query(Position{3, 21})
+	if symbol.Declaration == nil || *symbol.Declaration != expected {
+		t.Fatalf("Expected declaration %v, got %v", expected, symbol.Declaration)
+	}
+
+	references := program.References(expected)
+	if len(references) != 3 {
+		t.Fatalf("Expected 3 references (use, read, member), got %#v", references)
+	}
+	references = lib.References(expected)
+	if len(references) != 2 {
+		t.Fatalf("Expected 2 references (declaration, read), got %#v", references)
+	}
+}
+
+func TestReferencesWrites(t *testing.T) {
+	program, _ := ParseProgram(strings.NewReader("_a := 1\n_a = 2\n_b := _a"), "main")
+	declaration := Location{"main", Loc{Position{1, 1}, Position{1, 3}}}
+	references := program.References(declaration)
+	expected := Location{"main", Loc{Position{2, 1}, Position{2, 3}}}
+	if len(references) != 3 || !slices.Contains(references, expected) {
+		t.Fatalf("Expected 3 references (declaration, write, read), got %#v", references)
+	}
+}
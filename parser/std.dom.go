@@ -26,6 +26,11 @@ func DomLib() Module {
 		Ref:  Trait{Self: Generic{Name: "Self"}, Members: map[string]ExpressionType{}},
 	}
 	domLib.addMember("Event", Type{Event})
+	domLib.addMember("EventName", Type{TypeAlias{
+		Name: "EventName",
+		From: "dom",
+		Ref:  makeEventNameType(),
+	}})
 	domLib.addMember("EventHandler", Function{
 		Params:   &Tuple{[]ExpressionType{Event}},
 		Returned: Void{},
@@ -111,6 +116,41 @@ func DomLib() Module {
 	return domLib
 }
 
+// Names of the standard events that can be listened to
+var eventNames = []string{
+	"abort", "animationend", "animationiteration", "animationstart",
+	"beforeinput", "beforeunload", "blur", "change", "click", "close",
+	"contextmenu", "copy", "cut", "dblclick", "DOMContentLoaded", "drag",
+	"dragend", "dragenter", "dragleave", "dragover", "dragstart", "drop",
+	"error", "focus", "focusin", "focusout", "hashchange", "input", "keydown",
+	"keypress", "keyup", "load", "message", "mousedown", "mouseenter",
+	"mouseleave", "mousemove", "mouseout", "mouseover", "mouseup", "offline",
+	"online", "pagehide", "pageshow", "paste", "pointercancel", "pointerdown",
+	"pointerenter", "pointerleave", "pointermove", "pointerout", "pointerover",
+	"pointerup", "popstate", "reset", "resize", "scroll", "select", "storage",
+	"submit", "touchcancel", "touchend", "touchmove", "touchstart",
+	"transitionend", "transitionstart", "unload", "visibilitychange", "wheel",
+}
+
+func makeEventNameType() ExpressionType {
+	members := make([]ExpressionType, len(eventNames))
+	for i, name := range eventNames {
+		members[i] = LiteralType{Base: String{}, Value: fmt.Sprintf("%q", name)}
+	}
+	return Union{members}
+}
+
+// Strings which are not literals cannot be checked against known event
+// names, they are accepted for other events (e.g. custom events) but reported
+func isUncheckedEventName(expected ExpressionType, received ExpressionType) bool {
+	alias, ok := expected.(TypeAlias)
+	if !ok || alias.Name != "EventName" || alias.From != "dom" {
+		return false
+	}
+	_, ok = received.(String)
+	return ok
+}
+
 func buildEventTrait() {
 	Event := getDomMember("Event")
 	EventTarget := getDomMember("EventTarget")
@@ -136,11 +176,12 @@ func buildEventTargetTrait() {
 	EventTarget := getDomMember("EventTarget")
 	EventHandler, _ := domLib.GetOwned("EventHandler")
 	Event := getDomMember("Event")
+	EventName := getDomMember("EventName")
 	methods := EventTarget.(TypeAlias).Ref.(Trait).Members
 
 	methods["addEventListener"] = Function{
 		Params: &Tuple{[]ExpressionType{
-			String{},
+			EventName,
 			EventHandler,
 		}},
 		Returned: Void{},
@@ -153,7 +194,7 @@ func buildEventTargetTrait() {
 	}
 	methods["removeEventListener"] = Function{
 		Params: &Tuple{[]ExpressionType{
-			String{},
+			EventName,
 			EventHandler,
 		}},
 		Returned: Void{},
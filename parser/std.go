@@ -1,12 +1,25 @@
 package parser
 
+// names of the modules available through getLib
+var libNames = []string{"dom", "io", "node"}
+
+// Target environments of the modules which are not available everywhere
+var libTargets = map[string]string{"dom": "browser", "node": "node"}
+
 func getLib(name string) (Module, bool) {
 	switch name {
 	case "dom":
 		return DomLib(), true
 	case "io":
 		return makeIoLib(), true
+	case "node":
+		return makeNodeLib(), true
 	default:
 		return Module{}, false
 	}
 }
+
+func isLibAvailable(name string, target string) bool {
+	t, ok := libTargets[name]
+	return !ok || t == target
+}
@@ -1,7 +1,7 @@
 package parser
 
 func makeIoLib() Module {
-	m := Module{newObject()}
+	m := Module{Object: newObject()}
 	m.addMember("log", Function{
 		Params:   &Tuple{[]ExpressionType{Invalid{}}},
 		Returned: Void{},
@@ -0,0 +1,66 @@
+package parser
+
+// Errors thrown by Node.js functions
+func nodeResult(t ExpressionType) TypeAlias {
+	return makeResultType(t, std.variables["Error"].Typing.(Type).Value)
+}
+
+func makeNodeLib() Module {
+	fs := Module{Object: newObject()}
+	fs.addMember("readFile", Function{
+		Params:   &Tuple{[]ExpressionType{String{}}},
+		Returned: nodeResult(String{}),
+	})
+	fs.addMember("writeFile", Function{
+		Params:   &Tuple{[]ExpressionType{String{}, String{}}},
+		Returned: nodeResult(Void{}),
+	})
+	fs.addMember("readDir", Function{
+		Params:   &Tuple{[]ExpressionType{String{}}},
+		Returned: nodeResult(List{String{}}),
+	})
+	fs.addMember("mkdir", Function{
+		Params:   &Tuple{[]ExpressionType{String{}}},
+		Returned: nodeResult(Void{}),
+	})
+	fs.addMember("remove", Function{
+		Params:   &Tuple{[]ExpressionType{String{}}},
+		Returned: nodeResult(Void{}),
+	})
+	fs.addMember("exists", Function{
+		Params:   &Tuple{[]ExpressionType{String{}}},
+		Returned: Boolean{},
+	})
+
+	path := Module{Object: newObject()}
+	path.addMember("join", Function{
+		Params:   &Tuple{[]ExpressionType{String{}, String{}}},
+		Returned: String{},
+	})
+	for _, name := range []string{"basename", "dirname", "extname", "resolve"} {
+		path.addMember(name, Function{
+			Params:   &Tuple{[]ExpressionType{String{}}},
+			Returned: String{},
+		})
+	}
+	path.addMember("sep", String{})
+
+	process := Module{Object: newObject()}
+	process.addMember("argv", List{String{}})
+	// environment variables, by name
+	process.addMember("env", Function{
+		Params:   &Tuple{[]ExpressionType{String{}}},
+		Returned: makeOptionType(String{}),
+	})
+	process.addMember("cwd", newGetter(String{}))
+	process.addMember("exit", Function{
+		Params:   &Tuple{[]ExpressionType{Number{}}},
+		Returned: Never{},
+	})
+
+	m := Module{Object: newObject()}
+	m.addMember("fs", fs)
+	m.addMember("path", path)
+	m.addMember("process", process)
+	return m
+}
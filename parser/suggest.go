@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Get the candidate closest to the given name, if any is close enough to
+// be a likely typo.
+func findClosest(name string, candidates []string) (string, bool) {
+	best := ""
+	bestDistance := max(1, len(name)/3) + 1
+	for _, candidate := range candidates {
+		if candidate == name || candidate == "" {
+			continue
+		}
+		d := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if d < bestDistance || d == bestDistance && best != "" && candidate < best {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best, best != ""
+}
+
+// Edit distance between two strings, where swapping two adjacent
+// characters counts as a single edit (optimal string alignment).
+func editDistance(a string, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// Attach a 'did you mean' note and fix to the last reported error,
+// if one of the candidates is close to the name.
+// The fix replaces the text at loc with replace(suggestion).
+func (p *Parser) suggest(name string, candidates []string, loc Loc, replace func(string) string) {
+	suggestion, ok := findClosest(name, candidates)
+	if !ok {
+		return
+	}
+	err := &p.errors[len(p.errors)-1]
+	err.Notes = append(err.Notes, "did you mean '"+suggestion+"'?")
+	p.fix("replace with '"+suggestion+"'", loc, replace(suggestion))
+}
+
+func (p *Parser) suggestName(identifier *Identifier, candidates []string) {
+	if identifier == nil {
+		return
+	}
+	names := []string{}
+	for _, candidate := range candidates {
+		if isSameKindOfName(identifier, candidate) {
+			names = append(names, candidate)
+		}
+	}
+	p.suggest(identifier.Text(), names, identifier.Loc(), func(s string) string { return s })
+}
+
+// Only a valid identifier of the same kind (value or type) can replace the
+// identifier, which excludes std operators like '!'
+func isSameKindOfName(identifier *Identifier, name string) bool {
+	return word.FindString(name) == name && isTypeName(name) == identifier.IsType()
+}
+
+// Names of all variables visible from the scope
+func (s *Scope) visibleNames() []string {
+	names := []string{}
+	for scope := s; scope != nil; scope = scope.outer {
+		for name := range scope.variables {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Names of the members of an object, a type alias or a module
+func getMemberNames(t ExpressionType) []string {
+	names := []string{}
+	for _, member := range getMembers(t) {
+		names = append(names, member.Label)
+	}
+	return names
+}
+
+// Suggest an existing module for an unresolved 'use' path:
+// standard libs, or files next to the expected one.
+func (p *Parser) suggestPath(l *Literal, path string) {
+	candidates := libNames
+	base := path
+	if IsLocalPath(path) {
+		base = filepath.Base(path)
+		dir := filepath.Join(filepath.Dir(p.filePath), filepath.Dir(path))
+		candidates = getSiblingFiles(dir, p.filePath)
+	}
+	prefix := path[:len(path)-len(base)]
+	p.suggest(base, candidates, l.Loc(), func(s string) string {
+		return strconv.Quote(prefix + s)
+	})
+}
+
+func getSiblingFiles(dir string, except string) []string {
+	names := []string{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return names
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Join(dir, entry.Name()) == filepath.Clean(except) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names
+}
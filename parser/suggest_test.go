@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindClosest(t *testing.T) {
+	if s, ok := findClosest("lenght", []string{"length", "height", "width"}); !ok || s != "length" {
+		t.Fatalf("Expected 'length', got '%v'", s)
+	}
+	if s, ok := findClosest("x", []string{"length", "height"}); ok {
+		t.Fatalf("Expected no suggestion, got '%v'", s)
+	}
+}
+
+func testSuggestion(t *testing.T, errors []ParserError, kind ErrorKind, fix string) {
+	for _, err := range errors {
+		if err.Kind != kind {
+			continue
+		}
+		if len(err.Notes) != 1 || len(err.Fixes) != 1 {
+			t.Fatalf("Expected a note and a fix, got %#v", err)
+		}
+		if text := err.Fixes[0].Edits[0].NewText; text != fix {
+			t.Fatalf("Expected fix '%v', got '%v'", fix, text)
+		}
+		return
+	}
+	t.Fatalf("Expected error of kind %v, got %#v", kind, errors)
+}
+
+func TestSuggestVariable(t *testing.T) {
+	_, errors := ParseProgram(strings.NewReader("_count := 1\n_b := _cuont + 1"), "main")
+	testSuggestion(t, errors, CannotFind, "_count")
+}
+
+func TestSuggestOnlyIdentifiers(t *testing.T) {
+	_, errors := ParseProgram(strings.NewReader("_a := k"), "main")
+	if len(errors) != 1 || len(errors[0].Notes) != 0 {
+		t.Fatalf("Expected no suggestion, got %#v", errors[0].Notes)
+	}
+	_, errors = ParseProgram(strings.NewReader("Point :: { x number }\n_p := point"), "main")
+	if len(errors) != 1 || len(errors[0].Notes) != 0 {
+		t.Fatalf("Expected no suggestion, got %#v", errors[0].Notes)
+	}
+}
+
+func TestSuggestProperty(t *testing.T) {
+	source := "Point :: { x number, length number }\n"
+	source += "_p := Point{ x: 1, length: 2 }\n"
+	source += "_l := _p.lenght"
+	_, errors := ParseProgram(strings.NewReader(source), "main")
+	testSuggestion(t, errors, PropertyDoesNotExist, "length")
+}
+
+func TestSuggestModuleMember(t *testing.T) {
+	lib, _ := ParseProgram(strings.NewReader("answer :: 42\n_b := answer"), "/project/lib")
+	ExportProgram("/project/lib", lib)
+	_, errors := ParseProgram(strings.NewReader("use anwser from \"./lib\"\n_a := anwser"), "/project/main")
+	testSuggestion(t, errors, NotInModule, "answer")
+}
+
+func TestSuggestLib(t *testing.T) {
+	_, errors := ParseProgram(strings.NewReader("use * as d from \"don\"\n_a := d"), "main")
+	testSuggestion(t, errors, CannotResolvePath, "\"dom\"")
+}
+
+func TestSuggestSiblingFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "utils"), []byte{}, 0644)
+	main := filepath.Join(dir, "main")
+	_, errors := ParseProgram(strings.NewReader("use * as u from \"./utlis\"\n_a := u"), main)
+	testSuggestion(t, errors, CannotResolvePath, "\"./utils\"")
+}
@@ -34,10 +34,45 @@ func (l *Literal) Type() ExpressionType {
 	}
 }
 
+// Get the literal type of a value literal (e.g. '"light"' for "light")
+func (l *Literal) literalType() (LiteralType, bool) {
+	switch l.Kind() {
+	case NumberLiteral, BooleanLiteral, StringLiteral:
+		return LiteralType{Base: l.Type(), Value: l.Text()}, true
+	default:
+		return LiteralType{}, false
+	}
+}
+
+// Get the type of an expression as seen by some expected type.
+// Literal values have their base type (e.g. string), unless a literal type is
+// expected (e.g. '"light"' is expected by '"light" | "dark"').
+func getContextualType(expected ExpressionType, expr Expression) ExpressionType {
+	received := expr.Type()
+	if isNone(expr) && isOptionType(expected) {
+		return expected
+	}
+	literal, ok := Unwrap(expr).(*Literal)
+	if !ok || expected == nil || !containsLiteralType(expected) {
+		return received
+	}
+	if t, ok := literal.literalType(); ok {
+		return t
+	}
+	return received
+}
+
+// true if the expression is the empty option 'None'
+func isNone(expr Expression) bool {
+	identifier, ok := Unwrap(expr).(*Identifier)
+	return ok && identifier.Text() == "None" && identifier.scope == nil
+}
+
 type Identifier struct {
 	Token
 	typing ExpressionType
-	scope  *Scope
+	scope  *Scope // declaring the variable
+	usedIn *Scope // where the variable is read or written
 }
 
 func (i *Identifier) getChildren() []Node {
@@ -48,7 +83,9 @@ func (i *Identifier) IsPrivate() bool {
 	return i.Text()[0] == '_'
 }
 func (i *Identifier) IsType() bool {
-	text := i.Text()
+	return isTypeName(i.Text())
+}
+func isTypeName(text string) bool {
 	var firstLetter rune
 	if text[0] == '_' {
 		if len(text) == 1 {
@@ -66,16 +103,24 @@ func (i *Identifier) GetScope() *Scope { return i.scope }
 func (i *Identifier) typeCheck(p *Parser) {
 	variable, ok := p.scope.Find(i.Text())
 	if !ok {
+		if i.Text() != "_" {
+			p.error(i, CannotFind, i.Text())
+			p.suggestName(i, p.scope.visibleNames())
+		}
 		i.typing = Invalid{}
 		return
 	}
 	i.scope = variable.scope
+	i.usedIn = p.scope
 	if p.writing != nil {
 		variable.writeAt(p.writing)
 	} else {
 		variable.readAt(i.Loc())
 	}
 	i.typing = variable.Typing
+	if narrowed, ok := p.scope.findNarrowed(i.Text()); ok && p.writing == nil {
+		i.typing = narrowed
+	}
 }
 
 func (i *Identifier) Type() ExpressionType { return i.typing }
@@ -57,3 +57,19 @@ func TestParseCatchall(t *testing.T) {
 		t.Fatalf("Expected Identifier, got %#v", expr)
 	}
 }
+
+func TestSkipComments(t *testing.T) {
+	source := "// first\n_a := 1 // second\n_b := _a / 2"
+	program, errors := ParseProgram(strings.NewReader(source), "main")
+	if len(errors) > 0 {
+		t.Fatalf("Expected no errors, got %#v", errors)
+	}
+	comments := program.Comments()
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 comments, got %#v", comments)
+	}
+	expected := Comment{" second", Loc{Position{2, 9}, Position{2, 18}}}
+	if comments[1] != expected {
+		t.Fatalf("Expected %#v, got %#v", expected, comments[1])
+	}
+}
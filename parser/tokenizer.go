@@ -4,16 +4,17 @@ import (
 	"bufio"
 	"io"
 	"regexp"
+	"strings"
 )
 
 type Position struct {
-	Line int
-	Col  int
+	Line int `json:"line"`
+	Col  int `json:"col"`
 }
 
 type Loc struct {
-	Start Position
-	End   Position
+	Start Position `json:"start"`
+	End   Position `json:"end"`
 }
 
 type TokenKind int
@@ -48,6 +49,9 @@ const (
 	UseKeyword      // use
 	AsKeyword       // as
 	FromKeyword     // from
+	IsKeyword       // is
+	NewKeyword      // new
+	ExternKeyword   // extern
 
 	Add        // +
 	Concat     // ++
@@ -178,6 +182,7 @@ func (l literal) Loc() Loc {
 }
 
 var blank = regexp.MustCompile(`^[\t\f\r ]+`)
+var comment = regexp.MustCompile(`^//[^\n]*`)
 var newLine = regexp.MustCompile(`^\s+`)
 var number = regexp.MustCompile(`^\d+`)
 var str = regexp.MustCompile(`^"(.*?)[^\\]"`)
@@ -197,6 +202,8 @@ func split(data []byte, atEOF bool) (advance int, token []byte, err error) {
 		token = str.Find(data)
 	case word.Match(data):
 		token = word.Find(data)
+	case comment.Match(data):
+		token = comment.Find(data)
 	case operator.Match(data):
 		token = operator.Find(data)
 	case punctuation.Match(data):
@@ -210,10 +217,17 @@ func split(data []byte, atEOF bool) (advance int, token []byte, err error) {
 }
 
 type tokenizer struct {
-	scanner *bufio.Scanner
-	cursor  Position
-	token   Token
-	ready   bool
+	scanner  *bufio.Scanner
+	cursor   Position
+	token    Token
+	ready    bool
+	comments []Comment
+}
+
+// A '// comment', which is skipped by the tokenizer but kept for tooling
+type Comment struct {
+	Text string // without the leading '//'
+	Loc  Loc
 }
 
 type Tokenizer interface {
@@ -225,13 +239,14 @@ type Tokenizer interface {
 func NewTokenizer(reader io.Reader) *tokenizer {
 	scanner := bufio.NewScanner(reader)
 	scanner.Split(split)
-	return &tokenizer{scanner, Position{1, 1}, nil, false}
+	return &tokenizer{scanner: scanner, cursor: Position{1, 1}}
 }
 
 func (t *tokenizer) updateCursor(token string) {
 	if list := regexp.MustCompile(`\n`).FindAllString(token, -1); list != nil {
 		t.cursor.Line += len(list)
-		t.cursor.Col = 1
+		// line breaks may be followed by indentation
+		t.cursor.Col = len(token) - strings.LastIndex(token, "\n")
 		return
 	}
 	t.cursor.Col += len(token)
@@ -279,6 +294,12 @@ func makeToken(text string, loc Loc) Token {
 		return token{AsKeyword, loc}
 	case "from":
 		return token{FromKeyword, loc}
+	case "is":
+		return token{IsKeyword, loc}
+	case "new":
+		return token{NewKeyword, loc}
+	case "extern":
+		return token{ExternKeyword, loc}
 	case "+":
 		return token{Add, loc}
 	case "++":
@@ -393,6 +414,13 @@ func (t *tokenizer) next() bool {
 		t.updateCursor(value)
 		return t.next()
 	}
+	if comment.MatchString(value) {
+		loc := Loc{t.cursor, Position{}}
+		t.updateCursor(value)
+		loc.End = t.cursor
+		t.comments = append(t.comments, Comment{value[2:], loc})
+		return t.next()
+	}
 	loc := Loc{t.cursor, Position{}}
 	t.updateCursor(value)
 	loc.End = t.cursor
@@ -107,6 +107,11 @@ func (t *TupleExpression) reportDuplicatedParams(p *Parser) {
 		}
 		for _, loc := range locs {
 			p.error(&Block{loc: loc}, DuplicateIdentifier, name)
+			for _, other := range locs {
+				if other != loc {
+					p.label(other, "other declaration of '"+name+"'")
+				}
+			}
 		}
 	}
 }
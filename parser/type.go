@@ -52,9 +52,49 @@ func (u Invalid) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
 	return u, true
 }
 
+// The type of expressions that never complete,
+// such as 'return' or calls to 'todo()'.
+// Since no value is ever produced, it can be used as any other type.
+type Never struct{}
+
+func (n Never) Extends(t ExpressionType) bool {
+	_, ok := t.(Never)
+	return ok
+}
+func (n Never) Text() string { return "never" }
+func (n Never) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
+	return n, true
+}
+
+func isNever(t ExpressionType) bool {
+	_, ok := t.(Never)
+	return ok
+}
+
+// Get the type of an expression with several branches (e.g. if/else).
+// Branches that never complete are ignored.
+// Returns false if the remaining branches have mismatched types.
+func unifyBranches(types ...ExpressionType) (ExpressionType, bool) {
+	var unified ExpressionType = Never{}
+	for _, t := range types {
+		if isNever(t) {
+			continue
+		}
+		if isNever(unified) {
+			unified = t
+		} else if !Match(unified, t) {
+			return t, false
+		}
+	}
+	return unified, true
+}
+
 type Void struct{}
 
 func (n Void) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	_, ok := t.(Void)
 	return ok
 }
@@ -66,8 +106,7 @@ func (n Void) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
 type Number struct{}
 
 func (n Number) Extends(t ExpressionType) bool {
-	_, ok := t.(Number)
-	return ok
+	return extendsPrimitive(n, t)
 }
 func (n Number) Text() string { return "number" }
 func (n Number) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
@@ -77,8 +116,7 @@ func (n Number) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
 type Boolean struct{}
 
 func (b Boolean) Extends(t ExpressionType) bool {
-	_, ok := t.(Boolean)
-	return ok
+	return extendsPrimitive(b, t)
 }
 func (b Boolean) Text() string { return "boolean" }
 func (b Boolean) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
@@ -88,14 +126,91 @@ func (b Boolean) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
 type String struct{}
 
 func (s String) Extends(t ExpressionType) bool {
-	_, ok := t.(String)
-	return ok
+	return extendsPrimitive(s, t)
 }
 func (s String) Text() string { return "string" }
 func (s String) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
 	return s, true
 }
 
+// Check if a type can be used as the given primitive.
+// Literal types (and unions of them) are accepted if they share the same base.
+func extendsPrimitive(primitive ExpressionType, t ExpressionType) bool {
+	switch t := t.(type) {
+	case Never:
+		return true
+	case LiteralType:
+		return t.Base == primitive
+	case Union:
+		for _, member := range t.Members {
+			if !extendsPrimitive(primitive, member) {
+				return false
+			}
+		}
+		return true
+	case TypeAlias:
+		return isLiteralType(t.Ref) && extendsPrimitive(primitive, t.Ref)
+	default:
+		return t == primitive
+	}
+}
+
+// The type of a single literal value, such as '"light"', '42' or 'true'.
+type LiteralType struct {
+	Base  ExpressionType // Boolean, Number or String
+	Value string         // as written in source code
+}
+
+func (l LiteralType) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
+	literal, ok := t.(LiteralType)
+	return ok && literal.Base == l.Base && literal.Value == l.Value
+}
+func (l LiteralType) Text() string { return l.Value }
+func (l LiteralType) build(scope *Scope, c ExpressionType) (ExpressionType, bool) {
+	return l, true
+}
+
+// true if the type is a literal type or is made of literal types
+func isLiteralType(t ExpressionType) bool {
+	switch t := t.(type) {
+	case LiteralType:
+		return true
+	case Union:
+		for _, member := range t.Members {
+			if !isLiteralType(member) {
+				return false
+			}
+		}
+		return true
+	case TypeAlias:
+		return isLiteralType(t.Ref)
+	default:
+		return false
+	}
+}
+
+// true if literal types are part of the given type
+func containsLiteralType(t ExpressionType) bool {
+	switch t := t.(type) {
+	case LiteralType:
+		return true
+	case Union:
+		for _, member := range t.Members {
+			if containsLiteralType(member) {
+				return true
+			}
+		}
+		return false
+	case TypeAlias:
+		return containsLiteralType(t.Ref)
+	default:
+		return false
+	}
+}
+
 type TypeAlias struct {
 	Name    string
 	Params  []Generic
@@ -105,6 +220,9 @@ type TypeAlias struct {
 }
 
 func (ta TypeAlias) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	alias, ok := t.(TypeAlias)
 	if !ok {
 		return ta.Ref.Extends(t)
@@ -113,10 +231,15 @@ func (ta TypeAlias) Extends(t ExpressionType) bool {
 		return ta.Name == alias.Name || alias.Implements(trait)
 	}
 	if alias.Name != ta.Name {
+		// named unions accept the values of their members
+		if union, ok := ta.Ref.(Union); ok {
+			return union.Extends(t)
+		}
 		return false
 	}
 	for i, param := range ta.Params {
-		if param.Value != nil && !param.Value.Extends(alias.Params[i]) {
+		received := alias.Params[i].Value
+		if param.Value != nil && received != nil && !param.Value.Extends(received) {
 			return false
 		}
 	}
@@ -228,6 +351,9 @@ type Ref struct {
 }
 
 func (r Ref) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	ref, ok := t.(Ref)
 	if !ok {
 		return false
@@ -258,6 +384,9 @@ type List struct {
 }
 
 func (l List) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	if list, ok := t.(List); ok {
 		return l.Element.Extends(list.Element)
 	}
@@ -280,6 +409,9 @@ type Map struct {
 }
 
 func (m Map) Extends(received ExpressionType) bool {
+	if isNever(received) {
+		return true
+	}
 	t, ok := received.(Map)
 	if !ok {
 		return false
@@ -306,6 +438,9 @@ type Tuple struct {
 func newTuple() Tuple { return Tuple{[]ExpressionType{}} }
 
 func (tuple Tuple) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	switch t := t.(type) {
 	case Tuple:
 		if len(t.Elements) != len(tuple.Elements) {
@@ -359,6 +494,9 @@ type Range struct {
 }
 
 func (r Range) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	if received, ok := t.(Range); ok {
 		return r.operands.Extends(received.operands)
 	}
@@ -379,6 +517,7 @@ type Function struct {
 	Params     *Tuple
 	Returned   ExpressionType
 	Async      bool // true if the function can be called with 'async'
+	Optional   int  // number of trailing params that can be left out (in declaration files)
 }
 
 // returns a function equivalent to () => {}
@@ -398,6 +537,9 @@ func (f Function) arity() int {
 }
 
 func (f Function) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	function, ok := t.(Function)
 	if !ok {
 		return false
@@ -497,7 +639,7 @@ func newObject() Object {
 	}
 }
 
-func (o Object) Extends(t ExpressionType) bool { return false }
+func (o Object) Extends(t ExpressionType) bool { return isNever(t) }
 func (o Object) Text() string {
 	s := "{"
 	for _, member := range o.Members {
@@ -570,6 +712,11 @@ func (o Object) flatten() []ObjectMember {
 
 type Module struct {
 	Object
+	path     string   // path of the file declaring the module, empty for std modules
+	scope    *Scope   // declarations of the module, nil for std modules
+	typeOnly []string // names without runtime value, in declaration files
+	// params of classes' constructors, by class name, in declaration files
+	constructors map[string]Object
 }
 
 type Sum struct {
@@ -577,6 +724,9 @@ type Sum struct {
 }
 
 func (s Sum) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
 	// return true if exactly one member extends received type
 	found := false
 	for _, member := range s.Members {
@@ -620,12 +770,154 @@ func (s Sum) getMember(name string) ExpressionType {
 	return tuple
 }
 
+// An anonymous union of types, such as 'string | number'.
+// Unlike sum types, its members are not wrapped in a tagged object at runtime.
+type Union struct {
+	Members []ExpressionType
+}
+
+func (u Union) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
+	if received, ok := t.(Union); ok {
+		for _, member := range received.Members {
+			if !u.Extends(member) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, member := range u.Members {
+		if member.Extends(t) {
+			return true
+		}
+	}
+	// values of a named union, like 'Foo :: number | Shape'
+	if alias, ok := t.(TypeAlias); ok {
+		if received, ok := alias.Ref.(Union); ok {
+			return u.Extends(received)
+		}
+	}
+	return false
+}
+func (u Union) Text() string {
+	s := ""
+	for i, member := range u.Members {
+		if i > 0 {
+			s += " | "
+		}
+		s += member.Text()
+	}
+	return s
+}
+func (u Union) build(scope *Scope, compared ExpressionType) (ExpressionType, bool) {
+	ok := true
+	members := make([]ExpressionType, len(u.Members))
+	for i, member := range u.Members {
+		var k bool
+		members[i], k = member.build(scope, nil)
+		ok = ok && k
+	}
+	return Union{members}, ok
+}
+
+// Create a union from the given types.
+// Nested unions are flattened and duplicated members are removed.
+// If only one member remains, it is returned as is.
+func makeUnion(types ...ExpressionType) ExpressionType {
+	members := []ExpressionType{}
+	for _, t := range types {
+		var candidates []ExpressionType
+		if u, ok := t.(Union); ok {
+			candidates = u.Members
+		} else {
+			candidates = []ExpressionType{t}
+		}
+		for _, candidate := range candidates {
+			if !containsType(members, candidate) {
+				members = append(members, candidate)
+			}
+		}
+	}
+	if len(members) == 1 {
+		return members[0]
+	}
+	return Union{members}
+}
+
+// Remove the given type from a union.
+// Returns the remaining type (which might not be a union anymore).
+func (u Union) exclude(t ExpressionType) ExpressionType {
+	members := []ExpressionType{}
+	for _, member := range u.Members {
+		if !Match(member, t) {
+			members = append(members, member)
+		}
+	}
+	return makeUnion(members...)
+}
+
+// Get the union behind a type, if any (e.g. 'Mode :: "light" | "dark"')
+func asUnion(t ExpressionType) (Union, bool) {
+	switch t := t.(type) {
+	case Union:
+		return t, true
+	case TypeAlias:
+		return asUnion(t.Ref)
+	default:
+		return Union{}, false
+	}
+}
+
+func containsType(types []ExpressionType, t ExpressionType) bool {
+	for _, el := range types {
+		if Match(el, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Nominal wrapper around another type, declared with 'Name :: new Underlying'.
+// It is only assignable from itself, so values of the underlying type
+// need to be wrapped explicitly with 'Name{value}'.
+type Newtype struct {
+	Underlying ExpressionType
+}
+
+func (n Newtype) Extends(t ExpressionType) bool {
+	if isNever(t) {
+		return true
+	}
+	received, ok := t.(Newtype)
+	return ok && n.Underlying.Extends(received.Underlying)
+}
+func (n Newtype) Text() string { return "new " + n.Underlying.Text() }
+func (n Newtype) build(scope *Scope, compared ExpressionType) (ExpressionType, bool) {
+	return n, true
+}
+
+// Traits have no runtime representation, so values cannot be tested
+// against them
+func isTraitType(t ExpressionType) bool {
+	alias, ok := t.(TypeAlias)
+	if !ok {
+		return false
+	}
+	_, ok = alias.Ref.(Trait)
+	return ok
+}
+
 type Trait struct {
 	Self    Generic
 	Members map[string]ExpressionType
 }
 
 func (t Trait) Extends(et ExpressionType) bool {
+	if isNever(et) {
+		return true
+	}
 	var receivedMethods map[string]ExpressionType
 	switch et := et.(type) {
 	case Trait:
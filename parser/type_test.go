@@ -126,3 +126,112 @@ func TestGetSumTypeMember(t *testing.T) {
 		t.Fatalf("Expected number, got %v", some)
 	}
 }
+
+func TestUnionExtends(t *testing.T) {
+	union := Union{[]ExpressionType{Number{}, String{}}}
+	if !union.Extends(Number{}) {
+		t.Fatalf("Expected number to be assignable to %v", union.Text())
+	}
+	if union.Extends(Boolean{}) {
+		t.Fatalf("Expected boolean not to be assignable to %v", union.Text())
+	}
+	if !union.Extends(Union{[]ExpressionType{String{}, Number{}}}) {
+		t.Fatalf("Expected unions with the same members to match")
+	}
+	if (Number{}).Extends(union) {
+		t.Fatalf("Expected %v not to be assignable to number", union.Text())
+	}
+}
+
+func TestMakeUnion(t *testing.T) {
+	union := makeUnion(Number{}, Union{[]ExpressionType{String{}, Number{}}})
+	if union.Text() != "number | string" {
+		t.Fatalf("Expected 'number | string', got %v", union.Text())
+	}
+	if _, ok := makeUnion(Number{}, Number{}).(Number); !ok {
+		t.Fatalf("Expected a single number")
+	}
+}
+
+func TestUnionExclude(t *testing.T) {
+	union := Union{[]ExpressionType{Number{}, String{}, Boolean{}}}
+	remaining := union.exclude(String{})
+	if remaining.Text() != "number | boolean" {
+		t.Fatalf("Expected 'number | boolean', got %v", remaining.Text())
+	}
+	remaining = Union{[]ExpressionType{Number{}, String{}}}.exclude(Number{})
+	if _, ok := remaining.(String); !ok {
+		t.Fatalf("Expected string")
+	}
+}
+
+func TestLiteralTypeExtends(t *testing.T) {
+	light := LiteralType{String{}, "\"light\""}
+	dark := LiteralType{String{}, "\"dark\""}
+	mode := Union{[]ExpressionType{light, dark}}
+	if !(String{}).Extends(light) {
+		t.Fatalf("Expected %v to be assignable to string", light.Text())
+	}
+	if light.Extends(String{}) {
+		t.Fatalf("Expected string not to be assignable to %v", light.Text())
+	}
+	if !mode.Extends(light) {
+		t.Fatalf("Expected %v to be assignable to %v", light.Text(), mode.Text())
+	}
+	if mode.Extends(LiteralType{String{}, "\"blue\""}) {
+		t.Fatalf("Expected \"blue\" not to be assignable to %v", mode.Text())
+	}
+	if !(String{}).Extends(mode) {
+		t.Fatalf("Expected %v to be assignable to string", mode.Text())
+	}
+	if (Number{}).Extends(mode) {
+		t.Fatalf("Expected %v not to be assignable to number", mode.Text())
+	}
+}
+
+func TestNeverExtends(t *testing.T) {
+	types := []ExpressionType{
+		Number{},
+		Void{},
+		List{String{}},
+		makeOptionType(Number{}),
+		Union{[]ExpressionType{Number{}, String{}}},
+	}
+	for _, typing := range types {
+		if !typing.Extends(Never{}) {
+			t.Fatalf("Expected never to be assignable to %v", typing.Text())
+		}
+	}
+	if (Never{}).Extends(Number{}) {
+		t.Fatalf("Expected number not to be assignable to never")
+	}
+}
+
+func TestUnifyBranches(t *testing.T) {
+	if typing, ok := unifyBranches(Never{}, Number{}); !ok || typing != (Number{}) {
+		t.Fatalf("Expected number, got %v", typing.Text())
+	}
+	if typing, ok := unifyBranches(Never{}, Never{}); !ok || typing != (Never{}) {
+		t.Fatalf("Expected never, got %v", typing.Text())
+	}
+	if _, ok := unifyBranches(String{}, Number{}); ok {
+		t.Fatalf("Expected mismatched branches")
+	}
+}
+
+func TestNewtypeExtends(t *testing.T) {
+	userId := TypeAlias{Name: "UserId", Ref: Newtype{Number{}}}
+	orderId := TypeAlias{Name: "OrderId", Ref: Newtype{Number{}}}
+	if !userId.Extends(userId) {
+		t.Fatalf("Expected UserId to be assignable to UserId")
+	}
+	if userId.Extends(orderId) {
+		t.Fatalf("Expected OrderId not to be assignable to UserId")
+	}
+	if userId.Extends(Number{}) {
+		t.Fatalf("Expected number not to be assignable to UserId")
+	}
+	if (Number{}).Extends(userId) {
+		t.Fatalf("Expected UserId not to be assignable to number")
+	}
+}
@@ -21,7 +21,7 @@ func (u *UnaryExpression) typeCheck(p *Parser) {
 	u.Operand.typeCheck(p)
 	switch u.Operator.Kind() {
 	case AsyncKeyword:
-		checkAsyncExpression(p, u)
+		// unneeded 'async' is reported by the linter
 	case AwaitKeyword:
 		checkAwaitExpression(p, u)
 	case Bang:
@@ -30,6 +30,8 @@ func (u *UnaryExpression) typeCheck(p *Parser) {
 		checkReferenceExpression(p, u)
 	case Mul:
 		checkDerefExpression(p, u)
+	case NewKeyword:
+		checkNewtypeExpression(p, u)
 	case QuestionMark:
 		checkOptionType(p, u)
 	case TryKeyword:
@@ -38,16 +40,6 @@ func (u *UnaryExpression) typeCheck(p *Parser) {
 		panic(fmt.Sprintf("Operator '%v' not implemented!", u.Operator.Kind()))
 	}
 }
-func checkAsyncExpression(p *Parser, u *UnaryExpression) {
-	call, ok := u.Operand.(*CallExpression)
-	if !ok || call.Callee == nil {
-		return
-	}
-	f, ok := call.Callee.Type().(Function)
-	if ok && !f.Async {
-		p.error(u, UnneededAsync)
-	}
-}
 func checkAwaitExpression(p *Parser, u *UnaryExpression) {
 	alias, ok := u.Operand.Type().(TypeAlias)
 	if !ok || alias.Name != "..." {
@@ -83,6 +75,16 @@ func checkDerefExpression(p *Parser, u *UnaryExpression) {
 		p.error(u.Operand, RefExpected, u.Operand.Type())
 	}
 }
+func checkNewtypeExpression(p *Parser, u *UnaryExpression) {
+	t, ok := u.Operand.Type().(Type)
+	if !ok {
+		p.error(u.Operand, TypeExpected)
+		return
+	}
+	if _, ok := t.Value.(Trait); ok {
+		p.error(u.Operand, TypeExpected)
+	}
+}
 func checkOptionType(p *Parser, u *UnaryExpression) {
 	if _, ok := u.Operand.Type().(Type); !ok {
 		p.error(u.Operand, TypeExpected)
@@ -118,6 +120,8 @@ func (u *UnaryExpression) Type() ExpressionType {
 		return getRefType(u)
 	case Mul:
 		return getDerefType(u)
+	case NewKeyword:
+		return getNewtypeType(u)
 	case QuestionMark:
 		return getOptionType(u)
 	case TryKeyword:
@@ -169,6 +173,13 @@ func getDerefType(u *UnaryExpression) ExpressionType {
 	}
 	return ref.To
 }
+func getNewtypeType(u *UnaryExpression) ExpressionType {
+	t, ok := u.Operand.Type().(Type)
+	if !ok {
+		return Type{Newtype{Invalid{}}}
+	}
+	return Type{Newtype{t.Value}}
+}
 func getOptionType(u *UnaryExpression) ExpressionType {
 	var t ExpressionType
 	if ty, ok := u.Operand.Type().(Type); ok {
@@ -229,7 +240,7 @@ func (l *ListTypeExpression) Type() ExpressionType {
 
 func (p *Parser) parseUnaryExpression() Expression {
 	switch p.Peek().Kind() {
-	case AsyncKeyword, AwaitKeyword, Bang, BinaryAnd, Mul, QuestionMark, TryKeyword:
+	case AsyncKeyword, AwaitKeyword, Bang, BinaryAnd, Mul, NewKeyword, QuestionMark, TryKeyword:
 		token := p.Consume()
 		if token.Kind() == QuestionMark && p.Peek().Kind() == LeftBrace {
 			return parseInferredInstance(p, &UnaryExpression{token, nil})
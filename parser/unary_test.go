@@ -148,7 +148,8 @@ func TestCheckUnaryExpression(t *testing.T) {
 					Args:   &ParenthesizedExpression{Expr: &TupleExpression{Elements: []Expression{}}},
 				},
 			},
-			wantError:    true,
+			// unneeded 'async' is reported by the linter
+			wantError:    false,
 			expectedType: "async[number]",
 		},
 		{
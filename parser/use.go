@@ -1,9 +1,5 @@
 package parser
 
-import (
-	"path/filepath"
-)
-
 type UseDirective struct {
 	Names  Expression // *Identifier | *TupleExpression{[]*Identifier}
 	Star   bool       // is 'use * as XXX from YYY'
@@ -37,14 +33,22 @@ func typeCheckModule(p *Parser, source Expression) ExpressionType {
 	path := l.Text()
 	path = path[1 : len(path)-1]
 	var module Module
-	if IsLocalPath(path) {
-		path = filepath.Join(filepath.Dir(p.filePath), path)
-		module, ok = filesExports[path]
-	} else {
-		module, ok = getLib(path)
+	if resolved, isFile := ResolvePath(p.filePath, path); isFile {
+		module, ok = filesExports[resolved]
+		if !ok && IsDeclarationFile(resolved) {
+			var err error
+			module, _, err = ParseDeclarationFile(resolved)
+			ok = err == nil
+		}
+	} else if module, ok = getLib(path); ok {
+		if target := getTarget(p.filePath); !isLibAvailable(path, target) {
+			p.error(l, UnavailableLib, path, target)
+			return Invalid{}
+		}
 	}
 	if !ok {
 		p.error(l, CannotResolvePath)
+		p.suggestPath(l, l.Text()[1:len(l.Text())-1])
 		return Invalid{}
 	}
 	return module
@@ -62,6 +66,7 @@ func declareUseNames(p *Parser, module ExpressionType, names Expression) {
 			t, ok := module.GetOwned(id.Text())
 			if !ok {
 				p.error(id, NotInModule, id.Text())
+				p.suggestName(id, getMemberNames(module))
 				t = Invalid{}
 			}
 			p.scope.Add(id.Text(), id.Loc(), t)
@@ -25,3 +25,55 @@ func TestParseUseAs(t *testing.T) {
 	parser.parseAssignment()
 	testParserErrors(t, parser, 0)
 }
+
+func TestCheckUseNode(t *testing.T) {
+	SetTarget("node")
+	defer SetTarget("")
+	str := "use fs, path, process from \"node\"\n"
+	str += "fs.writeFile(path.join(process.cwd(), \"out.txt\"), \"done\") catch {\n    process.exit(1)\n}"
+	_, errors := ParseProgram(strings.NewReader(str), "")
+	if len(errors) != 0 {
+		t.Fatalf("Expected no errors, got %#v", errors)
+	}
+}
+
+func TestCheckUseDomEventNames(t *testing.T) {
+	str := "use document, Event from \"dom\"\n"
+	str += "_f :: (e Event) => {}\n"
+	str += "document().addEventListener(\"click\", _f)\n"
+	str += "document().addEventListener(\"transitionend\", _f)\n"
+	str += "document().addEventListener(\"clik\", _f)"
+	_, errors := ParseProgram(strings.NewReader(str), "")
+	if len(errors) != 1 || errors[0].Kind != CannotAssignType {
+		t.Fatalf("Expected only the unknown literal name to be rejected, got %v", len(errors))
+	}
+}
+
+func TestCheckUseDomUncheckedEventName(t *testing.T) {
+	str := "use document, Event from \"dom\"\n"
+	str += "_f :: (e Event) => {}\n"
+	str += "name := \"custom\"\n"
+	str += "document().removeEventListener(name, _f)"
+	_, errors := ParseProgram(strings.NewReader(str), "")
+	if len(errors) != 1 || errors[0].Kind != UncheckedEventName {
+		t.Fatalf("Expected an unchecked event name, got %v error(s)", len(errors))
+	}
+	if errors[0].Severity() != WarningSeverity {
+		t.Fatalf("Expected unchecked event names to be warnings")
+	}
+}
+
+func TestCheckUseUnavailableLib(t *testing.T) {
+	SetTarget("node")
+	defer SetTarget("")
+	_, errors := ParseProgram(strings.NewReader("use document from \"dom\""), "")
+	if len(errors) != 1 || errors[0].Kind != UnavailableLib {
+		t.Fatalf("Expected UnavailableLib error, got %#v", errors)
+	}
+
+	SetTarget("browser")
+	_, errors = ParseProgram(strings.NewReader("use fs from \"node\""), "")
+	if len(errors) != 1 || errors[0].Kind != UnavailableLib {
+		t.Fatalf("Expected UnavailableLib error, got %#v", errors)
+	}
+}
@@ -47,6 +47,20 @@ func getHappyType(t ExpressionType) ExpressionType {
 	return t
 }
 
+func isOptionType(t ExpressionType) bool {
+	alias, ok := t.(TypeAlias)
+	return ok && alias.Name == "?"
+}
+
+// If the given is an option, return its "Some" type.
+// Else return the given type.
+func getSomeTypeOf(t ExpressionType) ExpressionType {
+	if alias, ok := t.(TypeAlias); ok && alias.Name == "?" {
+		return getSomeType(alias.Ref.(Sum))
+	}
+	return t
+}
+
 // If the given is a result, return its "Err" type.
 // Else return nil.
 func getErrorType(t ExpressionType) ExpressionType {
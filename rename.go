@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+func runRename(args []string, stderr io.Writer) error {
+	fs := newFlagSet("rename")
+	o := buildOptions{stderr: stderr}
+	fs.StringVar(&o.entry, "entry", "", "entry `file` of the program (defaults to kiwi.json's entries, or the renamed file)")
+	addFormatFlag(fs, &o.format)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return usageError{"expected a position and a new name"}
+	}
+	path, position, err := parsePosition(args[0])
+	if err != nil {
+		return err
+	}
+	o.defaultEntry = path
+	if err := o.load(fs, nil); err != nil {
+		return err
+	}
+	return rename(o, path, position, args[1])
+}
+
+// Parse a position like 'file:line:col'
+func parsePosition(at string) (string, parser.Position, error) {
+	parts := strings.Split(at, ":")
+	invalid := usageError{fmt.Sprintf("expected position like 'file:line:col', got '%v'", at)}
+	if len(parts) < 3 {
+		return "", parser.Position{}, invalid
+	}
+	line, errLine := strconv.Atoi(parts[len(parts)-2])
+	col, errCol := strconv.Atoi(parts[len(parts)-1])
+	if errLine != nil || errCol != nil {
+		return "", parser.Position{}, invalid
+	}
+	path := filepath.Clean(strings.Join(parts[:len(parts)-2], ":"))
+	return path, parser.Position{Line: line, Col: col}, nil
+}
+
+// Rename the symbol at the given position in all files reachable from the entry
+func rename(o buildOptions, path string, position parser.Position, newName string) error {
+	programs, diagnostics, err := loadProgram(o.entries, o.lint)
+	if err != nil {
+		return err
+	}
+	if parser.HasErrors(diagnostics) {
+		if err := logDiagnostics(o.stderr, diagnostics, o.format); err != nil {
+			return err
+		}
+		return errReported
+	}
+
+	edits, renameErr := parser.Rename(programs, path, position, newName)
+	if renameErr != nil {
+		if err := logDiagnostics(o.stderr, []parser.Diagnostic{renameErr.Diagnostic(path)}, o.format); err != nil {
+			return err
+		}
+		return errReported
+	}
+	if len(edits) == 0 {
+		return fmt.Errorf("nothing to rename at %v:%v:%v", path, position.Line, position.Col)
+	}
+	byFile := map[string][]parser.TextEdit{}
+	for _, edit := range edits {
+		byFile[edit.Path] = append(byFile[edit.Path], edit)
+	}
+	for path, edits := range byFile {
+		if err := applyEdits(path, edits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Edits are expected to be on a single line each, and not to overlap
+func applyEdits(path string, edits []parser.TextEdit) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(content), "\n")
+	// apply from end to start so that positions stay valid
+	slices.SortFunc(edits, func(a, b parser.TextEdit) int {
+		if a.Loc.Start.Line != b.Loc.Start.Line {
+			return b.Loc.Start.Line - a.Loc.Start.Line
+		}
+		return b.Loc.Start.Col - a.Loc.Start.Col
+	})
+	for _, edit := range edits {
+		l := edit.Loc.Start.Line - 1
+		start, end := edit.Loc.Start.Col-1, edit.Loc.End.Col-1
+		lines[l] = lines[l][:start] + edit.NewText + lines[l][end:]
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
@@ -0,0 +1,188 @@
+// Package render prints diagnostics rustc-style, with excerpts of the source
+// they refer to:
+//
+//	error[K0027]: Duplicate identifier 'x'
+//	 --> main:1:12
+//	  |
+//	1 | Point :: { x number, x number }
+//	  |            ^
+//	  |                      - other declaration of 'x'
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bmelicque/test-parser/parser"
+)
+
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	red    = "\x1b[31m"
+	yellow = "\x1b[33m"
+	blue   = "\x1b[34m"
+	cyan   = "\x1b[36m"
+)
+
+type Renderer struct {
+	Color   bool
+	sources map[string][]string // lines by path
+}
+
+func NewRenderer(color bool) *Renderer {
+	return &Renderer{Color: color, sources: map[string][]string{}}
+}
+
+// Check if the file is a terminal, in which case colors can be used
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Set the text of a file instead of reading it from disk
+func (r *Renderer) SetSource(path string, text string) {
+	r.sources[path] = strings.Split(text, "\n")
+}
+
+func (r *Renderer) getLines(path string) []string {
+	if lines, ok := r.sources[path]; ok {
+		return lines
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		r.sources[path] = nil
+		return nil
+	}
+	r.SetSource(path, string(content))
+	return r.sources[path]
+}
+
+// A span to underline
+type span struct {
+	loc     parser.Loc
+	primary bool
+	message string
+}
+
+func (r *Renderer) Render(w io.Writer, d parser.Diagnostic) {
+	color := r.severityColor(d.Severity)
+	fmt.Fprintf(w, "%v%v[%v]%v%v: %v%v\n", r.paint(bold+color), d.Severity, d.Code, r.paint(reset), r.paint(bold), d.Message, r.paint(reset))
+
+	spans := map[string][]span{d.Location.Path: {{loc: d.Location.Loc, primary: true}}}
+	paths := []string{d.Location.Path}
+	for _, label := range d.Labels {
+		if _, ok := spans[label.Path]; !ok {
+			paths = append(paths, label.Path)
+		}
+		spans[label.Path] = append(spans[label.Path], span{loc: label.Loc, message: label.Message})
+	}
+
+	width := 1
+	for _, s := range spans {
+		for _, s := range s {
+			width = max(width, len(strconv.Itoa(s.loc.Start.Line)))
+		}
+	}
+	gutter := strings.Repeat(" ", width)
+
+	for i, path := range paths {
+		first := spans[path][0].loc.Start
+		arrow := "-->"
+		if i > 0 {
+			arrow = ":::"
+		}
+		fmt.Fprintf(w, "%v%v%v%v %v:%v:%v\n", gutter, r.paint(bold+blue), arrow, r.paint(reset), path, first.Line, first.Col)
+		r.renderExcerpt(w, r.getLines(path), spans[path], width, color)
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(w, "%v %v=%v note: %v\n", gutter, r.paint(bold+blue), r.paint(reset), note)
+	}
+	for _, fix := range d.Fixes {
+		fmt.Fprintf(w, "%v %v=%v help: %v\n", gutter, r.paint(bold+blue), r.paint(reset), fix.Message)
+	}
+}
+
+// Print the lines containing the spans, each followed by its underlines
+func (r *Renderer) renderExcerpt(w io.Writer, lines []string, spans []span, width int, color string) {
+	gutter := strings.Repeat(" ", width)
+	pipe := r.paint(bold+blue) + "|" + r.paint(reset)
+	fmt.Fprintf(w, "%v %v\n", gutter, pipe)
+	if lines == nil {
+		return
+	}
+
+	numbers := []int{}
+	for _, s := range spans {
+		if !slices.Contains(numbers, s.loc.Start.Line) {
+			numbers = append(numbers, s.loc.Start.Line)
+		}
+	}
+	slices.Sort(numbers)
+
+	for i, number := range numbers {
+		if number < 1 || number > len(lines) {
+			continue
+		}
+		if i > 0 && number > numbers[i-1]+1 {
+			fmt.Fprintf(w, "%v\n", r.paint(bold+blue)+"..."+r.paint(reset))
+		}
+		line := lines[number-1]
+		fmt.Fprintf(w, "%v%*d %v%v %v\n", r.paint(bold+blue), width, number, "|", r.paint(reset), expandTabs(line))
+		for _, s := range spans {
+			if s.loc.Start.Line != number {
+				continue
+			}
+			fmt.Fprintf(w, "%v %v %v\n", gutter, pipe, r.underline(line, s, color))
+		}
+	}
+}
+
+func (r *Renderer) underline(line string, s span, color string) string {
+	start := min(max(s.loc.Start.Col-1, 0), len(line))
+	end := len(line)
+	if s.loc.End.Line == s.loc.Start.Line {
+		end = min(s.loc.End.Col-1, len(line))
+	}
+	length := max(end-start, 1)
+
+	mark, markColor := "-", blue
+	if s.primary {
+		mark, markColor = "^", color
+	}
+	text := strings.Repeat(mark, length)
+	if s.message != "" {
+		text += " " + s.message
+	}
+	indent := len(expandTabs(line[:start]))
+	return strings.Repeat(" ", indent) + r.paint(bold+markColor) + text + r.paint(reset)
+}
+
+// Tabs are expanded so that underlines stay aligned with the source
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
+
+func (r *Renderer) severityColor(s parser.Severity) string {
+	switch s {
+	case parser.WarningSeverity:
+		return yellow
+	case parser.InfoSeverity:
+		return cyan
+	default:
+		return red
+	}
+}
+
+// Escape sequences are only written when colors are enabled
+func (r *Renderer) paint(code string) string {
+	if !r.Color {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmelicque/test-parser/lint"
+	"github.com/bmelicque/test-parser/parser"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Render the diagnostics of the given source and compare them with testdata/<name>.golden
+func testGolden(t *testing.T, name string, source string, color bool) {
+	program, errors := parser.ParseProgram(strings.NewReader(source), "main")
+	r := NewRenderer(color)
+	r.SetSource("main", source)
+	var b bytes.Buffer
+	for _, err := range errors {
+		r.Render(&b, err.Diagnostic("main"))
+	}
+	for _, finding := range lint.Run(program, nil) {
+		r.Render(&b, finding)
+	}
+
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		os.WriteFile(golden, b.Bytes(), 0644)
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Cannot read golden file: %v", err)
+	}
+	if b.String() != string(expected) {
+		t.Fatalf("Expected:\n%v\nGot:\n%v", string(expected), b.String())
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	testGolden(t, "error", "_a := 1\n_b := _a + \"hello\"", false)
+}
+
+func TestRenderWarning(t *testing.T) {
+	testGolden(t, "warning", "_a := 1\nanswer :: 42", false)
+}
+
+func TestRenderLabels(t *testing.T) {
+	testGolden(t, "labels", "_Point :: {\n    x number,\n    y number,\n    x number,\n}", false)
+}
+
+func TestRenderFix(t *testing.T) {
+	testGolden(t, "fix", "_Point := { x number }", false)
+}
+
+func TestRenderColor(t *testing.T) {
+	testGolden(t, "color", "_a := 1\n_b := _a + \"hello\"", true)
+}
+
+func TestRenderOtherFile(t *testing.T) {
+	d := parser.Diagnostic{
+		Severity: parser.ErrorSeverity,
+		Code:     "K0027",
+		Message:  "Duplicate identifier 'answer'",
+		Location: parser.Location{Path: "main", Loc: parser.Loc{
+			Start: parser.Position{Line: 1, Col: 5},
+			End:   parser.Position{Line: 1, Col: 11},
+		}},
+		Labels: []parser.Label{{
+			Location: parser.Location{Path: "lib", Loc: parser.Loc{
+				Start: parser.Position{Line: 12, Col: 1},
+				End:   parser.Position{Line: 12, Col: 7},
+			}},
+			Message: "declared here",
+		}},
+		Notes: []string{"names imported with 'use' must be unique"},
+	}
+	r := NewRenderer(false)
+	r.SetSource("main", "use answer from \"./lib\"")
+	r.SetSource("lib", strings.Repeat("\n", 11)+"answer :: 42")
+	var b bytes.Buffer
+	r.Render(&b, d)
+
+	expected := "error[K0027]: Duplicate identifier 'answer'\n"
+	expected += "  --> main:1:5\n"
+	expected += "   |\n"
+	expected += " 1 | use answer from \"./lib\"\n"
+	expected += "   |     ^^^^^^\n"
+	expected += "  ::: lib:12:1\n"
+	expected += "   |\n"
+	expected += "12 | answer :: 42\n"
+	expected += "   | ------ declared here\n"
+	expected += "   = note: names imported with 'use' must be unique\n"
+	if b.String() != expected {
+		t.Fatalf("Expected:\n%v\nGot:\n%v", expected, b.String())
+	}
+}